@@ -0,0 +1,371 @@
+package collector
+
+import (
+	"context"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	defaultARCProps = `arc_meta_limit,arc_meta_used,c,c_max,c_min,data_size,demand_data_hits,demand_data_misses,demand_metadata_hits,demand_metadata_misses,hits,metadata_size,mfu_hits,mfu_size,misses,mru_hits,mru_size,size`
+)
+
+var (
+	arcProperties = propertyStore{
+		defaultSubsystem: subsystemARC,
+		store: map[string]property{
+			`arc_dnode_limit`: newProperty(
+				subsystemARC,
+				`dnode_limit_bytes`,
+				`Limit in bytes of dnodes in the ARC.`,
+				transformNumeric,
+			),
+			`arc_meta_limit`: newProperty(
+				subsystemARC,
+				`meta_limit_bytes`,
+				`Limit in bytes of metadata in the ARC.`,
+				transformNumeric,
+			),
+			`arc_meta_max`: newProperty(
+				subsystemARC,
+				`meta_max_bytes`,
+				`Maximum size in bytes of metadata observed in the ARC.`,
+				transformNumeric,
+			),
+			`arc_meta_min`: newProperty(
+				subsystemARC,
+				`meta_min_bytes`,
+				`Minimum size in bytes of metadata in the ARC.`,
+				transformNumeric,
+			),
+			`arc_meta_used`: newProperty(
+				subsystemARC,
+				`meta_used_bytes`,
+				`Size in bytes of metadata in the ARC.`,
+				transformNumeric,
+			),
+			`arc_no_grow`: newProperty(
+				subsystemARC,
+				`no_grow`,
+				`Whether the ARC is prevented from growing [0: may grow, 1: may not grow].`,
+				transformNumeric,
+			),
+			`arc_prune`: newCounterProperty(
+				subsystemARC,
+				`prunes_total`,
+				`Total number of ARC prune requests.`,
+				transformNumeric,
+			),
+			`bonus_size`: newProperty(
+				subsystemARC,
+				`bonus_size_bytes`,
+				`Size in bytes of bonus buffers in the ARC.`,
+				transformNumeric,
+			),
+			`c`: newProperty(
+				subsystemARC,
+				`target_size_bytes`,
+				`Target size in bytes of the ARC.`,
+				transformNumeric,
+			),
+			`c_max`: newProperty(
+				subsystemARC,
+				`target_max_size_bytes`,
+				`Maximum target size in bytes of the ARC.`,
+				transformNumeric,
+			),
+			`c_min`: newProperty(
+				subsystemARC,
+				`target_min_size_bytes`,
+				`Minimum target size in bytes of the ARC.`,
+				transformNumeric,
+			),
+			`compressed_size`: newProperty(
+				subsystemARC,
+				`compressed_size_bytes`,
+				`Compressed size in bytes of data stored in the ARC.`,
+				transformNumeric,
+			),
+			`data_size`: newProperty(
+				subsystemARC,
+				`data_size_bytes`,
+				`Size in bytes of data buffers in the ARC.`,
+				transformNumeric,
+			),
+			`dbuf_size`: newProperty(
+				subsystemARC,
+				`dbuf_size_bytes`,
+				`Size in bytes of dbufs in the ARC.`,
+				transformNumeric,
+			),
+			`deleted`: newCounterProperty(
+				subsystemARC,
+				`deleted_total`,
+				`Total number of buffers deleted from the ARC.`,
+				transformNumeric,
+			),
+			`demand_data_hits`: newCounterProperty(
+				subsystemARC,
+				`demand_data_hits_total`,
+				`Total number of ARC hits for demand data reads.`,
+				transformNumeric,
+			),
+			`demand_data_misses`: newCounterProperty(
+				subsystemARC,
+				`demand_data_misses_total`,
+				`Total number of ARC misses for demand data reads.`,
+				transformNumeric,
+			),
+			`demand_metadata_hits`: newCounterProperty(
+				subsystemARC,
+				`demand_metadata_hits_total`,
+				`Total number of ARC hits for demand metadata reads.`,
+				transformNumeric,
+			),
+			`demand_metadata_misses`: newCounterProperty(
+				subsystemARC,
+				`demand_metadata_misses_total`,
+				`Total number of ARC misses for demand metadata reads.`,
+				transformNumeric,
+			),
+			`dnode_size`: newProperty(
+				subsystemARC,
+				`dnode_size_bytes`,
+				`Size in bytes of dnodes in the ARC.`,
+				transformNumeric,
+			),
+			`evict_skip`: newCounterProperty(
+				subsystemARC,
+				`evict_skips_total`,
+				`Total number of buffers skipped during eviction.`,
+				transformNumeric,
+			),
+			`hash_collisions`: newCounterProperty(
+				subsystemARC,
+				`hash_collisions_total`,
+				`Total number of ARC hash table collisions.`,
+				transformNumeric,
+			),
+			`hash_elements`: newProperty(
+				subsystemARC,
+				`hash_elements`,
+				`Current number of elements in the ARC hash table.`,
+				transformNumeric,
+			),
+			`hdr_size`: newProperty(
+				subsystemARC,
+				`header_size_bytes`,
+				`Size in bytes of ARC buffer headers.`,
+				transformNumeric,
+			),
+			`hits`: newCounterProperty(
+				subsystemARC,
+				`hits_total`,
+				`Total number of ARC hits.`,
+				transformNumeric,
+			),
+			`l2_asize`: newProperty(
+				subsystemARC,
+				`l2_allocated_size_bytes`,
+				`Allocated size in bytes of data stored in the L2ARC.`,
+				transformNumeric,
+			),
+			`l2_hits`: newCounterProperty(
+				subsystemARC,
+				`l2_hits_total`,
+				`Total number of L2ARC hits.`,
+				transformNumeric,
+			),
+			`l2_misses`: newCounterProperty(
+				subsystemARC,
+				`l2_misses_total`,
+				`Total number of L2ARC misses.`,
+				transformNumeric,
+			),
+			`l2_size`: newProperty(
+				subsystemARC,
+				`l2_size_bytes`,
+				`Uncompressed size in bytes of data stored in the L2ARC.`,
+				transformNumeric,
+			),
+			`memory_available_bytes`: newProperty(
+				subsystemARC,
+				`memory_available_bytes`,
+				`Amount of memory in bytes available to the ARC.`,
+				transformNumeric,
+			),
+			`memory_throttle_count`: newCounterProperty(
+				subsystemARC,
+				`memory_throttles_total`,
+				`Total number of times the ARC throttled writes due to memory pressure.`,
+				transformNumeric,
+			),
+			`metadata_size`: newProperty(
+				subsystemARC,
+				`metadata_size_bytes`,
+				`Size in bytes of metadata buffers in the ARC.`,
+				transformNumeric,
+			),
+			`mfu_ghost_hits`: newCounterProperty(
+				subsystemARC,
+				`mfu_ghost_hits_total`,
+				`Total number of ARC hits on the most frequently used ghost list.`,
+				transformNumeric,
+			),
+			`mfu_ghost_size`: newProperty(
+				subsystemARC,
+				`mfu_ghost_size_bytes`,
+				`Size in bytes of the most frequently used ghost list.`,
+				transformNumeric,
+			),
+			`mfu_hits`: newCounterProperty(
+				subsystemARC,
+				`mfu_hits_total`,
+				`Total number of ARC hits on the most frequently used list.`,
+				transformNumeric,
+			),
+			`mfu_size`: newProperty(
+				subsystemARC,
+				`mfu_size_bytes`,
+				`Size in bytes of the most frequently used list.`,
+				transformNumeric,
+			),
+			`misses`: newCounterProperty(
+				subsystemARC,
+				`misses_total`,
+				`Total number of ARC misses.`,
+				transformNumeric,
+			),
+			`mru_ghost_hits`: newCounterProperty(
+				subsystemARC,
+				`mru_ghost_hits_total`,
+				`Total number of ARC hits on the most recently used ghost list.`,
+				transformNumeric,
+			),
+			`mru_ghost_size`: newProperty(
+				subsystemARC,
+				`mru_ghost_size_bytes`,
+				`Size in bytes of the most recently used ghost list.`,
+				transformNumeric,
+			),
+			`mru_hits`: newCounterProperty(
+				subsystemARC,
+				`mru_hits_total`,
+				`Total number of ARC hits on the most recently used list.`,
+				transformNumeric,
+			),
+			`mru_size`: newProperty(
+				subsystemARC,
+				`mru_size_bytes`,
+				`Size in bytes of the most recently used list.`,
+				transformNumeric,
+			),
+			`mutex_miss`: newCounterProperty(
+				subsystemARC,
+				`mutex_misses_total`,
+				`Total number of buffers that could not be evicted due to a held mutex.`,
+				transformNumeric,
+			),
+			`overhead_size`: newProperty(
+				subsystemARC,
+				`overhead_size_bytes`,
+				`Size in bytes of ARC buffers held temporarily uncompressed.`,
+				transformNumeric,
+			),
+			`p`: newProperty(
+				subsystemARC,
+				`mru_target_size_bytes`,
+				`Target size in bytes of the most recently used list.`,
+				transformNumeric,
+			),
+			`prefetch_data_hits`: newCounterProperty(
+				subsystemARC,
+				`prefetch_data_hits_total`,
+				`Total number of ARC hits for prefetched data reads.`,
+				transformNumeric,
+			),
+			`prefetch_data_misses`: newCounterProperty(
+				subsystemARC,
+				`prefetch_data_misses_total`,
+				`Total number of ARC misses for prefetched data reads.`,
+				transformNumeric,
+			),
+			`prefetch_metadata_hits`: newCounterProperty(
+				subsystemARC,
+				`prefetch_metadata_hits_total`,
+				`Total number of ARC hits for prefetched metadata reads.`,
+				transformNumeric,
+			),
+			`prefetch_metadata_misses`: newCounterProperty(
+				subsystemARC,
+				`prefetch_metadata_misses_total`,
+				`Total number of ARC misses for prefetched metadata reads.`,
+				transformNumeric,
+			),
+			`size`: newProperty(
+				subsystemARC,
+				`size_bytes`,
+				`Current size in bytes of the ARC.`,
+				transformNumeric,
+			),
+			`uncompressed_size`: newProperty(
+				subsystemARC,
+				`uncompressed_size_bytes`,
+				`Uncompressed size in bytes of data stored in the ARC.`,
+				transformNumeric,
+			),
+		},
+	}
+)
+
+func init() {
+	registerCollector(`arc`, defaultDisabled, defaultARCProps, newARCCollector)
+}
+
+type arcCollector struct {
+	log    log.Logger
+	client zfs.Client
+	props  []string
+}
+
+func (c *arcCollector) describe(ch chan<- *prometheus.Desc) {
+	for _, k := range c.props {
+		prop, err := arcProperties.find(k)
+		if err != nil {
+			_ = level.Warn(c.log).Log(`msg`, propertyUnsupportedMsg, `help`, helpIssue, `collector`, `arc`, `property`, k, `err`, err)
+			continue
+		}
+		ch <- prop.desc
+	}
+}
+
+func (c *arcCollector) update(ctx context.Context, ch chan<- metric, pools []string, excludes regexpCollection) error {
+	stats, err := c.client.Kstat(ctx, `zfs`, `arcstats`)
+	if err != nil {
+		return err
+	}
+
+	values := stats.Values()
+	for _, k := range c.props {
+		v, ok := values[k]
+		if !ok {
+			continue
+		}
+		prop, err := arcProperties.find(k)
+		if err != nil {
+			_ = level.Warn(c.log).Log(`msg`, propertyUnsupportedMsg, `help`, helpIssue, `collector`, `arc`, `property`, k, `err`, err)
+		}
+		if err = prop.push(ch, v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func newARCCollector(l log.Logger, c zfs.Client, props []string) (Collector, error) {
+	return &arcCollector{log: l, client: c, props: props}, nil
+}
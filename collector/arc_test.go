@@ -0,0 +1,93 @@
+package collector
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/pdf/zfs_exporter/v2/zfs/mock_zfs"
+)
+
+func TestARCMetrics(t *testing.T) {
+	testCases := []struct {
+		name           string
+		propsRequested []string
+		metricNames    []string
+		kstatResults   []zfs.KstatNamed
+		metricResults  string
+	}{
+		{
+			name:           `counters and gauges`,
+			propsRequested: []string{`hits`, `misses`, `size`, `c_max`, `arc_meta_used`},
+			metricNames:    []string{`zfs_arc_hits_total`, `zfs_arc_misses_total`, `zfs_arc_size_bytes`, `zfs_arc_target_max_size_bytes`, `zfs_arc_meta_used_bytes`},
+			kstatResults: []zfs.KstatNamed{
+				{Name: `hits`, Type: zfs.KstatDataUint64, Value: `2353461`},
+				{Name: `misses`, Type: zfs.KstatDataUint64, Value: `113428`},
+				{Name: `c_max`, Type: zfs.KstatDataUint64, Value: `8327274496`},
+				{Name: `size`, Type: zfs.KstatDataUint64, Value: `7984732160`},
+				{Name: `arc_meta_used`, Type: zfs.KstatDataUint64, Value: `1094007232`},
+				{Name: `mru_size`, Type: zfs.KstatDataUint64, Value: `2781282304`},
+			},
+			metricResults: `# HELP zfs_arc_hits_total Total number of ARC hits.
+# TYPE zfs_arc_hits_total counter
+zfs_arc_hits_total 2353461
+# HELP zfs_arc_meta_used_bytes Size in bytes of metadata in the ARC.
+# TYPE zfs_arc_meta_used_bytes gauge
+zfs_arc_meta_used_bytes 1094007232
+# HELP zfs_arc_misses_total Total number of ARC misses.
+# TYPE zfs_arc_misses_total counter
+zfs_arc_misses_total 113428
+# HELP zfs_arc_size_bytes Current size in bytes of the ARC.
+# TYPE zfs_arc_size_bytes gauge
+zfs_arc_size_bytes 7984732160
+# HELP zfs_arc_target_max_size_bytes Maximum target size in bytes of the ARC.
+# TYPE zfs_arc_target_max_size_bytes gauge
+zfs_arc_target_max_size_bytes 8327274496
+`,
+		},
+		{
+			name:           `missing stat`,
+			propsRequested: []string{`hits`, `l2_hits`},
+			metricNames:    []string{`zfs_arc_hits_total`, `zfs_arc_l2_hits_total`},
+			kstatResults: []zfs.KstatNamed{
+				{Name: `hits`, Type: zfs.KstatDataUint64, Value: `1024`},
+			},
+			metricResults: `# HELP zfs_arc_hits_total Total number of ARC hits.
+# TYPE zfs_arc_hits_total counter
+zfs_arc_hits_total 1024
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			zfsClient := mock_zfs.NewMockClient(ctrl)
+			config := defaultConfig(zfsClient)
+
+			zfsClient.EXPECT().PoolNames(gomock.Any()).Return([]string{`testpool`}, nil).Times(1)
+			zfsClient.EXPECT().Kstat(gomock.Any(), `zfs`, `arcstats`).Return(zfs.Kstat{Module: `zfs`, Name: `arcstats`, Data: tc.kstatResults}, nil).Times(1)
+
+			collector, err := NewZFS(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			collector.Collectors = map[string]State{
+				`arc`: {
+					Name:       "arc",
+					Enabled:    boolPointer(true),
+					Properties: stringPointer(strings.Join(tc.propsRequested, `,`)),
+					factory:    newARCCollector,
+				},
+			}
+
+			if err = callCollector(ctx, collector, []byte(tc.metricResults), tc.metricNames); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	bookmarkLabels = []string{`name`, `pool`}
+
+	bookmarkCountDescName = prometheus.BuildFQName(namespace, subsystemDataset, `bookmarks`)
+	bookmarkCountDesc     = prometheus.NewDesc(
+		bookmarkCountDescName,
+		`Number of bookmarks of this dataset.`,
+		bookmarkLabels,
+		nil,
+	)
+	bookmarkNewestDescName = prometheus.BuildFQName(namespace, subsystemDataset, `bookmark_newest_timestamp_seconds`)
+	bookmarkNewestDesc     = prometheus.NewDesc(
+		bookmarkNewestDescName,
+		`Unix timestamp of the creation of the newest bookmark of this dataset.`,
+		bookmarkLabels,
+		nil,
+	)
+	bookmarkNewestInfoDescName = prometheus.BuildFQName(namespace, subsystemDataset, `bookmark_newest_info`)
+	bookmarkNewestInfoDesc     = prometheus.NewDesc(
+		bookmarkNewestInfoDescName,
+		`The newest bookmark of this dataset, as the bookmark label. The value is always 1.`,
+		append(append([]string{}, bookmarkLabels...), `bookmark`),
+		nil,
+	)
+)
+
+// bookmarkAggregate holds the values derived from all bookmarks of a single dataset
+type bookmarkAggregate struct {
+	count  uint64
+	newest float64
+	name   string
+}
+
+// bookmarkAggregator rolls bookmarks up to the dataset they were created from.
+type bookmarkAggregator struct {
+	datasets map[string]*bookmarkAggregate
+}
+
+func (a *bookmarkAggregator) describe(ch chan<- *prometheus.Desc) {
+	ch <- bookmarkCountDesc
+	ch <- bookmarkNewestDesc
+	ch <- bookmarkNewestInfoDesc
+}
+
+func (a *bookmarkAggregator) observe(bookmark string, props map[string]string) error {
+	name := parentDataset(bookmark)
+	aggregate, ok := a.datasets[name]
+	if !ok {
+		aggregate = &bookmarkAggregate{}
+		a.datasets[name] = aggregate
+	}
+	aggregate.count++
+
+	v, ok := props[`creation`]
+	if !ok {
+		return nil
+	}
+	creation, err := transformNumeric(v)
+	if err != nil {
+		return err
+	}
+	if aggregate.name == `` || creation > aggregate.newest {
+		aggregate.newest = creation
+		aggregate.name = bookmark
+	}
+
+	return nil
+}
+
+func (a *bookmarkAggregator) push(ch chan<- metric, pool string) {
+	for name, aggregate := range a.datasets {
+		labelValues := []string{name, pool}
+		ch <- metric{
+			name:       expandMetricName(bookmarkCountDescName, labelValues...),
+			prometheus: prometheus.MustNewConstMetric(bookmarkCountDesc, prometheus.GaugeValue, float64(aggregate.count), labelValues...),
+		}
+		if aggregate.name == `` {
+			continue
+		}
+		ch <- metric{
+			name:       expandMetricName(bookmarkNewestDescName, labelValues...),
+			prometheus: prometheus.MustNewConstMetric(bookmarkNewestDesc, prometheus.GaugeValue, aggregate.newest, labelValues...),
+		}
+		ch <- metric{
+			name:       expandMetricName(bookmarkNewestInfoDescName, labelValues...),
+			prometheus: prometheus.MustNewConstMetric(bookmarkNewestInfoDesc, prometheus.GaugeValue, 1, append(labelValues, aggregate.name)...),
+		}
+	}
+}
+
+func newBookmarkAggregator() *bookmarkAggregator {
+	return &bookmarkAggregator{
+		datasets: make(map[string]*bookmarkAggregate),
+	}
+}
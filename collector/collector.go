@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -18,8 +19,14 @@ const (
 	helpDefaultStateEnabled  = `enabled`
 	helpDefaultStateDisabled = `disabled`
 
+	subsystemARC     = `arc`
 	subsystemDataset = `dataset`
 	subsystemPool    = `pool`
+	subsystemTxg     = `txg`
+
+	collectorFailed    = 0
+	collectorSucceeded = 1
+	collectorCancelled = -1
 
 	propertyUnsupportedDesc = `!!! This property is unsupported, results are likely to be undesirable, please file an issue at https://github.com/pdf/zfs_exporter/issues to have this property supported !!!`
 	propertyUnsupportedMsg  = `Unsupported dataset property, results are likely to be undesirable`
@@ -38,7 +45,7 @@ var (
 	scrapeSuccessDescName = prometheus.BuildFQName(namespace, `scrape`, `collector_success`)
 	scrapeSuccessDesc     = prometheus.NewDesc(
 		scrapeSuccessDescName,
-		`zfs_exporter: Whether a collector succeeded.`,
+		fmt.Sprintf("zfs_exporter: Whether a collector succeeded [%d: failed, %d: succeeded, %d: cancelled].", collectorFailed, collectorSucceeded, collectorCancelled),
 		[]string{`collector`},
 		nil,
 	)
@@ -60,10 +67,18 @@ type State struct {
 
 // Collector defines the minimum functionality for registering a collector
 type Collector interface {
-	update(ch chan<- metric, pools []string, excludes regexpCollection) error
+	update(ctx context.Context, ch chan<- metric, pools []string, excludes regexpCollection) error
 	describe(ch chan<- *prometheus.Desc)
 }
 
+// backgroundCollector is implemented by collectors that gather data independently of collection runs, from a
+// background task that runs until the context is done. Collectors are instantiated for each collection, so start may
+// be called repeatedly, and must only start the task once.
+type backgroundCollector interface {
+	Collector
+	start(ctx context.Context)
+}
+
 type metric struct {
 	name       string
 	prometheus prometheus.Metric
@@ -72,6 +87,7 @@ type metric struct {
 type property struct {
 	name      string
 	desc      *prometheus.Desc
+	valueType prometheus.ValueType
 	transform transformFunc
 }
 
@@ -84,7 +100,7 @@ func (p property) push(ch chan<- metric, value string, labelValues ...string) er
 		name: expandMetricName(p.name, labelValues...),
 		prometheus: prometheus.MustNewConstMetric(
 			p.desc,
-			prometheus.GaugeValue,
+			p.valueType,
 			v,
 			labelValues...,
 		),
@@ -115,19 +131,10 @@ func (p *propertyStore) find(name string) (property, error) {
 }
 
 func registerCollector(collector string, isDefaultEnabled bool, defaultProps string, factory factoryFunc) {
-	helpDefaultState := helpDefaultStateDisabled
-	if isDefaultEnabled {
-		helpDefaultState = helpDefaultStateEnabled
-	}
-
-	enabledFlagName := fmt.Sprintf("collector.%s", collector)
-	enabledFlagHelp := fmt.Sprintf("Enable the %s collector (default: %s)", collector, helpDefaultState)
-	enabledDefaultValue := fmt.Sprintf("%t", isDefaultEnabled)
+	enabledFlag := newCollectorEnabledFlag(collector, isDefaultEnabled)
 
 	propsFlagName := fmt.Sprintf("properties.%s", collector)
 	propsFlagHelp := fmt.Sprintf("Properties to include for the %s collector, comma-separated.", collector)
-
-	enabledFlag := kingpin.Flag(enabledFlagName, enabledFlagHelp).Default(enabledDefaultValue).Bool()
 	propsFlag := kingpin.Flag(propsFlagName, propsFlagHelp).Default(defaultProps).String()
 
 	collectorStates[collector] = State{
@@ -137,6 +144,29 @@ func registerCollector(collector string, isDefaultEnabled bool, defaultProps str
 	}
 }
 
+// registerCollectorWithoutProperties registers a collector that does not accept any properties, so that no
+// properties flag is created for it.
+func registerCollectorWithoutProperties(collector string, isDefaultEnabled bool, factory factoryFunc) {
+	collectorStates[collector] = State{
+		Enabled:    newCollectorEnabledFlag(collector, isDefaultEnabled),
+		Properties: new(string),
+		factory:    factory,
+	}
+}
+
+func newCollectorEnabledFlag(collector string, isDefaultEnabled bool) *bool {
+	helpDefaultState := helpDefaultStateDisabled
+	if isDefaultEnabled {
+		helpDefaultState = helpDefaultStateEnabled
+	}
+
+	enabledFlagName := fmt.Sprintf("collector.%s", collector)
+	enabledFlagHelp := fmt.Sprintf("Enable the %s collector (default: %s)", collector, helpDefaultState)
+	enabledDefaultValue := fmt.Sprintf("%t", isDefaultEnabled)
+
+	return kingpin.Flag(enabledFlagName, enabledFlagHelp).Default(enabledDefaultValue).Bool()
+}
+
 func expandMetricName(prefix string, context ...string) string {
 	return strings.Join(append(context, prefix), `-`)
 }
@@ -146,6 +176,13 @@ func newProperty(subsystem, metricName, helpText string, transform transformFunc
 	return property{
 		name:      name,
 		desc:      prometheus.NewDesc(name, helpText, labels, nil),
+		valueType: prometheus.GaugeValue,
 		transform: transform,
 	}
 }
+
+func newCounterProperty(subsystem, metricName, helpText string, transform transformFunc, labels ...string) property {
+	prop := newProperty(subsystem, metricName, helpText, transform, labels...)
+	prop.valueType = prometheus.CounterValue
+	return prop
+}
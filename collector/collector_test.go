@@ -3,12 +3,14 @@ package collector
 import (
 	"bytes"
 	"context"
+	"testing"
 	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/pdf/zfs_exporter/v2/zfs"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gopkg.in/alecthomas/kingpin.v2"
 )
 
 var (
@@ -46,3 +48,20 @@ func stringPointer(s string) *string {
 func boolPointer(b bool) *bool {
 	return &b
 }
+
+func TestRegisterCollectorProperties(t *testing.T) {
+	for collector, expected := range map[string]bool{
+		`pool`:          true,
+		`pool-features`: true,
+		`pool-disks`:    false,
+		`txg`:           false,
+		`version`:       false,
+	} {
+		if flag := kingpin.CommandLine.GetFlag(`properties.` + collector); (flag != nil) != expected {
+			t.Fatalf("Expected properties flag for %s collector: %t", collector, expected)
+		}
+		if state := collectorStates[collector]; state.Properties == nil {
+			t.Fatalf("Expected properties for %s collector to be set", collector)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"fmt"
 	"sync"
 
@@ -8,16 +9,48 @@ import (
 	"github.com/go-kit/log/level"
 	"github.com/pdf/zfs_exporter/v2/zfs"
 	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
 )
 
 const (
 	defaultFilesystemProps = `available,logicalused,quota,referenced,used,usedbydataset,written`
 	defaultSnapshotProps   = `logicalused,referenced,used,written`
+	defaultBookmarkProps   = `creation`
 	defaultVolumeProps     = `available,logicalused,referenced,used,usedbydataset,volsize,written`
 )
 
 var (
-	datasetLabels     = []string{`name`, `pool`, `type`}
+	datasetLabels       = []string{`name`, `pool`, `type`}
+	datasetInfoDescName = prometheus.BuildFQName(namespace, subsystemDataset, `info`)
+	// datasetInfoProperties are string-valued properties, which are reported as labels of the info metric rather than
+	// as individual metrics. Every property is always a label, so that the label names are consistent regardless of the
+	// properties selected for each collector.
+	datasetInfoProperties = []string{
+		`atime`,
+		`canmount`,
+		`checksum`,
+		`compression`,
+		`dedup`,
+		`encryption`,
+		`encryptionroot`,
+		`keyformat`,
+		`keylocation`,
+		`keystatus`,
+		`logbias`,
+		`mounted`,
+		`mountpoint`,
+		`primarycache`,
+		`recordsize`,
+		`secondarycache`,
+		`sync`,
+		`volblocksize`,
+	}
+	datasetInfoDesc = prometheus.NewDesc(
+		datasetInfoDescName,
+		`Information about this dataset, with the value of each selected string property as a label, properties that are not selected or do not apply to the dataset type are empty. The value is always 1.`,
+		append(append([]string{}, datasetLabels...), datasetInfoProperties...),
+		nil,
+	)
 	datasetProperties = propertyStore{
 		defaultSubsystem: subsystemDataset,
 		defaultLabels:    datasetLabels,
@@ -36,6 +69,13 @@ var (
 				transformMultiplier,
 				datasetLabels...,
 			),
+			`creation`: newProperty(
+				subsystemDataset,
+				`creation_timestamp_seconds`,
+				`Unix timestamp of the creation of this filesystem, volume or bookmark. Not reported per snapshot, selecting it for snapshots instead reports the newest and oldest snapshot timestamps and the number of snapshots of each dataset, as "snapshot_newest_timestamp_seconds", "snapshot_oldest_timestamp_seconds" and "snapshots".`,
+				transformNumeric,
+				datasetLabels...,
+			),
 			`logicalused`: newProperty(
 				subsystemDataset,
 				`logical_used_bytes`,
@@ -160,20 +200,64 @@ var (
 )
 
 func init() {
+	aggregate := kingpin.Flag(`collector.dataset-snapshot.aggregate`, `Report snapshots of each dataset as aggregates (count, sum of used and written, max referenced), rather than a series per snapshot.`).Default(`false`).Bool()
+	registerCollector(`dataset-bookmark`, defaultDisabled, defaultBookmarkProps, newBookmarkCollector)
 	registerCollector(`dataset-filesystem`, defaultEnabled, defaultFilesystemProps, newFilesystemCollector)
-	registerCollector(`dataset-snapshot`, defaultDisabled, defaultSnapshotProps, newSnapshotCollector)
+	registerCollector(`dataset-snapshot`, defaultDisabled, defaultSnapshotProps, newSnapshotCollectorFactory(aggregate))
 	registerCollector(`dataset-volume`, defaultEnabled, defaultVolumeProps, newVolumeCollector)
 }
 
 type datasetCollector struct {
-	kind   zfs.DatasetKind
-	log    log.Logger
-	client zfs.Client
-	props  []string
+	kind      zfs.DatasetKind
+	log       log.Logger
+	client    zfs.Client
+	props     []string
+	aggregate bool
+}
+
+// datasetAggregator rolls the properties of snapshots or bookmarks up to the dataset they belong to.
+type datasetAggregator interface {
+	describe(ch chan<- *prometheus.Desc)
+	observe(name string, props map[string]string) error
+	push(ch chan<- metric, pool string)
+}
+
+// datasetInfo reports the requested string-valued properties of each dataset as labels of a single metric.
+type datasetInfo struct {
+	props map[string]bool
+}
+
+func (i *datasetInfo) push(ch chan<- metric, props map[string]string, labelValues ...string) {
+	values := make([]string, 0, len(labelValues)+len(datasetInfoProperties))
+	values = append(values, labelValues...)
+	for _, k := range datasetInfoProperties {
+		var v string
+		if i.props[k] {
+			v = props[k]
+		}
+		values = append(values, v)
+	}
+	ch <- metric{
+		name:       expandMetricName(datasetInfoDescName, labelValues...),
+		prometheus: prometheus.MustNewConstMetric(datasetInfoDesc, prometheus.GaugeValue, 1, values...),
+	}
 }
 
 func (c *datasetCollector) describe(ch chan<- *prometheus.Desc) {
+	if c.aggregate {
+		newSnapshotAggregator(c.aggregateProps()...).describe(ch)
+		return
+	}
+	if aggregator := c.aggregator(); aggregator != nil {
+		aggregator.describe(ch)
+	}
+	if info := c.info(); info != nil {
+		ch <- datasetInfoDesc
+	}
 	for _, k := range c.props {
+		if c.aggregated(k) || isDatasetInfoProperty(k) {
+			continue
+		}
 		prop, err := datasetProperties.find(k)
 		if err != nil {
 			_ = level.Warn(c.log).Log(`msg`, propertyUnsupportedMsg, `help`, helpIssue, `collector`, c.kind, `property`, k, `err`, err)
@@ -183,13 +267,13 @@ func (c *datasetCollector) describe(ch chan<- *prometheus.Desc) {
 	}
 }
 
-func (c *datasetCollector) update(ch chan<- metric, pools []string, excludes regexpCollection) error {
+func (c *datasetCollector) update(ctx context.Context, ch chan<- metric, pools []string, excludes regexpCollection) error {
 	var wg sync.WaitGroup
 	errChan := make(chan error, len(pools))
 	for _, pool := range pools {
 		wg.Add(1)
 		go func(pool string) {
-			if err := c.updatePoolMetrics(ch, pool, excludes); err != nil {
+			if err := c.updatePoolMetrics(ctx, ch, pool, excludes); err != nil {
 				errChan <- err
 			}
 			wg.Done()
@@ -205,29 +289,133 @@ func (c *datasetCollector) update(ch chan<- metric, pools []string, excludes reg
 	}
 }
 
-func (c *datasetCollector) updatePoolMetrics(ch chan<- metric, pool string, excludes regexpCollection) error {
+func (c *datasetCollector) updatePoolMetrics(ctx context.Context, ch chan<- metric, pool string, excludes regexpCollection) error {
+	if c.aggregate {
+		return c.updatePoolAggregates(ctx, ch, pool, excludes)
+	}
+
 	datasets := c.client.Datasets(pool, c.kind)
-	props, err := datasets.Properties(c.props...)
+	props, err := datasets.Properties(ctx, c.props...)
 	if err != nil {
 		return err
 	}
 
+	aggregator := c.aggregator()
+	info := c.info()
 	for _, dataset := range props {
 		if excludes.MatchString(dataset.DatasetName()) {
 			continue
 		}
-		if err = c.updateDatasetMetrics(ch, pool, dataset); err != nil {
+		if err = c.updateDatasetMetrics(ch, pool, dataset, aggregator, info); err != nil {
+			return err
+		}
+	}
+	if aggregator != nil {
+		aggregator.push(ch, pool)
+	}
+
+	return nil
+}
+
+// updatePoolAggregates reports only the per-dataset snapshot aggregates, listing just the properties required to
+// produce them.
+func (c *datasetCollector) updatePoolAggregates(ctx context.Context, ch chan<- metric, pool string, excludes regexpCollection) error {
+	props := c.aggregateProps()
+	if len(props) == 0 {
+		props = []string{`creation`}
+	}
+	datasets, err := c.client.Datasets(pool, c.kind).List(ctx, props...)
+	if err != nil {
+		return err
+	}
+
+	snapshots := newSnapshotAggregator(c.aggregateProps()...)
+	for _, dataset := range datasets {
+		if excludes.MatchString(dataset.DatasetName()) {
+			continue
+		}
+		if err = snapshots.observe(dataset.DatasetName(), dataset.Properties()); err != nil {
 			return err
 		}
 	}
+	snapshots.push(ch, pool)
+
+	return nil
+}
+
+// aggregateProps returns the requested properties that may be aggregated per dataset.
+func (c *datasetCollector) aggregateProps() []string {
+	props := make([]string, 0, len(snapshotAggregateProps))
+	for _, k := range c.props {
+		if snapshotAggregateProps[k] {
+			props = append(props, k)
+		}
+	}
+	return props
+}
+
+// info returns the info metric for the requested string-valued properties, or nil if none were requested.
+func (c *datasetCollector) info() *datasetInfo {
+	props := make(map[string]bool)
+	for _, k := range c.props {
+		if isDatasetInfoProperty(k) {
+			props[k] = true
+		}
+	}
+	if len(props) == 0 {
+		return nil
+	}
+
+	return &datasetInfo{props: props}
+}
+
+func isDatasetInfoProperty(k string) bool {
+	for _, p := range datasetInfoProperties {
+		if p == k {
+			return true
+		}
+	}
+	return false
+}
 
+// aggregator returns the aggregator that rolls snapshots or bookmarks up to their dataset, or nil if none applies.
+func (c *datasetCollector) aggregator() datasetAggregator {
+	switch c.kind {
+	case zfs.DatasetSnapshot:
+		for _, k := range c.props {
+			if k == `creation` {
+				return newSnapshotAggregator(`creation`)
+			}
+		}
+	case zfs.DatasetBookmark:
+		return newBookmarkAggregator()
+	}
 	return nil
 }
 
-func (c *datasetCollector) updateDatasetMetrics(ch chan<- metric, pool string, dataset zfs.DatasetProperties) error {
-	labelValues := []string{dataset.DatasetName(), pool, string(c.kind)}
+// aggregated reports whether the property is only reported via per-dataset aggregates, rather than per-snapshot.
+func (c *datasetCollector) aggregated(k string) bool {
+	return c.kind == zfs.DatasetSnapshot && k == `creation`
+}
+
+func (c *datasetCollector) updateDatasetMetrics(ch chan<- metric, pool string, dataset zfs.DatasetProperties, aggregator datasetAggregator, info *datasetInfo) error {
+	name := dataset.DatasetName()
+	labelValues := []string{name, pool, string(c.kind)}
+	props := dataset.Properties()
+	if aggregator != nil {
+		if err := aggregator.observe(name, props); err != nil {
+			return err
+		}
+	}
+
+	if info != nil {
+		info.push(ch, props, labelValues...)
+	}
 
-	for k, v := range dataset.Properties() {
+	for k, v := range props {
+		if c.aggregated(k) || isDatasetInfoProperty(k) {
+			continue
+		}
 		prop, err := datasetProperties.find(k)
 		if err != nil {
 			_ = level.Warn(c.log).Log(`msg`, propertyUnsupportedMsg, `help`, helpIssue, `collector`, c.kind, `property`, k, `err`, err)
@@ -242,7 +430,7 @@ func (c *datasetCollector) updateDatasetMetrics(ch chan<- metric, pool string, d
 
 func newDatasetCollector(kind zfs.DatasetKind, l log.Logger, c zfs.Client, props []string) (Collector, error) {
 	switch kind {
-	case zfs.DatasetFilesystem, zfs.DatasetSnapshot, zfs.DatasetVolume:
+	case zfs.DatasetFilesystem, zfs.DatasetSnapshot, zfs.DatasetVolume, zfs.DatasetBookmark:
 	default:
 		return nil, fmt.Errorf("unknown dataset type: %s", kind)
 	}
@@ -250,12 +438,18 @@ func newDatasetCollector(kind zfs.DatasetKind, l log.Logger, c zfs.Client, props
 	return &datasetCollector{kind: kind, log: l, client: c, props: props}, nil
 }
 
+func newBookmarkCollector(l log.Logger, c zfs.Client, props []string) (Collector, error) {
+	return newDatasetCollector(zfs.DatasetBookmark, l, c, props)
+}
+
 func newFilesystemCollector(l log.Logger, c zfs.Client, props []string) (Collector, error) {
 	return newDatasetCollector(zfs.DatasetFilesystem, l, c, props)
 }
 
-func newSnapshotCollector(l log.Logger, c zfs.Client, props []string) (Collector, error) {
-	return newDatasetCollector(zfs.DatasetSnapshot, l, c, props)
+func newSnapshotCollectorFactory(aggregate *bool) factoryFunc {
+	return func(l log.Logger, c zfs.Client, props []string) (Collector, error) {
+		return &datasetCollector{kind: zfs.DatasetSnapshot, log: l, client: c, props: props, aggregate: *aggregate}, nil
+	}
 }
 
 func newVolumeCollector(l log.Logger, c zfs.Client, props []string) (Collector, error) {
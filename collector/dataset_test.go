@@ -8,6 +8,7 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/pdf/zfs_exporter/v2/zfs"
 	"github.com/pdf/zfs_exporter/v2/zfs/mock_zfs"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 type datasetResults struct {
@@ -184,6 +185,139 @@ zfs_dataset_available_bytes{name="testpool1/test",pool="testpool1",type="filesys
 zfs_dataset_available_bytes{name="testpool/test",pool="testpool",type="filesystem"} 1024
 zfs_dataset_available_bytes{name="testpool/test",pool="testpool",type="snapshot"} 1024
 zfs_dataset_available_bytes{name="testpool/test",pool="testpool",type="volume"} 1024
+`,
+		},
+		{
+			name:           `snapshot creation`,
+			kinds:          []zfs.DatasetKind{zfs.DatasetSnapshot},
+			pools:          []string{`testpool`},
+			propsRequested: []string{`creation`, `used`},
+			metricNames:    []string{`zfs_dataset_creation_timestamp_seconds`, `zfs_dataset_snapshot_newest_timestamp_seconds`, `zfs_dataset_snapshot_oldest_timestamp_seconds`, `zfs_dataset_snapshots`, `zfs_dataset_used_bytes`},
+			propsResults: map[string][]datasetResults{
+				`testpool`: {
+					{
+						name: `testpool/test@daily-1`,
+						results: map[string]string{
+							`creation`: `1660000000`,
+							`used`:     `1024`,
+						},
+					},
+					{
+						name: `testpool/test@daily-2`,
+						results: map[string]string{
+							`creation`: `1660086400`,
+							`used`:     `2048`,
+						},
+					},
+					{
+						name: `testpool/other@daily-1`,
+						results: map[string]string{
+							`creation`: `1660000000`,
+							`used`:     `0`,
+						},
+					},
+				},
+			},
+			metricResults: `# HELP zfs_dataset_snapshot_newest_timestamp_seconds Unix timestamp of the creation of the newest snapshot of this dataset.
+# TYPE zfs_dataset_snapshot_newest_timestamp_seconds gauge
+zfs_dataset_snapshot_newest_timestamp_seconds{name="testpool/other",pool="testpool"} 1.66e+09
+zfs_dataset_snapshot_newest_timestamp_seconds{name="testpool/test",pool="testpool"} 1.6600864e+09
+# HELP zfs_dataset_snapshot_oldest_timestamp_seconds Unix timestamp of the creation of the oldest snapshot of this dataset.
+# TYPE zfs_dataset_snapshot_oldest_timestamp_seconds gauge
+zfs_dataset_snapshot_oldest_timestamp_seconds{name="testpool/other",pool="testpool"} 1.66e+09
+zfs_dataset_snapshot_oldest_timestamp_seconds{name="testpool/test",pool="testpool"} 1.66e+09
+# HELP zfs_dataset_snapshots Number of snapshots of this dataset.
+# TYPE zfs_dataset_snapshots gauge
+zfs_dataset_snapshots{name="testpool/other",pool="testpool"} 1
+zfs_dataset_snapshots{name="testpool/test",pool="testpool"} 2
+# HELP zfs_dataset_used_bytes The amount of space in bytes consumed by this dataset and all its descendents.
+# TYPE zfs_dataset_used_bytes gauge
+zfs_dataset_used_bytes{name="testpool/other@daily-1",pool="testpool",type="snapshot"} 0
+zfs_dataset_used_bytes{name="testpool/test@daily-1",pool="testpool",type="snapshot"} 1024
+zfs_dataset_used_bytes{name="testpool/test@daily-2",pool="testpool",type="snapshot"} 2048
+`,
+		},
+		{
+			name:           `bookmarks`,
+			kinds:          []zfs.DatasetKind{zfs.DatasetBookmark},
+			pools:          []string{`testpool`},
+			propsRequested: []string{`creation`},
+			metricNames:    []string{`zfs_dataset_creation_timestamp_seconds`, `zfs_dataset_bookmarks`, `zfs_dataset_bookmark_newest_timestamp_seconds`, `zfs_dataset_bookmark_newest_info`},
+			propsResults: map[string][]datasetResults{
+				`testpool`: {
+					{
+						name: `testpool/test#repl-2`,
+						results: map[string]string{
+							`creation`: `1660086400`,
+						},
+					},
+					{
+						name: `testpool/test#repl-1`,
+						results: map[string]string{
+							`creation`: `1660000000`,
+						},
+					},
+					{
+						name: `testpool/other#repl-1`,
+						results: map[string]string{
+							`creation`: `1660000000`,
+						},
+					},
+				},
+			},
+			metricResults: `# HELP zfs_dataset_bookmark_newest_info The newest bookmark of this dataset, as the bookmark label. The value is always 1.
+# TYPE zfs_dataset_bookmark_newest_info gauge
+zfs_dataset_bookmark_newest_info{bookmark="testpool/other#repl-1",name="testpool/other",pool="testpool"} 1
+zfs_dataset_bookmark_newest_info{bookmark="testpool/test#repl-2",name="testpool/test",pool="testpool"} 1
+# HELP zfs_dataset_bookmark_newest_timestamp_seconds Unix timestamp of the creation of the newest bookmark of this dataset.
+# TYPE zfs_dataset_bookmark_newest_timestamp_seconds gauge
+zfs_dataset_bookmark_newest_timestamp_seconds{name="testpool/other",pool="testpool"} 1.66e+09
+zfs_dataset_bookmark_newest_timestamp_seconds{name="testpool/test",pool="testpool"} 1.6600864e+09
+# HELP zfs_dataset_bookmarks Number of bookmarks of this dataset.
+# TYPE zfs_dataset_bookmarks gauge
+zfs_dataset_bookmarks{name="testpool/other",pool="testpool"} 1
+zfs_dataset_bookmarks{name="testpool/test",pool="testpool"} 2
+# HELP zfs_dataset_creation_timestamp_seconds Unix timestamp of the creation of this filesystem, volume or bookmark. Not reported per snapshot, selecting it for snapshots instead reports the newest and oldest snapshot timestamps and the number of snapshots of each dataset, as "snapshot_newest_timestamp_seconds", "snapshot_oldest_timestamp_seconds" and "snapshots".
+# TYPE zfs_dataset_creation_timestamp_seconds gauge
+zfs_dataset_creation_timestamp_seconds{name="testpool/other#repl-1",pool="testpool",type="bookmark"} 1.66e+09
+zfs_dataset_creation_timestamp_seconds{name="testpool/test#repl-1",pool="testpool",type="bookmark"} 1.66e+09
+zfs_dataset_creation_timestamp_seconds{name="testpool/test#repl-2",pool="testpool",type="bookmark"} 1.6600864e+09
+`,
+		},
+		{
+			name:           `info properties`,
+			kinds:          []zfs.DatasetKind{zfs.DatasetFilesystem},
+			pools:          []string{`testpool`},
+			propsRequested: []string{`compression`, `mountpoint`, `used`},
+			metricNames:    []string{`zfs_dataset_info`, `zfs_dataset_used_bytes`},
+			propsResults: map[string][]datasetResults{
+				`testpool`: {
+					{
+						name: `testpool/test`,
+						results: map[string]string{
+							`compression`: `lz4`,
+							`mountpoint`:  `/testpool/test`,
+							`used`:        `1024`,
+						},
+					},
+					{
+						name: `testpool/legacy`,
+						results: map[string]string{
+							`compression`: `off`,
+							`mountpoint`:  `legacy`,
+							`used`:        `2048`,
+						},
+					},
+				},
+			},
+			metricResults: `# HELP zfs_dataset_info Information about this dataset, with the value of each selected string property as a label, properties that are not selected or do not apply to the dataset type are empty. The value is always 1.
+# TYPE zfs_dataset_info gauge
+zfs_dataset_info{atime="",canmount="",checksum="",compression="lz4",dedup="",encryption="",encryptionroot="",keyformat="",keylocation="",keystatus="",logbias="",mounted="",mountpoint="/testpool/test",name="testpool/test",pool="testpool",primarycache="",recordsize="",secondarycache="",sync="",type="filesystem",volblocksize=""} 1
+zfs_dataset_info{atime="",canmount="",checksum="",compression="off",dedup="",encryption="",encryptionroot="",keyformat="",keylocation="",keystatus="",logbias="",mounted="",mountpoint="legacy",name="testpool/legacy",pool="testpool",primarycache="",recordsize="",secondarycache="",sync="",type="filesystem",volblocksize=""} 1
+# HELP zfs_dataset_used_bytes The amount of space in bytes consumed by this dataset and all its descendents.
+# TYPE zfs_dataset_used_bytes gauge
+zfs_dataset_used_bytes{name="testpool/legacy",pool="testpool",type="filesystem"} 2048
+zfs_dataset_used_bytes{name="testpool/test",pool="testpool",type="filesystem"} 1024
 `,
 		},
 		{
@@ -220,7 +354,7 @@ zfs_dataset_unsupported{name="testpool/test",pool="testpool",type="filesystem"}
 				config.Pools = tc.explicitPools
 			}
 
-			zfsClient.EXPECT().PoolNames().Return(tc.pools, nil).Times(1)
+			zfsClient.EXPECT().PoolNames(gomock.Any()).Return(tc.pools, nil).Times(1)
 			collector, err := NewZFS(config)
 			if err != nil {
 				t.Fatal(err)
@@ -241,7 +375,7 @@ zfs_dataset_unsupported{name="testpool/test",pool="testpool",type="filesystem"}
 						Name:       "dataset-snapshot",
 						Enabled:    boolPointer(true),
 						Properties: stringPointer(strings.Join(tc.propsRequested, `,`)),
-						factory:    newSnapshotCollector,
+						factory:    newSnapshotCollectorFactory(boolPointer(false)),
 					}
 				case zfs.DatasetVolume:
 					collector.Collectors[`dataset-volume`] = State{
@@ -250,6 +384,13 @@ zfs_dataset_unsupported{name="testpool/test",pool="testpool",type="filesystem"}
 						Properties: stringPointer(strings.Join(tc.propsRequested, `,`)),
 						factory:    newVolumeCollector,
 					}
+				case zfs.DatasetBookmark:
+					collector.Collectors[`dataset-bookmark`] = State{
+						Name:       "dataset-bookmark",
+						Enabled:    boolPointer(true),
+						Properties: stringPointer(strings.Join(tc.propsRequested, `,`)),
+						factory:    newBookmarkCollector,
+					}
 				}
 				for _, pool := range tc.pools {
 					if tc.explicitPools != nil {
@@ -272,7 +413,7 @@ zfs_dataset_unsupported{name="testpool/test",pool="testpool",type="filesystem"}
 						zfsDatasetResults[i] = zfsDatasetProperties
 					}
 					zfsDatasets := mock_zfs.NewMockDatasets(ctrl)
-					zfsDatasets.EXPECT().Properties(tc.propsRequested).Return(zfsDatasetResults, nil).Times(1)
+					zfsDatasets.EXPECT().Properties(gomock.Any(), tc.propsRequested).Return(zfsDatasetResults, nil).Times(1)
 					zfsClient.EXPECT().Datasets(pool, kind).Return(zfsDatasets).Times(1)
 				}
 			}
@@ -283,3 +424,167 @@ zfs_dataset_unsupported{name="testpool/test",pool="testpool",type="filesystem"}
 		})
 	}
 }
+
+func TestDatasetInfoMixedProperties(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	config := defaultConfig(zfsClient)
+
+	zfsClient.EXPECT().PoolNames(gomock.Any()).Return([]string{`testpool`}, nil).Times(1)
+	collector, err := NewZFS(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collector.Collectors = map[string]State{
+		`dataset-filesystem`: {
+			Name:       "dataset-filesystem",
+			Enabled:    boolPointer(true),
+			Properties: stringPointer(`used,mountpoint`),
+			factory:    newFilesystemCollector,
+		},
+		`dataset-volume`: {
+			Name:       "dataset-volume",
+			Enabled:    boolPointer(true),
+			Properties: stringPointer(`used,volblocksize`),
+			factory:    newVolumeCollector,
+		},
+	}
+
+	// Registration fails if the descriptors of the collectors have inconsistent label names.
+	if err = prometheus.NewRegistry().Register(collector); err != nil {
+		t.Fatal(err)
+	}
+
+	for kind, result := range map[zfs.DatasetKind]datasetResults{
+		zfs.DatasetFilesystem: {name: `testpool/fs`, results: map[string]string{`used`: `1024`, `mountpoint`: `/testpool/fs`}},
+		zfs.DatasetVolume:     {name: `testpool/vol`, results: map[string]string{`used`: `2048`, `volblocksize`: `16384`}},
+	} {
+		zfsDatasetProperties := mock_zfs.NewMockDatasetProperties(ctrl)
+		zfsDatasetProperties.EXPECT().DatasetName().Return(result.name).Times(2)
+		zfsDatasetProperties.EXPECT().Properties().Return(result.results).Times(1)
+		zfsDatasets := mock_zfs.NewMockDatasets(ctrl)
+		zfsDatasets.EXPECT().Properties(gomock.Any(), gomock.Any()).Return([]zfs.DatasetProperties{zfsDatasetProperties}, nil).Times(1)
+		zfsClient.EXPECT().Datasets(`testpool`, kind).Return(zfsDatasets).Times(1)
+	}
+
+	metricResults := `# HELP zfs_dataset_info Information about this dataset, with the value of each selected string property as a label, properties that are not selected or do not apply to the dataset type are empty. The value is always 1.
+# TYPE zfs_dataset_info gauge
+zfs_dataset_info{atime="",canmount="",checksum="",compression="",dedup="",encryption="",encryptionroot="",keyformat="",keylocation="",keystatus="",logbias="",mounted="",mountpoint="/testpool/fs",name="testpool/fs",pool="testpool",primarycache="",recordsize="",secondarycache="",sync="",type="filesystem",volblocksize=""} 1
+zfs_dataset_info{atime="",canmount="",checksum="",compression="",dedup="",encryption="",encryptionroot="",keyformat="",keylocation="",keystatus="",logbias="",mounted="",mountpoint="",name="testpool/vol",pool="testpool",primarycache="",recordsize="",secondarycache="",sync="",type="volume",volblocksize="16384"} 1
+`
+	if err = callCollector(ctx, collector, []byte(metricResults), []string{`zfs_dataset_info`}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSnapshotAggregateMetrics(t *testing.T) {
+	testCases := []struct {
+		name           string
+		propsRequested []string
+		propsQueried   []string
+		metricNames    []string
+		propsResults   []datasetResults
+		metricResults  string
+	}{
+		{
+			name:           `all aggregates`,
+			propsRequested: []string{`creation`, `logicalused`, `referenced`, `used`, `written`},
+			propsQueried:   []string{`creation`, `referenced`, `used`, `written`},
+			metricNames:    []string{`zfs_dataset_logical_used_bytes`, `zfs_dataset_snapshot_max_referenced_bytes`, `zfs_dataset_snapshot_newest_timestamp_seconds`, `zfs_dataset_snapshot_oldest_timestamp_seconds`, `zfs_dataset_snapshot_used_bytes`, `zfs_dataset_snapshot_written_bytes`, `zfs_dataset_snapshots`, `zfs_dataset_used_bytes`},
+			propsResults: []datasetResults{
+				{
+					name:    `testpool/test@daily-1`,
+					results: map[string]string{`creation`: `1660000000`, `referenced`: `4096`, `used`: `1024`, `written`: `4096`},
+				},
+				{
+					name:    `testpool/test@daily-2`,
+					results: map[string]string{`creation`: `1660086400`, `referenced`: `8192`, `used`: `2048`, `written`: `4096`},
+				},
+				{
+					name:    `testpool/other@daily-1`,
+					results: map[string]string{`creation`: `1660000000`, `referenced`: `512`, `used`: `0`, `written`: `512`},
+				},
+			},
+			metricResults: `# HELP zfs_dataset_snapshot_max_referenced_bytes Maximum amount of data in bytes accessible by any snapshot of this dataset.
+# TYPE zfs_dataset_snapshot_max_referenced_bytes gauge
+zfs_dataset_snapshot_max_referenced_bytes{name="testpool/other",pool="testpool"} 512
+zfs_dataset_snapshot_max_referenced_bytes{name="testpool/test",pool="testpool"} 8192
+# HELP zfs_dataset_snapshot_newest_timestamp_seconds Unix timestamp of the creation of the newest snapshot of this dataset.
+# TYPE zfs_dataset_snapshot_newest_timestamp_seconds gauge
+zfs_dataset_snapshot_newest_timestamp_seconds{name="testpool/other",pool="testpool"} 1.66e+09
+zfs_dataset_snapshot_newest_timestamp_seconds{name="testpool/test",pool="testpool"} 1.6600864e+09
+# HELP zfs_dataset_snapshot_oldest_timestamp_seconds Unix timestamp of the creation of the oldest snapshot of this dataset.
+# TYPE zfs_dataset_snapshot_oldest_timestamp_seconds gauge
+zfs_dataset_snapshot_oldest_timestamp_seconds{name="testpool/other",pool="testpool"} 1.66e+09
+zfs_dataset_snapshot_oldest_timestamp_seconds{name="testpool/test",pool="testpool"} 1.66e+09
+# HELP zfs_dataset_snapshot_used_bytes Sum of the space in bytes consumed by each snapshot of this dataset. Space shared between snapshots is not included, see the "used_by_snapshot_bytes" property.
+# TYPE zfs_dataset_snapshot_used_bytes gauge
+zfs_dataset_snapshot_used_bytes{name="testpool/other",pool="testpool"} 0
+zfs_dataset_snapshot_used_bytes{name="testpool/test",pool="testpool"} 3072
+# HELP zfs_dataset_snapshot_written_bytes Sum of the referenced space in bytes written between each snapshot of this dataset and the previous snapshot.
+# TYPE zfs_dataset_snapshot_written_bytes gauge
+zfs_dataset_snapshot_written_bytes{name="testpool/other",pool="testpool"} 512
+zfs_dataset_snapshot_written_bytes{name="testpool/test",pool="testpool"} 8192
+# HELP zfs_dataset_snapshots Number of snapshots of this dataset.
+# TYPE zfs_dataset_snapshots gauge
+zfs_dataset_snapshots{name="testpool/other",pool="testpool"} 1
+zfs_dataset_snapshots{name="testpool/test",pool="testpool"} 2
+`,
+		},
+		{
+			name:           `count only`,
+			propsRequested: []string{`logicalused`},
+			propsQueried:   []string{`creation`},
+			metricNames:    []string{`zfs_dataset_logical_used_bytes`, `zfs_dataset_snapshot_newest_timestamp_seconds`, `zfs_dataset_snapshots`},
+			propsResults: []datasetResults{
+				{
+					name:    `testpool/test@daily-1`,
+					results: map[string]string{`creation`: `1660000000`},
+				},
+			},
+			metricResults: `# HELP zfs_dataset_snapshots Number of snapshots of this dataset.
+# TYPE zfs_dataset_snapshots gauge
+zfs_dataset_snapshots{name="testpool/test",pool="testpool"} 1
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			zfsClient := mock_zfs.NewMockClient(ctrl)
+			config := defaultConfig(zfsClient)
+
+			zfsClient.EXPECT().PoolNames(gomock.Any()).Return([]string{`testpool`}, nil).Times(1)
+			collector, err := NewZFS(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			collector.Collectors = map[string]State{
+				`dataset-snapshot`: {
+					Name:       "dataset-snapshot",
+					Enabled:    boolPointer(true),
+					Properties: stringPointer(strings.Join(tc.propsRequested, `,`)),
+					factory:    newSnapshotCollectorFactory(boolPointer(true)),
+				},
+			}
+
+			zfsDatasetResults := make([]zfs.DatasetProperties, len(tc.propsResults))
+			for i, propResults := range tc.propsResults {
+				zfsDatasetProperties := mock_zfs.NewMockDatasetProperties(ctrl)
+				zfsDatasetProperties.EXPECT().DatasetName().Return(propResults.name).Times(2)
+				zfsDatasetProperties.EXPECT().Properties().Return(propResults.results).Times(1)
+				zfsDatasetResults[i] = zfsDatasetProperties
+			}
+			zfsDatasets := mock_zfs.NewMockDatasets(ctrl)
+			zfsDatasets.EXPECT().List(gomock.Any(), tc.propsQueried).Return(zfsDatasetResults, nil).Times(1)
+			zfsClient.EXPECT().Datasets(`testpool`, zfs.DatasetSnapshot).Return(zfsDatasets).Times(1)
+
+			if err = callCollector(ctx, collector, []byte(tc.metricResults), tc.metricNames); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
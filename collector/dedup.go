@@ -0,0 +1,182 @@
+package collector
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	dedupKindAllocated  = `allocated`
+	dedupKindReferenced = `referenced`
+	// dedupClassAll identifies statistics aggregated over all DDTs
+	dedupClassAll = `all`
+)
+
+var (
+	dedupLabels       = []string{`pool`, `class`}
+	dedupBucketLabels = []string{`pool`, `class`, `refcount`, `kind`}
+
+	dedupEntriesDescName = prometheus.BuildFQName(namespace, subsystemPool, `dedup_entries`)
+	dedupEntriesDesc     = prometheus.NewDesc(
+		dedupEntriesDescName,
+		`Number of entries in the dedup table (DDT) of the pool, per DDT class (ie - DDT-sha256-zap-unique) where reported, otherwise with a class of all.`,
+		dedupLabels,
+		nil,
+	)
+	dedupOnDiskDescName = prometheus.BuildFQName(namespace, subsystemPool, `dedup_table_on_disk_bytes`)
+	dedupOnDiskDesc     = prometheus.NewDesc(
+		dedupOnDiskDescName,
+		`Estimated size in bytes of the dedup table (DDT) on disk, calculated as the number of entries multiplied by the average on-disk entry size.`,
+		dedupLabels,
+		nil,
+	)
+	dedupInCoreDescName = prometheus.BuildFQName(namespace, subsystemPool, `dedup_table_in_core_bytes`)
+	dedupInCoreDesc     = prometheus.NewDesc(
+		dedupInCoreDescName,
+		`Estimated size in bytes of the dedup table (DDT) in memory, calculated as the number of entries multiplied by the average in-core entry size.`,
+		dedupLabels,
+		nil,
+	)
+	dedupBlocksDescName = prometheus.BuildFQName(namespace, subsystemPool, `dedup_blocks`)
+	dedupBlocksDesc     = prometheus.NewDesc(
+		dedupBlocksDescName,
+		`Number of blocks in the dedup table (DDT) histogram bucket, aggregated over all DDTs, whose reference count is at least refcount and less than double refcount. The allocated kind counts unique blocks, the referenced kind counts each reference.`,
+		dedupBucketLabels,
+		nil,
+	)
+	dedupLogicalDescName = prometheus.BuildFQName(namespace, subsystemPool, `dedup_logical_bytes`)
+	dedupLogicalDesc     = prometheus.NewDesc(
+		dedupLogicalDescName,
+		`Logical (uncompressed) size in bytes of the blocks in the dedup table (DDT) histogram bucket.`,
+		dedupBucketLabels,
+		nil,
+	)
+	dedupPhysicalDescName = prometheus.BuildFQName(namespace, subsystemPool, `dedup_physical_bytes`)
+	dedupPhysicalDesc     = prometheus.NewDesc(
+		dedupPhysicalDescName,
+		`Physical (compressed) size in bytes of the blocks in the dedup table (DDT) histogram bucket.`,
+		dedupBucketLabels,
+		nil,
+	)
+	dedupDiskDescName = prometheus.BuildFQName(namespace, subsystemPool, `dedup_disk_bytes`)
+	dedupDiskDesc     = prometheus.NewDesc(
+		dedupDiskDescName,
+		`Size in bytes allocated on disk for the blocks in the dedup table (DDT) histogram bucket, including parity and redundancy.`,
+		dedupBucketLabels,
+		nil,
+	)
+)
+
+func init() {
+	registerCollectorWithoutProperties(`pool-dedup`, defaultDisabled, newPoolDedupCollector)
+}
+
+type poolDedupCollector struct {
+	log    log.Logger
+	client zfs.Client
+}
+
+func (c *poolDedupCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- dedupEntriesDesc
+	ch <- dedupOnDiskDesc
+	ch <- dedupInCoreDesc
+	ch <- dedupBlocksDesc
+	ch <- dedupLogicalDesc
+	ch <- dedupPhysicalDesc
+	ch <- dedupDiskDesc
+}
+
+func (c *poolDedupCollector) update(ctx context.Context, ch chan<- metric, pools []string, excludes regexpCollection) error {
+	var wg sync.WaitGroup
+	errChan := make(chan error, len(pools))
+	for _, pool := range pools {
+		wg.Add(1)
+		go func(pool string) {
+			if err := c.updatePoolMetrics(ctx, ch, pool); err != nil {
+				errChan <- err
+			}
+			wg.Done()
+		}(pool)
+	}
+	wg.Wait()
+
+	select {
+	case err := <-errChan:
+		return err
+	default:
+		return nil
+	}
+}
+
+func (c *poolDedupCollector) updatePoolMetrics(ctx context.Context, ch chan<- metric, pool string) error {
+	stats, err := c.client.Pool(pool).Dedup(ctx)
+	if err != nil {
+		return err
+	}
+
+	classes := stats.Classes
+	if len(classes) == 0 {
+		classes = []zfs.DedupClass{{
+			Name:            dedupClassAll,
+			Entries:         stats.Entries,
+			EntrySizeOnDisk: stats.EntrySizeOnDisk,
+			EntrySizeInCore: stats.EntrySizeInCore,
+		}}
+	}
+	for _, class := range classes {
+		labelValues := []string{pool, class.Name}
+		for _, m := range []struct {
+			desc  *prometheus.Desc
+			name  string
+			value uint64
+		}{
+			{dedupEntriesDesc, dedupEntriesDescName, class.Entries},
+			{dedupOnDiskDesc, dedupOnDiskDescName, class.Entries * class.EntrySizeOnDisk},
+			{dedupInCoreDesc, dedupInCoreDescName, class.Entries * class.EntrySizeInCore},
+		} {
+			ch <- metric{
+				name:       expandMetricName(m.name, labelValues...),
+				prometheus: prometheus.MustNewConstMetric(m.desc, prometheus.GaugeValue, float64(m.value), labelValues...),
+			}
+		}
+	}
+
+	for _, bucket := range stats.Histogram {
+		refCount := strconv.FormatUint(bucket.RefCount, 10)
+		for _, kind := range []struct {
+			name   string
+			blocks zfs.DedupBlocks
+		}{
+			{dedupKindAllocated, bucket.Allocated},
+			{dedupKindReferenced, bucket.Referenced},
+		} {
+			labelValues := []string{pool, dedupClassAll, refCount, kind.name}
+			for _, m := range []struct {
+				desc  *prometheus.Desc
+				name  string
+				value uint64
+			}{
+				{dedupBlocksDesc, dedupBlocksDescName, kind.blocks.Blocks},
+				{dedupLogicalDesc, dedupLogicalDescName, kind.blocks.LogicalSize},
+				{dedupPhysicalDesc, dedupPhysicalDescName, kind.blocks.PhysicalSize},
+				{dedupDiskDesc, dedupDiskDescName, kind.blocks.AllocatedSize},
+			} {
+				ch <- metric{
+					name:       expandMetricName(m.name, labelValues...),
+					prometheus: prometheus.MustNewConstMetric(m.desc, prometheus.GaugeValue, float64(m.value), labelValues...),
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func newPoolDedupCollector(l log.Logger, c zfs.Client, props []string) (Collector, error) {
+	return &poolDedupCollector{log: l, client: c}, nil
+}
@@ -0,0 +1,126 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/pdf/zfs_exporter/v2/zfs/mock_zfs"
+)
+
+func TestPoolDedupMetrics(t *testing.T) {
+	testCases := []struct {
+		name          string
+		stats         zfs.DedupStats
+		metricNames   []string
+		metricResults string
+	}{
+		{
+			name: `table`,
+			stats: zfs.DedupStats{
+				Entries:         2435,
+				EntrySizeOnDisk: 1234,
+				EntrySizeInCore: 320,
+				Histogram: []zfs.DedupBucket{
+					{
+						RefCount:   1,
+						Allocated:  zfs.DedupBlocks{Blocks: 2237, LogicalSize: 297795584, PhysicalSize: 148897792, AllocatedSize: 148897792},
+						Referenced: zfs.DedupBlocks{Blocks: 2237, LogicalSize: 297795584, PhysicalSize: 148897792, AllocatedSize: 148897792},
+					},
+					{
+						RefCount:   2,
+						Allocated:  zfs.DedupBlocks{Blocks: 198, LogicalSize: 26004685, PhysicalSize: 13002342, AllocatedSize: 13002342},
+						Referenced: zfs.DedupBlocks{Blocks: 396, LogicalSize: 52009370, PhysicalSize: 26004684, AllocatedSize: 26004684},
+					},
+				},
+			},
+			metricNames: []string{`zfs_pool_dedup_entries`, `zfs_pool_dedup_table_on_disk_bytes`, `zfs_pool_dedup_table_in_core_bytes`, `zfs_pool_dedup_blocks`, `zfs_pool_dedup_physical_bytes`},
+			metricResults: `# HELP zfs_pool_dedup_blocks Number of blocks in the dedup table (DDT) histogram bucket, aggregated over all DDTs, whose reference count is at least refcount and less than double refcount. The allocated kind counts unique blocks, the referenced kind counts each reference.
+# TYPE zfs_pool_dedup_blocks gauge
+zfs_pool_dedup_blocks{class="all",kind="allocated",pool="testpool",refcount="1"} 2237
+zfs_pool_dedup_blocks{class="all",kind="allocated",pool="testpool",refcount="2"} 198
+zfs_pool_dedup_blocks{class="all",kind="referenced",pool="testpool",refcount="1"} 2237
+zfs_pool_dedup_blocks{class="all",kind="referenced",pool="testpool",refcount="2"} 396
+# HELP zfs_pool_dedup_entries Number of entries in the dedup table (DDT) of the pool, per DDT class (ie - DDT-sha256-zap-unique) where reported, otherwise with a class of all.
+# TYPE zfs_pool_dedup_entries gauge
+zfs_pool_dedup_entries{class="all",pool="testpool"} 2435
+# HELP zfs_pool_dedup_physical_bytes Physical (compressed) size in bytes of the blocks in the dedup table (DDT) histogram bucket.
+# TYPE zfs_pool_dedup_physical_bytes gauge
+zfs_pool_dedup_physical_bytes{class="all",kind="allocated",pool="testpool",refcount="1"} 1.48897792e+08
+zfs_pool_dedup_physical_bytes{class="all",kind="allocated",pool="testpool",refcount="2"} 1.3002342e+07
+zfs_pool_dedup_physical_bytes{class="all",kind="referenced",pool="testpool",refcount="1"} 1.48897792e+08
+zfs_pool_dedup_physical_bytes{class="all",kind="referenced",pool="testpool",refcount="2"} 2.6004684e+07
+# HELP zfs_pool_dedup_table_in_core_bytes Estimated size in bytes of the dedup table (DDT) in memory, calculated as the number of entries multiplied by the average in-core entry size.
+# TYPE zfs_pool_dedup_table_in_core_bytes gauge
+zfs_pool_dedup_table_in_core_bytes{class="all",pool="testpool"} 779200
+# HELP zfs_pool_dedup_table_on_disk_bytes Estimated size in bytes of the dedup table (DDT) on disk, calculated as the number of entries multiplied by the average on-disk entry size.
+# TYPE zfs_pool_dedup_table_on_disk_bytes gauge
+zfs_pool_dedup_table_on_disk_bytes{class="all",pool="testpool"} 3.00479e+06
+`,
+		},
+		{
+			name: `classes`,
+			stats: zfs.DedupStats{
+				Entries:         2435,
+				EntrySizeOnDisk: 1234,
+				EntrySizeInCore: 320,
+				Classes: []zfs.DedupClass{
+					{Name: `DDT-sha256-zap-duplicate`, Entries: 198, EntrySizeOnDisk: 1102, EntrySizeInCore: 356},
+					{Name: `DDT-sha256-zap-unique`, Entries: 2237, EntrySizeOnDisk: 1245, EntrySizeInCore: 317},
+				},
+			},
+			metricNames: []string{`zfs_pool_dedup_entries`, `zfs_pool_dedup_table_in_core_bytes`},
+			metricResults: `# HELP zfs_pool_dedup_entries Number of entries in the dedup table (DDT) of the pool, per DDT class (ie - DDT-sha256-zap-unique) where reported, otherwise with a class of all.
+# TYPE zfs_pool_dedup_entries gauge
+zfs_pool_dedup_entries{class="DDT-sha256-zap-duplicate",pool="testpool"} 198
+zfs_pool_dedup_entries{class="DDT-sha256-zap-unique",pool="testpool"} 2237
+# HELP zfs_pool_dedup_table_in_core_bytes Estimated size in bytes of the dedup table (DDT) in memory, calculated as the number of entries multiplied by the average in-core entry size.
+# TYPE zfs_pool_dedup_table_in_core_bytes gauge
+zfs_pool_dedup_table_in_core_bytes{class="DDT-sha256-zap-duplicate",pool="testpool"} 70488
+zfs_pool_dedup_table_in_core_bytes{class="DDT-sha256-zap-unique",pool="testpool"} 709129
+`,
+		},
+		{
+			name:        `no entries`,
+			stats:       zfs.DedupStats{},
+			metricNames: []string{`zfs_pool_dedup_entries`, `zfs_pool_dedup_blocks`},
+			metricResults: `# HELP zfs_pool_dedup_entries Number of entries in the dedup table (DDT) of the pool, per DDT class (ie - DDT-sha256-zap-unique) where reported, otherwise with a class of all.
+# TYPE zfs_pool_dedup_entries gauge
+zfs_pool_dedup_entries{class="all",pool="testpool"} 0
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			zfsClient := mock_zfs.NewMockClient(ctrl)
+			config := defaultConfig(zfsClient)
+
+			zfsClient.EXPECT().PoolNames(gomock.Any()).Return([]string{`testpool`}, nil).Times(1)
+			zfsPool := mock_zfs.NewMockPool(ctrl)
+			zfsPool.EXPECT().Dedup(gomock.Any()).Return(tc.stats, nil).Times(1)
+			zfsClient.EXPECT().Pool(`testpool`).Return(zfsPool).Times(1)
+
+			collector, err := NewZFS(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			collector.Collectors = map[string]State{
+				`pool-dedup`: {
+					Name:       "pool-dedup",
+					Enabled:    boolPointer(true),
+					Properties: stringPointer(``),
+					factory:    newPoolDedupCollector,
+				},
+			}
+
+			if err = callCollector(ctx, collector, []byte(tc.metricResults), tc.metricNames); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package collector
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	encryptionOff          = `off`
+	keyStatusAvailable     = `available`
+	keyLocationSchemeDelim = `://`
+)
+
+var (
+	encryptionProps = []string{`encryption`, `keyformat`, `keylocation`, `keystatus`, `encryptionroot`}
+
+	encryptionInfoDescName = prometheus.BuildFQName(namespace, subsystemDataset, `encryption_info`)
+	encryptionInfoDesc     = prometheus.NewDesc(
+		encryptionInfoDescName,
+		`Encryption configuration of this dataset. The keylocation label is the type of key location (prompt, file, http, https or none), and the encryptionroot label is the dataset from which the key is inherited. The value is always 1.`,
+		append(append([]string{}, datasetLabels...), `encryption`, `keyformat`, `keylocation`, `encryptionroot`),
+		nil,
+	)
+	encryptionKeyAvailableDescName = prometheus.BuildFQName(namespace, subsystemDataset, `key_available`)
+	encryptionKeyAvailableDesc     = prometheus.NewDesc(
+		encryptionKeyAvailableDescName,
+		`Whether the encryption key of this dataset is loaded [0: unavailable, 1: available].`,
+		datasetLabels,
+		nil,
+	)
+	encryptionRootDescName = prometheus.BuildFQName(namespace, subsystemDataset, `encryption_root`)
+	encryptionRootDesc     = prometheus.NewDesc(
+		encryptionRootDescName,
+		`Whether this dataset is an encryption root, from which descendents inherit their key [0: false, 1: true].`,
+		datasetLabels,
+		nil,
+	)
+)
+
+func init() {
+	registerCollectorWithoutProperties(`dataset-encryption`, defaultDisabled, newEncryptionCollector)
+}
+
+type encryptionCollector struct {
+	log    log.Logger
+	client zfs.Client
+}
+
+func (c *encryptionCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- encryptionInfoDesc
+	ch <- encryptionKeyAvailableDesc
+	ch <- encryptionRootDesc
+}
+
+func (c *encryptionCollector) update(ctx context.Context, ch chan<- metric, pools []string, excludes regexpCollection) error {
+	var wg sync.WaitGroup
+	errChan := make(chan error, len(pools))
+	for _, pool := range pools {
+		wg.Add(1)
+		go func(pool string) {
+			if err := c.updatePoolMetrics(ctx, ch, pool, excludes); err != nil {
+				errChan <- err
+			}
+			wg.Done()
+		}(pool)
+	}
+	wg.Wait()
+
+	select {
+	case err := <-errChan:
+		return err
+	default:
+		return nil
+	}
+}
+
+func (c *encryptionCollector) updatePoolMetrics(ctx context.Context, ch chan<- metric, pool string, excludes regexpCollection) error {
+	for _, kind := range []zfs.DatasetKind{zfs.DatasetFilesystem, zfs.DatasetVolume} {
+		datasets, err := c.client.Datasets(pool, kind).Properties(ctx, encryptionProps...)
+		if err != nil {
+			return err
+		}
+		for _, dataset := range datasets {
+			name := dataset.DatasetName()
+			if excludes.MatchString(name) {
+				continue
+			}
+			props := dataset.Properties()
+			if props[`encryption`] == `` || props[`encryption`] == encryptionOff {
+				continue
+			}
+			c.updateDatasetMetrics(ch, []string{name, pool, string(kind)}, props)
+		}
+	}
+
+	return nil
+}
+
+func (c *encryptionCollector) updateDatasetMetrics(ch chan<- metric, labelValues []string, props map[string]string) {
+	name := labelValues[0]
+	infoValues := append(append([]string{}, labelValues...),
+		props[`encryption`],
+		props[`keyformat`],
+		keyLocationType(props[`keylocation`]),
+		props[`encryptionroot`],
+	)
+	ch <- metric{
+		name:       expandMetricName(encryptionInfoDescName, labelValues...),
+		prometheus: prometheus.MustNewConstMetric(encryptionInfoDesc, prometheus.GaugeValue, 1, infoValues...),
+	}
+
+	var available float64
+	if props[`keystatus`] == keyStatusAvailable {
+		available = 1
+	}
+	ch <- metric{
+		name:       expandMetricName(encryptionKeyAvailableDescName, labelValues...),
+		prometheus: prometheus.MustNewConstMetric(encryptionKeyAvailableDesc, prometheus.GaugeValue, available, labelValues...),
+	}
+
+	var root float64
+	if props[`encryptionroot`] == name {
+		root = 1
+	}
+	ch <- metric{
+		name:       expandMetricName(encryptionRootDescName, labelValues...),
+		prometheus: prometheus.MustNewConstMetric(encryptionRootDesc, prometheus.GaugeValue, root, labelValues...),
+	}
+}
+
+// keyLocationType returns the type of the keylocation property, so that key file paths and URLs are not exposed.
+// Datasets that inherit their key report a keylocation of `none`.
+func keyLocationType(location string) string {
+	if i := strings.Index(location, keyLocationSchemeDelim); i >= 0 {
+		return location[:i]
+	}
+	return location
+}
+
+func newEncryptionCollector(l log.Logger, c zfs.Client, props []string) (Collector, error) {
+	return &encryptionCollector{log: l, client: c}, nil
+}
@@ -0,0 +1,98 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/pdf/zfs_exporter/v2/zfs/mock_zfs"
+)
+
+func TestEncryptionMetrics(t *testing.T) {
+	t.Parallel()
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	config := defaultConfig(zfsClient)
+	config.Excludes = []string{`^testpool/excluded`}
+
+	zfsClient.EXPECT().PoolNames(gomock.Any()).Return([]string{`testpool`}, nil).Times(1)
+	collector, err := NewZFS(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collector.Collectors = map[string]State{
+		`dataset-encryption`: {
+			Name:       "dataset-encryption",
+			Enabled:    boolPointer(true),
+			Properties: stringPointer(``),
+			factory:    newEncryptionCollector,
+		},
+	}
+
+	newDatasets := func(results []datasetResults) []zfs.DatasetProperties {
+		datasets := make([]zfs.DatasetProperties, len(results))
+		for i, result := range results {
+			dataset := mock_zfs.NewMockDatasetProperties(ctrl)
+			dataset.EXPECT().DatasetName().Return(result.name).Times(1)
+			dataset.EXPECT().Properties().Return(result.results).MaxTimes(1)
+			datasets[i] = dataset
+		}
+		return datasets
+	}
+	filesystems := mock_zfs.NewMockDatasets(ctrl)
+	filesystems.EXPECT().Properties(gomock.Any(), encryptionProps).Return(newDatasets([]datasetResults{
+		{
+			name:    `testpool`,
+			results: map[string]string{`encryption`: `off`, `keyformat`: `none`, `keylocation`: `none`, `keystatus`: `-`, `encryptionroot`: `-`},
+		},
+		{
+			name:    `testpool/secure`,
+			results: map[string]string{`encryption`: `aes-256-gcm`, `keyformat`: `raw`, `keylocation`: `file:///etc/zfs/keys/secure`, `keystatus`: `available`, `encryptionroot`: `testpool/secure`},
+		},
+		{
+			name:    `testpool/secure/child`,
+			results: map[string]string{`encryption`: `aes-256-gcm`, `keyformat`: `raw`, `keylocation`: `none`, `keystatus`: `available`, `encryptionroot`: `testpool/secure`},
+		},
+		{
+			name:    `testpool/locked`,
+			results: map[string]string{`encryption`: `aes-256-gcm`, `keyformat`: `passphrase`, `keylocation`: `prompt`, `keystatus`: `unavailable`, `encryptionroot`: `testpool/locked`},
+		},
+		{
+			name:    `testpool/excluded`,
+			results: map[string]string{`encryption`: `aes-256-gcm`, `keyformat`: `passphrase`, `keylocation`: `prompt`, `keystatus`: `unavailable`, `encryptionroot`: `testpool/excluded`},
+		},
+	}), nil).Times(1)
+	volumes := mock_zfs.NewMockDatasets(ctrl)
+	volumes.EXPECT().Properties(gomock.Any(), encryptionProps).Return(newDatasets([]datasetResults{
+		{
+			name:    `testpool/secure/vol`,
+			results: map[string]string{`encryption`: `aes-256-gcm`, `keyformat`: `raw`, `keylocation`: `none`, `keystatus`: `available`, `encryptionroot`: `testpool/secure`},
+		},
+	}), nil).Times(1)
+	zfsClient.EXPECT().Datasets(`testpool`, zfs.DatasetFilesystem).Return(filesystems).Times(1)
+	zfsClient.EXPECT().Datasets(`testpool`, zfs.DatasetVolume).Return(volumes).Times(1)
+
+	metricResults := `# HELP zfs_dataset_encryption_info Encryption configuration of this dataset. The keylocation label is the type of key location (prompt, file, http, https or none), and the encryptionroot label is the dataset from which the key is inherited. The value is always 1.
+# TYPE zfs_dataset_encryption_info gauge
+zfs_dataset_encryption_info{encryption="aes-256-gcm",encryptionroot="testpool/locked",keyformat="passphrase",keylocation="prompt",name="testpool/locked",pool="testpool",type="filesystem"} 1
+zfs_dataset_encryption_info{encryption="aes-256-gcm",encryptionroot="testpool/secure",keyformat="raw",keylocation="file",name="testpool/secure",pool="testpool",type="filesystem"} 1
+zfs_dataset_encryption_info{encryption="aes-256-gcm",encryptionroot="testpool/secure",keyformat="raw",keylocation="none",name="testpool/secure/child",pool="testpool",type="filesystem"} 1
+zfs_dataset_encryption_info{encryption="aes-256-gcm",encryptionroot="testpool/secure",keyformat="raw",keylocation="none",name="testpool/secure/vol",pool="testpool",type="volume"} 1
+# HELP zfs_dataset_encryption_root Whether this dataset is an encryption root, from which descendents inherit their key [0: false, 1: true].
+# TYPE zfs_dataset_encryption_root gauge
+zfs_dataset_encryption_root{name="testpool/locked",pool="testpool",type="filesystem"} 1
+zfs_dataset_encryption_root{name="testpool/secure",pool="testpool",type="filesystem"} 1
+zfs_dataset_encryption_root{name="testpool/secure/child",pool="testpool",type="filesystem"} 0
+zfs_dataset_encryption_root{name="testpool/secure/vol",pool="testpool",type="volume"} 0
+# HELP zfs_dataset_key_available Whether the encryption key of this dataset is loaded [0: unavailable, 1: available].
+# TYPE zfs_dataset_key_available gauge
+zfs_dataset_key_available{name="testpool/locked",pool="testpool",type="filesystem"} 0
+zfs_dataset_key_available{name="testpool/secure",pool="testpool",type="filesystem"} 1
+zfs_dataset_key_available{name="testpool/secure/child",pool="testpool",type="filesystem"} 1
+zfs_dataset_key_available{name="testpool/secure/vol",pool="testpool",type="volume"} 1
+`
+	if err = callCollector(ctx, collector, []byte(metricResults), []string{`zfs_dataset_encryption_info`, `zfs_dataset_encryption_root`, `zfs_dataset_key_available`}); err != nil {
+		t.Fatal(err)
+	}
+}
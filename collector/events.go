@@ -0,0 +1,177 @@
+package collector
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+var (
+	eventsDescName = prometheus.BuildFQName(namespace, subsystemPool, `events_total`)
+	eventsDesc     = prometheus.NewDesc(
+		eventsDescName,
+		`Number of ZFS events of this class since the exporter started, as reported by zpool events. The vdev label is empty for events that do not relate to a vdev.`,
+		[]string{`pool`, `vdev`, `class`},
+		nil,
+	)
+	eventsFollowerUpDescName = prometheus.BuildFQName(namespace, subsystemPool, `events_follower_up`)
+	eventsFollowerUpDesc     = prometheus.NewDesc(
+		eventsFollowerUpDescName,
+		`Whether zpool events is currently being followed [0: stopped, 1: running]. Events that occur while stopped are recovered from the kernel event log when following resumes, if they have not since been discarded.`,
+		nil,
+		nil,
+	)
+)
+
+func init() {
+	retry := kingpin.Flag(`collector.pool-events.retry-interval`, `Interval between attempts to restart zpool events, if it exits, for the pool-events collector.`).Default(`10s`).Duration()
+	registerCollectorWithoutProperties(`pool-events`, defaultDisabled, newPoolEventCollectorFactory(retry))
+}
+
+type poolEventCollector struct {
+	log      log.Logger
+	client   zfs.Client
+	follower *eventFollower
+}
+
+func (c *poolEventCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- eventsDesc
+	ch <- eventsFollowerUpDesc
+}
+
+func (c *poolEventCollector) start(ctx context.Context) {
+	c.follower.start(ctx, c.log, c.client)
+}
+
+func (c *poolEventCollector) update(ctx context.Context, ch chan<- metric, pools []string, excludes regexpCollection) error {
+	wanted := make(map[string]bool, len(pools))
+	for _, pool := range pools {
+		wanted[pool] = true
+	}
+
+	running, counts := c.follower.snapshot()
+	var up float64
+	if running {
+		up = 1
+	}
+	ch <- metric{
+		name:       eventsFollowerUpDescName,
+		prometheus: prometheus.MustNewConstMetric(eventsFollowerUpDesc, prometheus.GaugeValue, up),
+	}
+	for key, count := range counts {
+		// Some events (ie - config_sync for an exported pool) do not relate to any pool.
+		if key.pool != `` && !wanted[key.pool] {
+			continue
+		}
+		labelValues := []string{key.pool, key.vdev, key.class}
+		ch <- metric{
+			name:       expandMetricName(eventsDescName, labelValues...),
+			prometheus: prometheus.MustNewConstMetric(eventsDesc, prometheus.CounterValue, float64(count), labelValues...),
+		}
+	}
+
+	return nil
+}
+
+type eventKey struct {
+	pool  string
+	vdev  string
+	class string
+}
+
+// eventFollower follows zpool events for the lifetime of the exporter, counting events as they occur, so that
+// transient changes between collections are not missed.
+type eventFollower struct {
+	retry *time.Duration
+	once  sync.Once
+	// since holds the time following started, events from the kernel event log that occurred earlier are ignored, so
+	// that counters start at zero.
+	since time.Time
+	// newestEID and newestTime hold the identifier and time of the most recent event counted, so that events are not
+	// counted again when zpool events is restarted, and replays the kernel event log.
+	newestEID  uint64
+	newestTime time.Time
+	running    bool
+	counts     map[eventKey]uint64
+	sync.Mutex
+}
+
+func (f *eventFollower) start(ctx context.Context, l log.Logger, client zfs.Client) {
+	f.once.Do(func() {
+		f.Lock()
+		f.since = time.Now()
+		f.Unlock()
+		go f.run(ctx, l, client)
+	})
+}
+
+func (f *eventFollower) run(ctx context.Context, l log.Logger, client zfs.Client) {
+	for {
+		f.setRunning(true)
+		err := client.Events(ctx, f.observe)
+		f.setRunning(false)
+		if ctx.Err() != nil {
+			return
+		}
+		_ = level.Warn(l).Log(`msg`, `Following zpool events stopped, retrying`, `retry`, *f.retry, `err`, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(*f.retry):
+		}
+	}
+}
+
+func (f *eventFollower) setRunning(running bool) {
+	f.Lock()
+	defer f.Unlock()
+	f.running = running
+}
+
+// snapshot returns a copy of the counters, so that events are not blocked while they are sent.
+func (f *eventFollower) snapshot() (bool, map[eventKey]uint64) {
+	f.Lock()
+	defer f.Unlock()
+	counts := make(map[eventKey]uint64, len(f.counts))
+	for key, count := range f.counts {
+		counts[key] = count
+	}
+	return f.running, counts
+}
+
+func (f *eventFollower) observe(event zfs.Event) {
+	f.Lock()
+	defer f.Unlock()
+	if !event.Time.IsZero() && event.Time.Before(f.since) {
+		return
+	}
+	// Event identifiers restart when the ZFS module is reloaded, in which case newer events have lower identifiers.
+	if event.EID != 0 && event.EID <= f.newestEID && !event.Time.After(f.newestTime) {
+		return
+	}
+	if event.EID != 0 {
+		f.newestEID, f.newestTime = event.EID, event.Time
+	}
+	f.counts[eventKey{pool: event.Pool, vdev: event.Vdev, class: event.Class}]++
+}
+
+func newEventFollower(retry *time.Duration) *eventFollower {
+	return &eventFollower{
+		retry:  retry,
+		counts: make(map[eventKey]uint64),
+	}
+}
+
+func newPoolEventCollectorFactory(retry *time.Duration) factoryFunc {
+	follower := newEventFollower(retry)
+	return func(l log.Logger, c zfs.Client, props []string) (Collector, error) {
+		return &poolEventCollector{log: l, client: c, follower: follower}, nil
+	}
+}
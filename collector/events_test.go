@@ -0,0 +1,88 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/pdf/zfs_exporter/v2/zfs/mock_zfs"
+)
+
+func TestPoolEventMetrics(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	followCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	config := defaultConfig(zfsClient)
+
+	now := time.Now()
+	history := []zfs.Event{
+		{Class: `ereport.fs.zfs.checksum`, EID: 1, Time: now.Add(-time.Hour), Pool: `testpool`, Vdev: `/dev/sda1`},
+		{Class: `ereport.fs.zfs.checksum`, EID: 2, Time: now.Add(time.Second), Pool: `testpool`, Vdev: `/dev/sda1`},
+		{Class: `resource.fs.zfs.statechange`, EID: 3, Time: now.Add(2 * time.Second), Pool: `testpool`, Vdev: `/dev/sdb1`},
+		{Class: `ereport.fs.zfs.io`, EID: 4, Time: now.Add(2 * time.Second), Pool: `otherpool`, Vdev: `/dev/sdc1`},
+	}
+	followed := make(chan struct{})
+	gomock.InOrder(
+		// The first run exits after delivering the history, which is replayed by the second run.
+		zfsClient.EXPECT().Events(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, handler func(zfs.Event)) error {
+			for _, event := range history {
+				handler(event)
+			}
+			return errors.New(`exit status 1`)
+		}).Times(1),
+		zfsClient.EXPECT().Events(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, handler func(zfs.Event)) error {
+			for _, event := range history {
+				handler(event)
+			}
+			handler(zfs.Event{Class: `ereport.fs.zfs.checksum`, EID: 5, Time: now.Add(3 * time.Second), Pool: `testpool`, Vdev: `/dev/sda1`})
+			handler(zfs.Event{Class: `sysevent.fs.zfs.scrub_finish`, EID: 6, Time: now.Add(4 * time.Second), Pool: `testpool`})
+			close(followed)
+			<-ctx.Done()
+			return ctx.Err()
+		}).Times(1),
+	)
+	zfsClient.EXPECT().PoolNames(gomock.Any()).Return([]string{`testpool`}, nil).Times(1)
+
+	retry := time.Millisecond
+	factory := newPoolEventCollectorFactory(&retry)
+	events, err := factory(logger, zfsClient, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events.(backgroundCollector).start(followCtx)
+	select {
+	case <-followed:
+	case <-time.After(5 * time.Second):
+		t.Fatal(`Timed out waiting for events`)
+	}
+
+	collector, err := NewZFS(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collector.Collectors = map[string]State{
+		`pool-events`: {
+			Name:       "pool-events",
+			Enabled:    boolPointer(true),
+			Properties: stringPointer(``),
+			factory:    factory,
+		},
+	}
+
+	metricResults := `# HELP zfs_pool_events_follower_up Whether zpool events is currently being followed [0: stopped, 1: running]. Events that occur while stopped are recovered from the kernel event log when following resumes, if they have not since been discarded.
+# TYPE zfs_pool_events_follower_up gauge
+zfs_pool_events_follower_up 1
+# HELP zfs_pool_events_total Number of ZFS events of this class since the exporter started, as reported by zpool events. The vdev label is empty for events that do not relate to a vdev.
+# TYPE zfs_pool_events_total counter
+zfs_pool_events_total{class="ereport.fs.zfs.checksum",pool="testpool",vdev="/dev/sda1"} 2
+zfs_pool_events_total{class="resource.fs.zfs.statechange",pool="testpool",vdev="/dev/sdb1"} 1
+zfs_pool_events_total{class="sysevent.fs.zfs.scrub_finish",pool="testpool",vdev=""} 1
+`
+	if err = callCollector(ctx, collector, []byte(metricResults), []string{`zfs_pool_events_total`, `zfs_pool_events_follower_up`}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,113 @@
+package collector
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const featurePropertyPrefix = `feature@`
+
+var (
+	featureStates = []string{`disabled`, `enabled`, `active`}
+
+	featureStateDescName = prometheus.BuildFQName(namespace, subsystemPool, `feature_state`)
+	featureStateDesc     = prometheus.NewDesc(
+		featureStateDescName,
+		`State of the pool feature flag, the current state has a value of 1 [disabled: not enabled by zpool upgrade, enabled: may be used, but does not yet affect the on-disk format, active: in use, hosts that do not support the feature cannot import the pool, or only read-only if the feature is read-only compatible].`,
+		[]string{`pool`, `feature`, `state`},
+		nil,
+	)
+)
+
+func init() {
+	registerCollector(`pool-features`, defaultDisabled, ``, newPoolFeatureCollector)
+}
+
+type poolFeatureCollector struct {
+	log      log.Logger
+	client   zfs.Client
+	features []string
+}
+
+func (c *poolFeatureCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- featureStateDesc
+}
+
+func (c *poolFeatureCollector) update(ctx context.Context, ch chan<- metric, pools []string, excludes regexpCollection) error {
+	var wg sync.WaitGroup
+	errChan := make(chan error, len(pools))
+	for _, pool := range pools {
+		wg.Add(1)
+		go func(pool string) {
+			if err := c.updatePoolMetrics(ctx, ch, pool); err != nil {
+				errChan <- err
+			}
+			wg.Done()
+		}(pool)
+	}
+	wg.Wait()
+
+	select {
+	case err := <-errChan:
+		return err
+	default:
+		return nil
+	}
+}
+
+func (c *poolFeatureCollector) updatePoolMetrics(ctx context.Context, ch chan<- metric, pool string) error {
+	// Without an explicit selection, all properties must be requested to discover the supported features.
+	query := []string{`all`}
+	if len(c.features) > 0 {
+		query = make([]string, len(c.features))
+		for i, feature := range c.features {
+			query[i] = featurePropertyPrefix + feature
+		}
+	}
+	props, err := c.client.Pool(pool).Properties(ctx, query...)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range props.Properties() {
+		if !strings.HasPrefix(k, featurePropertyPrefix) {
+			continue
+		}
+		feature := strings.TrimPrefix(k, featurePropertyPrefix)
+		known := false
+		for _, state := range featureStates {
+			var value float64
+			if v == state {
+				value = 1
+				known = true
+			}
+			labelValues := []string{pool, feature, state}
+			ch <- metric{
+				name:       expandMetricName(featureStateDescName, labelValues...),
+				prometheus: prometheus.MustNewConstMetric(featureStateDesc, prometheus.GaugeValue, value, labelValues...),
+			}
+		}
+		if !known {
+			_ = level.Warn(c.log).Log(`msg`, `Unknown pool feature state`, `pool`, pool, `feature`, feature, `state`, v)
+		}
+	}
+
+	return nil
+}
+
+func newPoolFeatureCollector(l log.Logger, c zfs.Client, props []string) (Collector, error) {
+	features := make([]string, 0, len(props))
+	for _, p := range props {
+		if p == `` {
+			continue
+		}
+		features = append(features, strings.TrimPrefix(p, featurePropertyPrefix))
+	}
+	return &poolFeatureCollector{log: l, client: c, features: features}, nil
+}
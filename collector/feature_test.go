@@ -0,0 +1,96 @@
+package collector
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pdf/zfs_exporter/v2/zfs/mock_zfs"
+)
+
+func TestPoolFeatureMetrics(t *testing.T) {
+	testCases := []struct {
+		name           string
+		propsRequested []string
+		propsQueried   []string
+		propsResults   map[string]string
+		metricResults  string
+	}{
+		{
+			name:         `all features`,
+			propsQueried: []string{`all`},
+			propsResults: map[string]string{
+				`size`:                   `10737418240`,
+				`feature@async_destroy`:  `enabled`,
+				`feature@draid`:          `disabled`,
+				`feature@encryption`:     `active`,
+				`unsupported@com.vendor`: `inactive`,
+			},
+			metricResults: `# HELP zfs_pool_feature_state State of the pool feature flag, the current state has a value of 1 [disabled: not enabled by zpool upgrade, enabled: may be used, but does not yet affect the on-disk format, active: in use, hosts that do not support the feature cannot import the pool, or only read-only if the feature is read-only compatible].
+# TYPE zfs_pool_feature_state gauge
+zfs_pool_feature_state{feature="async_destroy",pool="testpool",state="active"} 0
+zfs_pool_feature_state{feature="async_destroy",pool="testpool",state="disabled"} 0
+zfs_pool_feature_state{feature="async_destroy",pool="testpool",state="enabled"} 1
+zfs_pool_feature_state{feature="draid",pool="testpool",state="active"} 0
+zfs_pool_feature_state{feature="draid",pool="testpool",state="disabled"} 1
+zfs_pool_feature_state{feature="draid",pool="testpool",state="enabled"} 0
+zfs_pool_feature_state{feature="encryption",pool="testpool",state="active"} 1
+zfs_pool_feature_state{feature="encryption",pool="testpool",state="disabled"} 0
+zfs_pool_feature_state{feature="encryption",pool="testpool",state="enabled"} 0
+`,
+		},
+		{
+			name:           `selected features`,
+			propsRequested: []string{`encryption`, `feature@draid`},
+			propsQueried:   []string{`feature@encryption`, `feature@draid`},
+			propsResults: map[string]string{
+				`feature@draid`:      `disabled`,
+				`feature@encryption`: `active`,
+			},
+			metricResults: `# HELP zfs_pool_feature_state State of the pool feature flag, the current state has a value of 1 [disabled: not enabled by zpool upgrade, enabled: may be used, but does not yet affect the on-disk format, active: in use, hosts that do not support the feature cannot import the pool, or only read-only if the feature is read-only compatible].
+# TYPE zfs_pool_feature_state gauge
+zfs_pool_feature_state{feature="draid",pool="testpool",state="active"} 0
+zfs_pool_feature_state{feature="draid",pool="testpool",state="disabled"} 1
+zfs_pool_feature_state{feature="draid",pool="testpool",state="enabled"} 0
+zfs_pool_feature_state{feature="encryption",pool="testpool",state="active"} 1
+zfs_pool_feature_state{feature="encryption",pool="testpool",state="disabled"} 0
+zfs_pool_feature_state{feature="encryption",pool="testpool",state="enabled"} 0
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			zfsClient := mock_zfs.NewMockClient(ctrl)
+			config := defaultConfig(zfsClient)
+
+			zfsClient.EXPECT().PoolNames(gomock.Any()).Return([]string{`testpool`}, nil).Times(1)
+			zfsPoolProperties := mock_zfs.NewMockPoolProperties(ctrl)
+			zfsPoolProperties.EXPECT().Properties().Return(tc.propsResults).Times(1)
+			zfsPool := mock_zfs.NewMockPool(ctrl)
+			zfsPool.EXPECT().Properties(gomock.Any(), tc.propsQueried).Return(zfsPoolProperties, nil).Times(1)
+			zfsClient.EXPECT().Pool(`testpool`).Return(zfsPool).Times(1)
+
+			collector, err := NewZFS(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			collector.Collectors = map[string]State{
+				`pool-features`: {
+					Name:       "pool-features",
+					Enabled:    boolPointer(true),
+					Properties: stringPointer(strings.Join(tc.propsRequested, `,`)),
+					factory:    newPoolFeatureCollector,
+				},
+			}
+
+			if err = callCollector(ctx, collector, []byte(tc.metricResults), []string{`zfs_pool_feature_state`}); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
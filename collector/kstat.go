@@ -0,0 +1,172 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+const (
+	kstatModule = `zfs`
+)
+
+var (
+	// kstatNames are the SPL kstat files that may be exposed by the generic kstat collector, each is registered as
+	// an individual collector.
+	kstatNames = []string{
+		`abdstats`,
+		`dbufstats`,
+		`dmu_tx`,
+		`fm`,
+		`vdev_mirror_stats`,
+		`zfetchstats`,
+		`zil`,
+	}
+
+	// kstatGauges match the unsigned statistics of each kstat that are known to be levels (ie - current sizes and
+	// counts) rather than monotonic counters, as the kstat data type does not distinguish them. Statistics added by
+	// later releases may be added via --collector.kstat.gauge.
+	kstatGauges = map[string]string{
+		`abdstats`:    `^(struct_size|linear_cnt|linear_data_size|scatter_cnt|scatter_data_size|scatter_chunk_waste|scatter_order_\d+)$`,
+		`dbufstats`:   `^(cache_count|cache_size_bytes(_max)?|cache_target_bytes|cache_lowater_bytes|cache_hiwater_bytes|cache_level_\d+(_bytes)?|hash_elements(_max)?|hash_chains|hash_chain_max|hash_table_count|hash_mutex_count|metadata_cache_count|metadata_cache_size_bytes(_max)?)$`,
+		`zfetchstats`: `^io_active$`,
+	}
+
+	invalidMetricChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+)
+
+func init() {
+	gauges := newKstatGaugeSet(kingpin.Flag(`collector.kstat.gauge`, `Unsigned statistics to expose as gauges rather than counters for the kstat-* collectors, in the form kstat=regex, where the regex is matched against the statistic name. Applies in addition to the built-in list of levels, may be specified multiple times.`).Strings())
+	for _, name := range kstatNames {
+		registerCollector(`kstat-`+name, defaultDisabled, ``, newKstatCollectorFactory(kstatModule, name, gauges))
+	}
+}
+
+type kstatCollector struct {
+	log    log.Logger
+	client zfs.Client
+	module string
+	name   string
+	props  map[string]struct{}
+	gauges *kstatGaugeSet
+}
+
+// describe sends no descriptors, as the available statistics vary between releases, and are only known by reading the
+// kstat at collection time, so these metrics are collected unchecked.
+func (c *kstatCollector) describe(ch chan<- *prometheus.Desc) {
+}
+
+func (c *kstatCollector) update(ctx context.Context, ch chan<- metric, pools []string, excludes regexpCollection) error {
+	gauges, err := c.gauges.get(c.name)
+	if err != nil {
+		return err
+	}
+	stats, err := c.client.Kstat(ctx, c.module, c.name)
+	if err != nil {
+		return err
+	}
+
+	for _, stat := range stats.Data {
+		if !c.wanted(stat.Name) {
+			continue
+		}
+		prop, ok := newKstatProperty(c.module, c.name, stat, gauges)
+		if !ok {
+			_ = level.Debug(c.log).Log(`msg`, `Skipping non-numeric kstat`, `collector`, `kstat-`+c.name, `property`, stat.Name, `type`, stat.Type)
+			continue
+		}
+		if err = prop.push(ch, stat.Value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *kstatCollector) wanted(stat string) bool {
+	if len(c.props) == 0 {
+		return true
+	}
+	_, ok := c.props[stat]
+	return ok
+}
+
+// newKstatProperty builds a property for a kstat value, unsigned values are monotonic counters in the SPL kstat
+// conventions, unless matched by gauges, while signed values may decrease and are exposed as gauges.
+func newKstatProperty(module, name string, stat zfs.KstatNamed, gauges regexpCollection) (property, bool) {
+	subsystem := `kstat_` + invalidMetricChars.ReplaceAllString(name, `_`)
+	metricName := invalidMetricChars.ReplaceAllString(stat.Name, `_`)
+	helpText := fmt.Sprintf("Value of %s from the %s/%s kstat.", stat.Name, module, name)
+	switch stat.Type {
+	case zfs.KstatDataUint32, zfs.KstatDataUint64, zfs.KstatDataUlong:
+		if gauges.MatchString(stat.Name) {
+			return newProperty(subsystem, metricName, helpText, transformNumeric), true
+		}
+		return newCounterProperty(subsystem, metricName+`_total`, helpText, transformNumeric), true
+	case zfs.KstatDataInt32, zfs.KstatDataInt64, zfs.KstatDataLong:
+		return newProperty(subsystem, metricName, helpText, transformNumeric), true
+	default:
+		return property{}, false
+	}
+}
+
+// kstatGaugeSet holds the expressions matching the unsigned statistics of each kstat to expose as gauges, combining
+// the built-in list with those provided via flags, which are compiled on first use.
+type kstatGaugeSet struct {
+	flag   *[]string
+	once   sync.Once
+	gauges map[string]regexpCollection
+	err    error
+}
+
+// get returns the expressions for the kstat, or an error if any provided via flags are invalid.
+func (g *kstatGaugeSet) get(name string) (regexpCollection, error) {
+	g.once.Do(func() {
+		g.gauges, g.err = parseKstatGauges(*g.flag)
+	})
+	return g.gauges[name], g.err
+}
+
+func parseKstatGauges(flags []string) (map[string]regexpCollection, error) {
+	result := make(map[string]regexpCollection, len(kstatGauges))
+	for name, expr := range kstatGauges {
+		result[name] = regexpCollection{regexp.MustCompile(expr)}
+	}
+	for _, gauge := range flags {
+		parts := strings.SplitN(gauge, `=`, 2)
+		if len(parts) != 2 || parts[0] == `` || parts[1] == `` {
+			return nil, fmt.Errorf("invalid kstat gauge, expected kstat=regex: %s", gauge)
+		}
+		r, err := regexp.Compile(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid kstat gauge %s: %w", gauge, err)
+		}
+		result[parts[0]] = append(result[parts[0]], r)
+	}
+	return result, nil
+}
+
+func newKstatGaugeSet(flag *[]string) *kstatGaugeSet {
+	return &kstatGaugeSet{flag: flag}
+}
+
+func newKstatCollectorFactory(module, name string, gauges *kstatGaugeSet) factoryFunc {
+	return func(l log.Logger, c zfs.Client, props []string) (Collector, error) {
+		wanted := make(map[string]struct{}, len(props))
+		for _, p := range props {
+			if p == `` {
+				continue
+			}
+			wanted[p] = struct{}{}
+		}
+		return &kstatCollector{log: l, client: c, module: module, name: name, props: wanted, gauges: gauges}, nil
+	}
+}
@@ -0,0 +1,142 @@
+package collector
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/pdf/zfs_exporter/v2/zfs/mock_zfs"
+)
+
+func TestKstatMetrics(t *testing.T) {
+	testCases := []struct {
+		name           string
+		kstat          string
+		propsRequested []string
+		gauges         []string
+		metricNames    []string
+		kstatResults   []zfs.KstatNamed
+		metricResults  string
+	}{
+		{
+			name:        `all stats`,
+			kstat:       `zil`,
+			metricNames: []string{`zfs_kstat_zil_zil_commit_count_total`, `zfs_kstat_zil_zil_itx_count_total`, `zfs_kstat_zil_zil_commit_writer_count`},
+			kstatResults: []zfs.KstatNamed{
+				{Name: `zil_commit_count`, Type: zfs.KstatDataUint64, Value: `1234`},
+				{Name: `zil_commit_writer_count`, Type: zfs.KstatDataInt64, Value: `-12`},
+				{Name: `zil_itx_count`, Type: zfs.KstatDataUint32, Value: `56`},
+				{Name: `zil_class`, Type: zfs.KstatDataString, Value: `misc`},
+			},
+			metricResults: `# HELP zfs_kstat_zil_zil_commit_count_total Value of zil_commit_count from the zfs/zil kstat.
+# TYPE zfs_kstat_zil_zil_commit_count_total counter
+zfs_kstat_zil_zil_commit_count_total 1234
+# HELP zfs_kstat_zil_zil_commit_writer_count Value of zil_commit_writer_count from the zfs/zil kstat.
+# TYPE zfs_kstat_zil_zil_commit_writer_count gauge
+zfs_kstat_zil_zil_commit_writer_count -12
+# HELP zfs_kstat_zil_zil_itx_count_total Value of zil_itx_count from the zfs/zil kstat.
+# TYPE zfs_kstat_zil_zil_itx_count_total counter
+zfs_kstat_zil_zil_itx_count_total 56
+`,
+		},
+		{
+			name:           `selected stats`,
+			kstat:          `dmu_tx`,
+			propsRequested: []string{`dmu_tx_assigned`},
+			metricNames:    []string{`zfs_kstat_dmu_tx_dmu_tx_assigned_total`, `zfs_kstat_dmu_tx_dmu_tx_delay_total`},
+			kstatResults: []zfs.KstatNamed{
+				{Name: `dmu_tx_assigned`, Type: zfs.KstatDataUint64, Value: `4096`},
+				{Name: `dmu_tx_delay`, Type: zfs.KstatDataUint64, Value: `2`},
+			},
+			metricResults: `# HELP zfs_kstat_dmu_tx_dmu_tx_assigned_total Value of dmu_tx_assigned from the zfs/dmu_tx kstat.
+# TYPE zfs_kstat_dmu_tx_dmu_tx_assigned_total counter
+zfs_kstat_dmu_tx_dmu_tx_assigned_total 4096
+`,
+		},
+		{
+			name:        `configured gauges`,
+			kstat:       `zil`,
+			gauges:      []string{`zil=^zil_itx_count$`},
+			metricNames: []string{`zfs_kstat_zil_zil_commit_count_total`, `zfs_kstat_zil_zil_itx_count`},
+			kstatResults: []zfs.KstatNamed{
+				{Name: `zil_commit_count`, Type: zfs.KstatDataUint64, Value: `1234`},
+				{Name: `zil_itx_count`, Type: zfs.KstatDataUint64, Value: `56`},
+			},
+			metricResults: `# HELP zfs_kstat_zil_zil_commit_count_total Value of zil_commit_count from the zfs/zil kstat.
+# TYPE zfs_kstat_zil_zil_commit_count_total counter
+zfs_kstat_zil_zil_commit_count_total 1234
+# HELP zfs_kstat_zil_zil_itx_count Value of zil_itx_count from the zfs/zil kstat.
+# TYPE zfs_kstat_zil_zil_itx_count gauge
+zfs_kstat_zil_zil_itx_count 56
+`,
+		},
+		{
+			name:        `unsigned gauges`,
+			kstat:       `dbufstats`,
+			metricNames: []string{`zfs_kstat_dbufstats_cache_size_bytes`, `zfs_kstat_dbufstats_cache_level_0_bytes`, `zfs_kstat_dbufstats_hash_hits_total`},
+			kstatResults: []zfs.KstatNamed{
+				{Name: `cache_size_bytes`, Type: zfs.KstatDataUint64, Value: `1048576`},
+				{Name: `cache_level_0_bytes`, Type: zfs.KstatDataUint64, Value: `524288`},
+				{Name: `hash_hits`, Type: zfs.KstatDataUint64, Value: `789`},
+			},
+			metricResults: `# HELP zfs_kstat_dbufstats_cache_level_0_bytes Value of cache_level_0_bytes from the zfs/dbufstats kstat.
+# TYPE zfs_kstat_dbufstats_cache_level_0_bytes gauge
+zfs_kstat_dbufstats_cache_level_0_bytes 524288
+# HELP zfs_kstat_dbufstats_cache_size_bytes Value of cache_size_bytes from the zfs/dbufstats kstat.
+# TYPE zfs_kstat_dbufstats_cache_size_bytes gauge
+zfs_kstat_dbufstats_cache_size_bytes 1.048576e+06
+# HELP zfs_kstat_dbufstats_hash_hits_total Value of hash_hits from the zfs/dbufstats kstat.
+# TYPE zfs_kstat_dbufstats_hash_hits_total counter
+zfs_kstat_dbufstats_hash_hits_total 789
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			zfsClient := mock_zfs.NewMockClient(ctrl)
+			config := defaultConfig(zfsClient)
+
+			zfsClient.EXPECT().PoolNames(gomock.Any()).Return([]string{`testpool`}, nil).Times(1)
+			zfsClient.EXPECT().Kstat(gomock.Any(), `zfs`, tc.kstat).Return(zfs.Kstat{Module: `zfs`, Name: tc.kstat, Data: tc.kstatResults}, nil).Times(1)
+
+			collector, err := NewZFS(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			collector.Collectors = map[string]State{
+				`kstat-` + tc.kstat: {
+					Name:       `kstat-` + tc.kstat,
+					Enabled:    boolPointer(true),
+					Properties: stringPointer(strings.Join(tc.propsRequested, `,`)),
+					factory:    newKstatCollectorFactory(`zfs`, tc.kstat, newKstatGaugeSet(&tc.gauges)),
+				},
+			}
+
+			if err = callCollector(ctx, collector, []byte(tc.metricResults), tc.metricNames); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestParseKstatGauges(t *testing.T) {
+	gauges, err := parseKstatGauges([]string{`dbufstats=^custom$`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !gauges[`dbufstats`].MatchString(`custom`) || !gauges[`dbufstats`].MatchString(`cache_size_bytes`) {
+		t.Fatal(`Expected configured gauges in addition to the built-in gauges`)
+	}
+
+	for _, invalid := range []string{`dbufstats`, `=^custom$`, `dbufstats=(`} {
+		if _, err = parseKstatGauges([]string{invalid}); err == nil {
+			t.Fatalf("Expected error for gauge %q", invalid)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package collector
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+const (
+	defaultPoolLatencyProps = `disk_wait,queue_wait,total_wait`
+
+	latencySumUnavailable = ` ZFS does not report the sum of observations, so the sum is estimated from the midpoint of each bucket.`
+)
+
+type latencyHistogram struct {
+	name string
+	desc *prometheus.Desc
+	// columns maps `zpool iostat -w` columns to the value of the final label
+	columns map[string]string
+}
+
+var (
+	latencyHistograms = map[string]latencyHistogram{
+		`disk_wait`: newLatencyHistogram(
+			`disk_wait_seconds`,
+			`Histogram of I/O latency in seconds spent waiting on disk, excluding queue time.`+latencySumUnavailable,
+			`operation`,
+			map[string]string{
+				`disk_wait_read`:  `read`,
+				`disk_wait_write`: `write`,
+			},
+		),
+		`queue_wait`: newLatencyHistogram(
+			`queue_wait_seconds`,
+			`Histogram of I/O latency in seconds spent waiting in the scheduler queues.`+latencySumUnavailable,
+			`queue`,
+			map[string]string{
+				`syncq_wait_read`:   `sync_read`,
+				`syncq_wait_write`:  `sync_write`,
+				`asyncq_wait_read`:  `async_read`,
+				`asyncq_wait_write`: `async_write`,
+				`scrub`:             `scrub`,
+				`trim`:              `trim`,
+				`rebuild`:           `rebuild`,
+				// Labels prior to OpenZFS 0.8
+				`sync_queue_read`:   `sync_read`,
+				`sync_queue_write`:  `sync_write`,
+				`async_queue_read`:  `async_read`,
+				`async_queue_write`: `async_write`,
+			},
+		),
+		`total_wait`: newLatencyHistogram(
+			`total_wait_seconds`,
+			`Histogram of total I/O latency in seconds, including queue and disk time.`+latencySumUnavailable,
+			`operation`,
+			map[string]string{
+				`total_wait_read`:  `read`,
+				`total_wait_write`: `write`,
+			},
+		),
+	}
+)
+
+func init() {
+	vdevs := kingpin.Flag(`collector.pool-latency.vdevs`, `Include per-vdev histograms for the pool-latency collector, in addition to the pool totals.`).Default(`false`).Bool()
+	registerCollector(`pool-latency`, defaultDisabled, defaultPoolLatencyProps, newPoolLatencyCollectorFactory(vdevs))
+}
+
+type poolLatencyCollector struct {
+	log    log.Logger
+	client zfs.Client
+	props  []string
+	vdevs  bool
+}
+
+func (c *poolLatencyCollector) describe(ch chan<- *prometheus.Desc) {
+	for _, k := range c.props {
+		h, ok := latencyHistograms[k]
+		if !ok {
+			_ = level.Warn(c.log).Log(`msg`, propertyUnsupportedMsg, `help`, helpIssue, `collector`, `pool-latency`, `property`, k, `err`, errUnsupportedProperty)
+			continue
+		}
+		ch <- h.desc
+	}
+}
+
+func (c *poolLatencyCollector) update(ctx context.Context, ch chan<- metric, pools []string, excludes regexpCollection) error {
+	var wg sync.WaitGroup
+	errChan := make(chan error, len(pools))
+	for _, pool := range pools {
+		wg.Add(1)
+		go func(pool string) {
+			if err := c.updatePoolMetrics(ctx, ch, pool); err != nil {
+				errChan <- err
+			}
+			wg.Done()
+		}(pool)
+	}
+	wg.Wait()
+
+	select {
+	case err := <-errChan:
+		return err
+	default:
+		return nil
+	}
+}
+
+func (c *poolLatencyCollector) updatePoolMetrics(ctx context.Context, ch chan<- metric, pool string) error {
+	histograms, err := c.client.Pool(pool).LatencyHistograms(ctx, c.vdevs)
+	if err != nil {
+		return err
+	}
+
+	for _, histogram := range histograms {
+		for _, k := range c.props {
+			h, ok := latencyHistograms[k]
+			if !ok {
+				continue
+			}
+			for column, label := range h.columns {
+				counts, ok := histogram.Counts[column]
+				if !ok {
+					continue
+				}
+				h.push(ch, histogram.Buckets, counts, pool, histogram.Vdev, label)
+			}
+		}
+	}
+
+	return nil
+}
+
+// push sends the histogram, estimating the sum by assuming that each observation lies at the midpoint of its bucket,
+// between the bound of the previous bucket (or zero) and the bound of its own bucket.
+func (h latencyHistogram) push(ch chan<- metric, bounds []time.Duration, counts []uint64, labelValues ...string) {
+	var (
+		count uint64
+		sum   float64
+		lower time.Duration
+	)
+	buckets := make(map[float64]uint64, len(bounds))
+	for i, bound := range bounds {
+		count += counts[i]
+		sum += float64(counts[i]) * (lower + bound).Seconds() / 2
+		buckets[bound.Seconds()] = count
+		lower = bound
+	}
+	ch <- metric{
+		name:       expandMetricName(h.name, labelValues...),
+		prometheus: prometheus.MustNewConstHistogram(h.desc, count, sum, buckets, labelValues...),
+	}
+}
+
+func newLatencyHistogram(metricName, helpText, label string, columns map[string]string) latencyHistogram {
+	name := prometheus.BuildFQName(namespace, subsystemPool, metricName)
+	return latencyHistogram{
+		name:    name,
+		desc:    prometheus.NewDesc(name, helpText, []string{`pool`, `vdev`, label}, nil),
+		columns: columns,
+	}
+}
+
+func newPoolLatencyCollectorFactory(vdevs *bool) factoryFunc {
+	return func(l log.Logger, c zfs.Client, props []string) (Collector, error) {
+		return &poolLatencyCollector{log: l, client: c, props: props, vdevs: *vdevs}, nil
+	}
+}
@@ -0,0 +1,125 @@
+package collector
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/pdf/zfs_exporter/v2/zfs/mock_zfs"
+)
+
+func TestPoolLatencyMetrics(t *testing.T) {
+	testCases := []struct {
+		name              string
+		vdevs             bool
+		propsRequested    []string
+		metricNames       []string
+		histogramsResults []zfs.LatencyHistogram
+		metricResults     string
+	}{
+		{
+			name:           `pool totals`,
+			propsRequested: []string{`total_wait`, `queue_wait`},
+			metricNames:    []string{`zfs_pool_total_wait_seconds`, `zfs_pool_queue_wait_seconds`},
+			histogramsResults: []zfs.LatencyHistogram{
+				{
+					Vdev:    `testpool`,
+					Buckets: []time.Duration{1023, 2047, 4095},
+					Counts: map[string][]uint64{
+						`total_wait_read`:  {1, 2, 3},
+						`total_wait_write`: {0, 0, 4},
+						`disk_wait_read`:   {1, 1, 1},
+						`scrub`:            {5, 0, 0},
+					},
+				},
+			},
+			metricResults: `# HELP zfs_pool_queue_wait_seconds Histogram of I/O latency in seconds spent waiting in the scheduler queues. ZFS does not report the sum of observations, so the sum is estimated from the midpoint of each bucket.
+# TYPE zfs_pool_queue_wait_seconds histogram
+zfs_pool_queue_wait_seconds_bucket{pool="testpool",queue="scrub",vdev="testpool",le="1.023e-06"} 5
+zfs_pool_queue_wait_seconds_bucket{pool="testpool",queue="scrub",vdev="testpool",le="2.047e-06"} 5
+zfs_pool_queue_wait_seconds_bucket{pool="testpool",queue="scrub",vdev="testpool",le="4.095e-06"} 5
+zfs_pool_queue_wait_seconds_bucket{pool="testpool",queue="scrub",vdev="testpool",le="+Inf"} 5
+zfs_pool_queue_wait_seconds_sum{pool="testpool",queue="scrub",vdev="testpool"} 2.5575e-06
+zfs_pool_queue_wait_seconds_count{pool="testpool",queue="scrub",vdev="testpool"} 5
+# HELP zfs_pool_total_wait_seconds Histogram of total I/O latency in seconds, including queue and disk time. ZFS does not report the sum of observations, so the sum is estimated from the midpoint of each bucket.
+# TYPE zfs_pool_total_wait_seconds histogram
+zfs_pool_total_wait_seconds_bucket{operation="read",pool="testpool",vdev="testpool",le="1.023e-06"} 1
+zfs_pool_total_wait_seconds_bucket{operation="read",pool="testpool",vdev="testpool",le="2.047e-06"} 3
+zfs_pool_total_wait_seconds_bucket{operation="read",pool="testpool",vdev="testpool",le="4.095e-06"} 6
+zfs_pool_total_wait_seconds_bucket{operation="read",pool="testpool",vdev="testpool",le="+Inf"} 6
+zfs_pool_total_wait_seconds_sum{operation="read",pool="testpool",vdev="testpool"} 1.27945e-05
+zfs_pool_total_wait_seconds_count{operation="read",pool="testpool",vdev="testpool"} 6
+zfs_pool_total_wait_seconds_bucket{operation="write",pool="testpool",vdev="testpool",le="1.023e-06"} 0
+zfs_pool_total_wait_seconds_bucket{operation="write",pool="testpool",vdev="testpool",le="2.047e-06"} 0
+zfs_pool_total_wait_seconds_bucket{operation="write",pool="testpool",vdev="testpool",le="4.095e-06"} 4
+zfs_pool_total_wait_seconds_bucket{operation="write",pool="testpool",vdev="testpool",le="+Inf"} 4
+zfs_pool_total_wait_seconds_sum{operation="write",pool="testpool",vdev="testpool"} 1.2284e-05
+zfs_pool_total_wait_seconds_count{operation="write",pool="testpool",vdev="testpool"} 4
+`,
+		},
+		{
+			name:           `vdevs`,
+			vdevs:          true,
+			propsRequested: []string{`disk_wait`},
+			metricNames:    []string{`zfs_pool_disk_wait_seconds`},
+			histogramsResults: []zfs.LatencyHistogram{
+				{
+					Vdev:    `testpool`,
+					Buckets: []time.Duration{1023},
+					Counts:  map[string][]uint64{`disk_wait_read`: {2}},
+				},
+				{
+					Vdev:    `sda`,
+					Buckets: []time.Duration{1023},
+					Counts:  map[string][]uint64{`disk_wait_read`: {1}},
+				},
+			},
+			metricResults: `# HELP zfs_pool_disk_wait_seconds Histogram of I/O latency in seconds spent waiting on disk, excluding queue time. ZFS does not report the sum of observations, so the sum is estimated from the midpoint of each bucket.
+# TYPE zfs_pool_disk_wait_seconds histogram
+zfs_pool_disk_wait_seconds_bucket{operation="read",pool="testpool",vdev="sda",le="1.023e-06"} 1
+zfs_pool_disk_wait_seconds_bucket{operation="read",pool="testpool",vdev="sda",le="+Inf"} 1
+zfs_pool_disk_wait_seconds_sum{operation="read",pool="testpool",vdev="sda"} 5.115e-07
+zfs_pool_disk_wait_seconds_count{operation="read",pool="testpool",vdev="sda"} 1
+zfs_pool_disk_wait_seconds_bucket{operation="read",pool="testpool",vdev="testpool",le="1.023e-06"} 2
+zfs_pool_disk_wait_seconds_bucket{operation="read",pool="testpool",vdev="testpool",le="+Inf"} 2
+zfs_pool_disk_wait_seconds_sum{operation="read",pool="testpool",vdev="testpool"} 1.023e-06
+zfs_pool_disk_wait_seconds_count{operation="read",pool="testpool",vdev="testpool"} 2
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			zfsClient := mock_zfs.NewMockClient(ctrl)
+			config := defaultConfig(zfsClient)
+
+			zfsClient.EXPECT().PoolNames(gomock.Any()).Return([]string{`testpool`}, nil).Times(1)
+			zfsPool := mock_zfs.NewMockPool(ctrl)
+			zfsPool.EXPECT().LatencyHistograms(gomock.Any(), tc.vdevs).Return(tc.histogramsResults, nil).Times(1)
+			zfsClient.EXPECT().Pool(`testpool`).Return(zfsPool).Times(1)
+
+			collector, err := NewZFS(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			collector.Collectors = map[string]State{
+				`pool-latency`: {
+					Name:       "pool-latency",
+					Enabled:    boolPointer(true),
+					Properties: stringPointer(strings.Join(tc.propsRequested, `,`)),
+					factory:    newPoolLatencyCollectorFactory(boolPointer(tc.vdevs)),
+				},
+			}
+
+			if err = callCollector(ctx, collector, []byte(tc.metricResults), tc.metricNames); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
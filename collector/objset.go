@@ -0,0 +1,169 @@
+package collector
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	defaultObjsetProps = `nread,nunlinked,nunlinks,nwritten,reads,writes`
+
+	objsetDatasetName = `dataset_name`
+)
+
+var (
+	objsetProperties = propertyStore{
+		defaultSubsystem: subsystemDataset,
+		defaultLabels:    datasetLabels,
+		store: map[string]property{
+			`nread`: newCounterProperty(
+				subsystemDataset,
+				`read_bytes_total`,
+				`Total number of bytes read from this dataset.`,
+				transformNumeric,
+				datasetLabels...,
+			),
+			`nunlinked`: newCounterProperty(
+				subsystemDataset,
+				`unlinked_total`,
+				`Total number of files unlinked from this dataset after being queued for deletion.`,
+				transformNumeric,
+				datasetLabels...,
+			),
+			`nunlinks`: newCounterProperty(
+				subsystemDataset,
+				`unlinks_total`,
+				`Total number of files queued for deletion from this dataset.`,
+				transformNumeric,
+				datasetLabels...,
+			),
+			`nwritten`: newCounterProperty(
+				subsystemDataset,
+				`write_bytes_total`,
+				`Total number of bytes written to this dataset.`,
+				transformNumeric,
+				datasetLabels...,
+			),
+			`reads`: newCounterProperty(
+				subsystemDataset,
+				`reads_total`,
+				`Total number of read operations on this dataset.`,
+				transformNumeric,
+				datasetLabels...,
+			),
+			`writes`: newCounterProperty(
+				subsystemDataset,
+				`writes_total`,
+				`Total number of write operations on this dataset.`,
+				transformNumeric,
+				datasetLabels...,
+			),
+		},
+	}
+)
+
+func init() {
+	registerCollector(`dataset-io`, defaultDisabled, defaultObjsetProps, newObjsetCollector)
+}
+
+type objsetCollector struct {
+	log    log.Logger
+	client zfs.Client
+	props  []string
+}
+
+func (c *objsetCollector) describe(ch chan<- *prometheus.Desc) {
+	for _, k := range c.props {
+		prop, err := objsetProperties.find(k)
+		if err != nil {
+			_ = level.Warn(c.log).Log(`msg`, propertyUnsupportedMsg, `help`, helpIssue, `collector`, `dataset-io`, `property`, k, `err`, err)
+			continue
+		}
+		ch <- prop.desc
+	}
+}
+
+func (c *objsetCollector) update(ctx context.Context, ch chan<- metric, pools []string, excludes regexpCollection) error {
+	var wg sync.WaitGroup
+	errChan := make(chan error, len(pools))
+	for _, pool := range pools {
+		wg.Add(1)
+		go func(pool string) {
+			if err := c.updatePoolMetrics(ctx, ch, pool, excludes); err != nil {
+				errChan <- err
+			}
+			wg.Done()
+		}(pool)
+	}
+	wg.Wait()
+
+	select {
+	case err := <-errChan:
+		return err
+	default:
+		return nil
+	}
+}
+
+func (c *objsetCollector) updatePoolMetrics(ctx context.Context, ch chan<- metric, pool string, excludes regexpCollection) error {
+	objsets, err := c.client.ObjsetKstats(ctx, pool)
+	if err != nil {
+		return err
+	}
+	kinds, err := c.datasetKinds(ctx, pool)
+	if err != nil {
+		return err
+	}
+
+	for _, objset := range objsets {
+		values := objset.Values()
+		name := values[objsetDatasetName]
+		// Objsets for snapshots and internal datasets (eg - $ORIGIN) are not reported.
+		kind, ok := kinds[name]
+		if !ok || excludes.MatchString(name) {
+			continue
+		}
+
+		labelValues := []string{name, pool, string(kind)}
+		for _, k := range c.props {
+			v, ok := values[k]
+			if !ok {
+				continue
+			}
+			prop, err := objsetProperties.find(k)
+			if err != nil {
+				_ = level.Warn(c.log).Log(`msg`, propertyUnsupportedMsg, `help`, helpIssue, `collector`, `dataset-io`, `property`, k, `err`, err)
+			}
+			if err = prop.push(ch, v, labelValues...); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// datasetKinds maps dataset names in the pool to their kind, since the objset kstats do not include the type.
+func (c *objsetCollector) datasetKinds(ctx context.Context, pool string) (map[string]zfs.DatasetKind, error) {
+	result := make(map[string]zfs.DatasetKind)
+	for _, kind := range []zfs.DatasetKind{zfs.DatasetFilesystem, zfs.DatasetVolume} {
+		datasets, err := c.client.Datasets(pool, kind).Properties(ctx, `type`)
+		if err != nil {
+			return nil, err
+		}
+		for _, dataset := range datasets {
+			result[dataset.DatasetName()] = kind
+		}
+	}
+
+	return result, nil
+}
+
+func newObjsetCollector(l log.Logger, c zfs.Client, props []string) (Collector, error) {
+	return &objsetCollector{log: l, client: c, props: props}, nil
+}
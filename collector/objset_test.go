@@ -0,0 +1,171 @@
+package collector
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/pdf/zfs_exporter/v2/zfs/mock_zfs"
+)
+
+func TestObjsetMetrics(t *testing.T) {
+	testCases := []struct {
+		name           string
+		pools          []string
+		explicitPools  []string
+		excludes       []string
+		propsRequested []string
+		metricNames    []string
+		objsetResults  map[string][]map[string]string
+		kindResults    map[string]map[zfs.DatasetKind][]string
+		metricResults  string
+	}{
+		{
+			name:           `all metrics`,
+			pools:          []string{`testpool`},
+			propsRequested: []string{`nread`, `nunlinked`, `nunlinks`, `nwritten`, `reads`, `writes`},
+			metricNames:    []string{`zfs_dataset_read_bytes_total`, `zfs_dataset_unlinked_total`, `zfs_dataset_unlinks_total`, `zfs_dataset_write_bytes_total`, `zfs_dataset_reads_total`, `zfs_dataset_writes_total`},
+			objsetResults: map[string][]map[string]string{
+				`testpool`: {
+					{
+						`dataset_name`: `testpool/test`,
+						`nread`:        `1024`,
+						`nunlinked`:    `2`,
+						`nunlinks`:     `3`,
+						`nwritten`:     `2048`,
+						`reads`:        `4`,
+						`writes`:       `5`,
+					},
+				},
+			},
+			kindResults: map[string]map[zfs.DatasetKind][]string{
+				`testpool`: {
+					zfs.DatasetFilesystem: {`testpool/test`},
+				},
+			},
+			metricResults: `# HELP zfs_dataset_read_bytes_total Total number of bytes read from this dataset.
+# TYPE zfs_dataset_read_bytes_total counter
+zfs_dataset_read_bytes_total{name="testpool/test",pool="testpool",type="filesystem"} 1024
+# HELP zfs_dataset_reads_total Total number of read operations on this dataset.
+# TYPE zfs_dataset_reads_total counter
+zfs_dataset_reads_total{name="testpool/test",pool="testpool",type="filesystem"} 4
+# HELP zfs_dataset_unlinked_total Total number of files unlinked from this dataset after being queued for deletion.
+# TYPE zfs_dataset_unlinked_total counter
+zfs_dataset_unlinked_total{name="testpool/test",pool="testpool",type="filesystem"} 2
+# HELP zfs_dataset_unlinks_total Total number of files queued for deletion from this dataset.
+# TYPE zfs_dataset_unlinks_total counter
+zfs_dataset_unlinks_total{name="testpool/test",pool="testpool",type="filesystem"} 3
+# HELP zfs_dataset_write_bytes_total Total number of bytes written to this dataset.
+# TYPE zfs_dataset_write_bytes_total counter
+zfs_dataset_write_bytes_total{name="testpool/test",pool="testpool",type="filesystem"} 2048
+# HELP zfs_dataset_writes_total Total number of write operations on this dataset.
+# TYPE zfs_dataset_writes_total counter
+zfs_dataset_writes_total{name="testpool/test",pool="testpool",type="filesystem"} 5
+`,
+		},
+		{
+			name:           `volumes, excludes and unknown datasets`,
+			pools:          []string{`testpool`},
+			excludes:       []string{`^testpool/excluded`},
+			propsRequested: []string{`reads`},
+			metricNames:    []string{`zfs_dataset_reads_total`},
+			objsetResults: map[string][]map[string]string{
+				`testpool`: {
+					{`dataset_name`: `testpool/fs`, `reads`: `1`},
+					{`dataset_name`: `testpool/vol`, `reads`: `2`},
+					{`dataset_name`: `testpool/excluded`, `reads`: `3`},
+					{`dataset_name`: `testpool/$ORIGIN`, `reads`: `4`},
+				},
+			},
+			kindResults: map[string]map[zfs.DatasetKind][]string{
+				`testpool`: {
+					zfs.DatasetFilesystem: {`testpool/fs`, `testpool/excluded`},
+					zfs.DatasetVolume:     {`testpool/vol`},
+				},
+			},
+			metricResults: `# HELP zfs_dataset_reads_total Total number of read operations on this dataset.
+# TYPE zfs_dataset_reads_total counter
+zfs_dataset_reads_total{name="testpool/fs",pool="testpool",type="filesystem"} 1
+zfs_dataset_reads_total{name="testpool/vol",pool="testpool",type="volume"} 2
+`,
+		},
+		{
+			name:           `explicit pools`,
+			pools:          []string{`testpool1`, `testpool2`},
+			explicitPools:  []string{`testpool2`},
+			propsRequested: []string{`reads`},
+			metricNames:    []string{`zfs_dataset_reads_total`},
+			objsetResults: map[string][]map[string]string{
+				`testpool2`: {
+					{`dataset_name`: `testpool2`, `reads`: `1`},
+				},
+			},
+			kindResults: map[string]map[zfs.DatasetKind][]string{
+				`testpool2`: {
+					zfs.DatasetFilesystem: {`testpool2`},
+				},
+			},
+			metricResults: `# HELP zfs_dataset_reads_total Total number of read operations on this dataset.
+# TYPE zfs_dataset_reads_total counter
+zfs_dataset_reads_total{name="testpool2",pool="testpool2",type="filesystem"} 1
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			zfsClient := mock_zfs.NewMockClient(ctrl)
+			config := defaultConfig(zfsClient)
+			config.Pools = tc.explicitPools
+			config.Excludes = tc.excludes
+
+			zfsClient.EXPECT().PoolNames(gomock.Any()).Return(tc.pools, nil).Times(1)
+			for pool, objsets := range tc.objsetResults {
+				kstats := make([]zfs.Kstat, len(objsets))
+				for i, values := range objsets {
+					data := make([]zfs.KstatNamed, 0, len(values))
+					for k, v := range values {
+						data = append(data, zfs.KstatNamed{Name: k, Value: v})
+					}
+					kstats[i] = zfs.Kstat{Module: `zfs/` + pool, Data: data}
+				}
+				zfsClient.EXPECT().ObjsetKstats(gomock.Any(), pool).Return(kstats, nil).Times(1)
+
+				for _, kind := range []zfs.DatasetKind{zfs.DatasetFilesystem, zfs.DatasetVolume} {
+					names := tc.kindResults[pool][kind]
+					zfsDatasetResults := make([]zfs.DatasetProperties, len(names))
+					for i, name := range names {
+						zfsDatasetProperties := mock_zfs.NewMockDatasetProperties(ctrl)
+						zfsDatasetProperties.EXPECT().DatasetName().Return(name).Times(1)
+						zfsDatasetResults[i] = zfsDatasetProperties
+					}
+					zfsDatasets := mock_zfs.NewMockDatasets(ctrl)
+					zfsDatasets.EXPECT().Properties(gomock.Any(), `type`).Return(zfsDatasetResults, nil).Times(1)
+					zfsClient.EXPECT().Datasets(pool, kind).Return(zfsDatasets).Times(1)
+				}
+			}
+
+			collector, err := NewZFS(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			collector.Collectors = map[string]State{
+				`dataset-io`: {
+					Name:       "dataset-io",
+					Enabled:    boolPointer(true),
+					Properties: stringPointer(strings.Join(tc.propsRequested, `,`)),
+					factory:    newObjsetCollector,
+				},
+			}
+
+			if err = callCollector(ctx, collector, []byte(tc.metricResults), tc.metricNames); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
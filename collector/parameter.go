@@ -0,0 +1,102 @@
+package collector
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/go-kit/log"
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+const (
+	subsystemModule = `module`
+)
+
+var (
+	moduleParameterDescName = prometheus.BuildFQName(namespace, subsystemModule, `parameter`)
+	moduleParameterDesc     = prometheus.NewDesc(
+		moduleParameterDescName,
+		`Value of the numeric ZFS kernel module parameter (tunable) currently in effect.`,
+		[]string{`name`},
+		nil,
+	)
+	moduleParameterInfoDescName = prometheus.BuildFQName(namespace, subsystemModule, `parameter_info`)
+	moduleParameterInfoDesc     = prometheus.NewDesc(
+		moduleParameterInfoDescName,
+		`Value of the non-numeric ZFS kernel module parameter (tunable) currently in effect, as the value label. The value is always 1.`,
+		[]string{`name`, `value`},
+		nil,
+	)
+)
+
+func init() {
+	includes := kingpin.Flag(`collector.module-parameters.include`, `Include only module parameters that match the provided regex (e.g. '^zfs_arc_') in the module-parameters collector, may be specified multiple times (default: all parameters).`).Strings()
+	excludes := kingpin.Flag(`collector.module-parameters.exclude`, `Exclude module parameters that match the provided regex in the module-parameters collector, may be specified multiple times.`).Strings()
+	registerCollectorWithoutProperties(`module-parameters`, defaultDisabled, newModuleParameterCollectorFactory(includes, excludes))
+}
+
+type moduleParameterCollector struct {
+	log      log.Logger
+	client   zfs.Client
+	includes regexpCollection
+	excludes regexpCollection
+}
+
+func (c *moduleParameterCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- moduleParameterDesc
+	ch <- moduleParameterInfoDesc
+}
+
+func (c *moduleParameterCollector) update(ctx context.Context, ch chan<- metric, pools []string, excludes regexpCollection) error {
+	params, err := c.client.ModuleParameters(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, param := range params {
+		if (len(c.includes) > 0 && !c.includes.MatchString(param.Name)) || c.excludes.MatchString(param.Name) {
+			continue
+		}
+		if value, ok := parseModuleParameter(param.Value); ok {
+			ch <- metric{
+				name:       expandMetricName(moduleParameterDescName, param.Name),
+				prometheus: prometheus.MustNewConstMetric(moduleParameterDesc, prometheus.GaugeValue, value, param.Name),
+			}
+			continue
+		}
+		ch <- metric{
+			name:       expandMetricName(moduleParameterInfoDescName, param.Name),
+			prometheus: prometheus.MustNewConstMetric(moduleParameterInfoDesc, prometheus.GaugeValue, 1, param.Name, param.Value),
+		}
+	}
+
+	return nil
+}
+
+// parseModuleParameter returns the value of integer parameters, other values (ie - implementation selections) are
+// not numeric, even where they would parse as a float (ie - `inf`).
+func parseModuleParameter(value string) (float64, bool) {
+	if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return float64(v), true
+	}
+	if v, err := strconv.ParseUint(value, 10, 64); err == nil {
+		return float64(v), true
+	}
+	return 0, false
+}
+
+func newModuleParameterCollectorFactory(includes, excludes *[]string) factoryFunc {
+	return func(l log.Logger, c zfs.Client, props []string) (Collector, error) {
+		included, err := newRegexpCollection(*includes)
+		if err != nil {
+			return nil, err
+		}
+		excluded, err := newRegexpCollection(*excludes)
+		if err != nil {
+			return nil, err
+		}
+		return &moduleParameterCollector{log: l, client: c, includes: included, excludes: excluded}, nil
+	}
+}
@@ -0,0 +1,86 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/pdf/zfs_exporter/v2/zfs/mock_zfs"
+)
+
+func TestModuleParameterMetrics(t *testing.T) {
+	params := []zfs.ModuleParameter{
+		{Name: `zfs_arc_max`, Value: `4294967296`},
+		{Name: `zfs_arc_meta_limit_percent`, Value: `-1`},
+		{Name: `zfs_arc_min`, Value: `0`},
+		{Name: `zfs_dirty_data_max`, Value: `429496729`},
+		{Name: `zfs_fletcher_4_impl`, Value: `fletcher4`},
+		{Name: `zfs_vdev_async_write_max_active`, Value: `10`},
+		{Name: `zfs_vdev_raidz_impl`, Value: `[fastest] original scalar sse2 ssse3 avx2`},
+	}
+
+	testCases := []struct {
+		name          string
+		includes      []string
+		excludes      []string
+		metricResults string
+	}{
+		{
+			name: `all parameters`,
+			metricResults: `# HELP zfs_module_parameter Value of the numeric ZFS kernel module parameter (tunable) currently in effect.
+# TYPE zfs_module_parameter gauge
+zfs_module_parameter{name="zfs_arc_max"} 4.294967296e+09
+zfs_module_parameter{name="zfs_arc_meta_limit_percent"} -1
+zfs_module_parameter{name="zfs_arc_min"} 0
+zfs_module_parameter{name="zfs_dirty_data_max"} 4.29496729e+08
+zfs_module_parameter{name="zfs_vdev_async_write_max_active"} 10
+# HELP zfs_module_parameter_info Value of the non-numeric ZFS kernel module parameter (tunable) currently in effect, as the value label. The value is always 1.
+# TYPE zfs_module_parameter_info gauge
+zfs_module_parameter_info{name="zfs_fletcher_4_impl",value="fletcher4"} 1
+zfs_module_parameter_info{name="zfs_vdev_raidz_impl",value="[fastest] original scalar sse2 ssse3 avx2"} 1
+`,
+		},
+		{
+			name:     `filtered parameters`,
+			includes: []string{`^zfs_arc_`, `_max_active$`},
+			excludes: []string{`_percent$`},
+			metricResults: `# HELP zfs_module_parameter Value of the numeric ZFS kernel module parameter (tunable) currently in effect.
+# TYPE zfs_module_parameter gauge
+zfs_module_parameter{name="zfs_arc_max"} 4.294967296e+09
+zfs_module_parameter{name="zfs_arc_min"} 0
+zfs_module_parameter{name="zfs_vdev_async_write_max_active"} 10
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			zfsClient := mock_zfs.NewMockClient(ctrl)
+			config := defaultConfig(zfsClient)
+
+			zfsClient.EXPECT().PoolNames(gomock.Any()).Return([]string{`testpool`}, nil).Times(1)
+			zfsClient.EXPECT().ModuleParameters(gomock.Any()).Return(params, nil).Times(1)
+
+			collector, err := NewZFS(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			collector.Collectors = map[string]State{
+				`module-parameters`: {
+					Name:       "module-parameters",
+					Enabled:    boolPointer(true),
+					Properties: stringPointer(``),
+					factory:    newModuleParameterCollectorFactory(&tc.includes, &tc.excludes),
+				},
+			}
+
+			if err = callCollector(ctx, collector, []byte(tc.metricResults), []string{`zfs_module_parameter`, `zfs_module_parameter_info`}); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"fmt"
 	"sync"
 
@@ -111,7 +112,7 @@ var (
 
 func init() {
 	registerCollector(`pool`, defaultEnabled, defaultPoolProps, newPoolCollector)
-	registerCollector(`pool-disks`, defaultEnabled, "", newPoolDiskCollector)
+	registerCollectorWithoutProperties(`pool-disks`, defaultEnabled, newPoolDiskCollector)
 }
 
 type poolCollector struct {
@@ -131,13 +132,13 @@ func (c *poolCollector) describe(ch chan<- *prometheus.Desc) {
 	}
 }
 
-func (c *poolCollector) update(ch chan<- metric, pools []string, excludes regexpCollection) error {
+func (c *poolCollector) update(ctx context.Context, ch chan<- metric, pools []string, excludes regexpCollection) error {
 	var wg sync.WaitGroup
 	errChan := make(chan error, len(pools))
 	for _, pool := range pools {
 		wg.Add(1)
 		go func(pool string) {
-			if err := c.updatePoolMetrics(ch, pool); err != nil {
+			if err := c.updatePoolMetrics(ctx, ch, pool); err != nil {
 				errChan <- err
 			}
 			wg.Done()
@@ -153,9 +154,9 @@ func (c *poolCollector) update(ch chan<- metric, pools []string, excludes regexp
 	}
 }
 
-func (c *poolCollector) updatePoolMetrics(ch chan<- metric, pool string) error {
+func (c *poolCollector) updatePoolMetrics(ctx context.Context, ch chan<- metric, pool string) error {
 	p := c.client.Pool(pool)
-	props, err := p.Properties(c.props...)
+	props, err := p.Properties(ctx, c.props...)
 	if err != nil {
 		return err
 	}
@@ -184,11 +185,13 @@ type poolDiskCollector struct {
 }
 
 var (
+	diskLabels = []string{`zpool`, `vdev`, `state`, `kind`, `disk`, `class`}
+
 	diskStatusDescName = prometheus.BuildFQName(namespace, `disk`, `status`)
 	diskStatusDesc     = prometheus.NewDesc(
 		diskStatusDescName,
 		`zfs_exporter: Disk status`,
-		[]string{`zpool`, `vdev`, `state`, `kind`, `disk`},
+		diskLabels,
 		nil,
 	)
 
@@ -196,7 +199,7 @@ var (
 	diskReadErrDesc     = prometheus.NewDesc(
 		diskReadErrDescName,
 		`zfs_exporter: Disk read errors`,
-		[]string{`zpool`, `vdev`, `state`, `kind`, `disk`},
+		diskLabels,
 		nil,
 	)
 
@@ -204,7 +207,7 @@ var (
 	diskWriteErrDesc     = prometheus.NewDesc(
 		diskWriteErrDescName,
 		`zfs_exporter: Disk write errors`,
-		[]string{`zpool`, `vdev`, `state`, `kind`, `disk`},
+		diskLabels,
 		nil,
 	)
 
@@ -212,7 +215,7 @@ var (
 	diskChecksumErrDesc     = prometheus.NewDesc(
 		diskChecksumErrDescName,
 		`zfs_exporter: Disk checksum errors`,
-		[]string{`zpool`, `vdev`, `state`, `kind`, `disk`},
+		diskLabels,
 		nil,
 	)
 )
@@ -224,57 +227,86 @@ func (c *poolDiskCollector) describe(ch chan<- *prometheus.Desc) {
 	ch <- diskChecksumErrDesc
 }
 
-func (c *poolDiskCollector) update(ch chan<- metric, pools []string, excludes regexpCollection) error {
-	disks, err := c.client.PoolDisks()
+func (c *poolDiskCollector) update(ctx context.Context, ch chan<- metric, pools []string, excludes regexpCollection) error {
+	// Without any pool arguments, zpool status would report every pool. Pools are selected via --pool, excludes only
+	// apply to datasets.
+	if len(pools) == 0 {
+		return nil
+	}
+
+	trees, err := c.client.VdevTrees(ctx, pools...)
 	if err != nil {
 		return err
 	}
 
-	for _, disk := range disks {
-		labelValues := []string{disk.Zpool, disk.Vdev, disk.State, disk.Kind, disk.Name}
-		ch <- metric{
-			name: "zfs_disk_status",
-			prometheus: prometheus.MustNewConstMetric(
-				diskStatusDesc,
-				prometheus.GaugeValue,
-				1.0,
-				labelValues...,
-			),
-		}
-		if disk.Kind != "spare" {
-			ch <- metric{
-				name: "zfs_disk_read_error",
-				prometheus: prometheus.MustNewConstMetric(
-					diskReadErrDesc,
-					prometheus.GaugeValue,
-					float64(disk.ReadErrors),
-					labelValues...,
-				),
-			}
-			ch <- metric{
-				name: "zfs_disk_write_error",
-				prometheus: prometheus.MustNewConstMetric(
-					diskWriteErrDesc,
-					prometheus.GaugeValue,
-					float64(disk.WriteErrors),
-					labelValues...,
-				),
-			}
-			ch <- metric{
-				name: "zfs_disk_checksum_error",
-				prometheus: prometheus.MustNewConstMetric(
-					diskChecksumErrDesc,
-					prometheus.GaugeValue,
-					float64(disk.ChecksumErrors),
-					labelValues...,
-				),
-			}
-		}
+	for _, tree := range trees {
+		tree.Root.Walk(func(vdev, parent *zfs.Vdev, depth int) {
+			c.updateVdevMetrics(ch, tree.Pool, vdev, parent, depth)
+		})
 	}
 
 	return nil
 }
 
+func (c *poolDiskCollector) updateVdevMetrics(ch chan<- metric, pool string, vdev, parent *zfs.Vdev, depth int) {
+	// Top-level vdevs are reported as their own parent, and nested vdevs (eg - replacing-0) under their immediate
+	// parent.
+	kind, parentName := `disk`, parent.Name
+	switch {
+	case vdev.Class == zfs.VdevClassSpare && depth == 1:
+		kind, parentName = `spare`, ``
+	case depth == 1:
+		kind, parentName = `vdev`, vdev.Name
+	case len(vdev.Children) > 0:
+		kind = `vdev`
+	}
+
+	labelValues := []string{pool, parentName, vdev.State, kind, vdev.Name, string(vdev.Class)}
+	ch <- metric{
+		name: expandMetricName(diskStatusDescName, labelValues...),
+		prometheus: prometheus.MustNewConstMetric(
+			diskStatusDesc,
+			prometheus.GaugeValue,
+			1.0,
+			labelValues...,
+		),
+	}
+	if kind == `spare` {
+		return
+	}
+	if vdev.Err != nil {
+		_ = level.Warn(c.log).Log(`msg`, `Unable to parse vdev error counts`, `pool`, pool, `vdev`, vdev.Name, `err`, vdev.Err)
+		return
+	}
+	ch <- metric{
+		name: expandMetricName(diskReadErrDescName, labelValues...),
+		prometheus: prometheus.MustNewConstMetric(
+			diskReadErrDesc,
+			prometheus.GaugeValue,
+			float64(vdev.ReadErrors),
+			labelValues...,
+		),
+	}
+	ch <- metric{
+		name: expandMetricName(diskWriteErrDescName, labelValues...),
+		prometheus: prometheus.MustNewConstMetric(
+			diskWriteErrDesc,
+			prometheus.GaugeValue,
+			float64(vdev.WriteErrors),
+			labelValues...,
+		),
+	}
+	ch <- metric{
+		name: expandMetricName(diskChecksumErrDescName, labelValues...),
+		prometheus: prometheus.MustNewConstMetric(
+			diskChecksumErrDesc,
+			prometheus.GaugeValue,
+			float64(vdev.ChecksumErrors),
+			labelValues...,
+		),
+	}
+}
+
 func newPoolDiskCollector(l log.Logger, c zfs.Client, _props []string) (Collector, error) {
 	return &poolDiskCollector{log: l, client: c}, nil
 }
@@ -179,7 +179,7 @@ zfs_pool_unsupported{pool="testpool"} 1024
 				config.Pools = tc.explicitPools
 			}
 
-			zfsClient.EXPECT().PoolNames().Return(tc.pools, nil).Times(1)
+			zfsClient.EXPECT().PoolNames(gomock.Any()).Return(tc.pools, nil).Times(1)
 			for _, pool := range tc.pools {
 				if tc.explicitPools != nil {
 					wanted := false
@@ -196,7 +196,7 @@ zfs_pool_unsupported{pool="testpool"} 1024
 				zfsPoolProperties := mock_zfs.NewMockPoolProperties(ctrl)
 				zfsPoolProperties.EXPECT().Properties().Return(tc.propsResults[pool]).Times(1)
 				zfsPool := mock_zfs.NewMockPool(ctrl)
-				zfsPool.EXPECT().Properties(tc.propsRequested).Return(zfsPoolProperties, nil).Times(1)
+				zfsPool.EXPECT().Properties(gomock.Any(), tc.propsRequested).Return(zfsPoolProperties, nil).Times(1)
 				zfsClient.EXPECT().Pool(pool).Return(zfsPool).Times(1)
 			}
 
@@ -0,0 +1,447 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+const subsystemReplication = `replication`
+
+var (
+	replicationLabels = []string{`source`, `target`}
+
+	replicationCommonInfoDescName = prometheus.BuildFQName(namespace, subsystemReplication, `common_snapshot_info`)
+	replicationCommonInfoDesc     = prometheus.NewDesc(
+		replicationCommonInfoDescName,
+		`The newest snapshot common to the source and target datasets, as the snapshot label. The value is always 1.`,
+		append(append([]string{}, replicationLabels...), `snapshot`),
+		nil,
+	)
+	replicationCommonTimestampDescName = prometheus.BuildFQName(namespace, subsystemReplication, `common_snapshot_timestamp_seconds`)
+	replicationCommonTimestampDesc     = prometheus.NewDesc(
+		replicationCommonTimestampDescName,
+		`Unix timestamp of the creation of the newest snapshot common to the source and target datasets.`,
+		replicationLabels,
+		nil,
+	)
+	replicationLagDescName = prometheus.BuildFQName(namespace, subsystemReplication, `lag_seconds`)
+	replicationLagDesc     = prometheus.NewDesc(
+		replicationLagDescName,
+		`Age in seconds of the newest snapshot common to the source and target datasets, relative to the newest snapshot of the source dataset.`,
+		replicationLabels,
+		nil,
+	)
+	replicationBehindDescName = prometheus.BuildFQName(namespace, subsystemReplication, `snapshots_behind`)
+	replicationBehindDesc     = prometheus.NewDesc(
+		replicationBehindDescName,
+		`Number of snapshots of the source dataset newer than the newest snapshot common to the target dataset, or all snapshots of the source dataset if there is no common snapshot.`,
+		replicationLabels,
+		nil,
+	)
+	replicationResumeTokenDescName = prometheus.BuildFQName(namespace, subsystemReplication, `resume_token_pending`)
+	replicationResumeTokenDesc     = prometheus.NewDesc(
+		replicationResumeTokenDescName,
+		`Whether the target dataset has a receive_resume_token from an interrupted receive [0: false, 1: true].`,
+		replicationLabels,
+		nil,
+	)
+
+	replicationPendingDescName = prometheus.BuildFQName(namespace, subsystemReplication, `pending_bytes`)
+	replicationPendingDesc     = prometheus.NewDesc(
+		replicationPendingDescName,
+		`Estimated size in bytes of the incremental stream from the newest snapshot common to the source and target datasets, to the newest snapshot of the source dataset. Refreshed at the configured pending interval.`,
+		replicationLabels,
+		nil,
+	)
+
+	replicationSnapshotProps = []string{`guid`, `createtxg`, `creation`}
+)
+
+func init() {
+	mappings := kingpin.Flag(`collector.replication.mapping`, `Source and target datasets to compare for the replication collector, in the form source=target. The mapping also applies to descendents of the source dataset, may be specified multiple times.`).Strings()
+	pendingInterval := kingpin.Flag(`collector.replication.pending-interval`, `Interval at which to estimate the size of pending incremental sends for the replication collector, via a dry run of zfs send. Set to 0 to disable.`).Default(`0s`).Duration()
+	registerCollectorWithoutProperties(`replication`, defaultDisabled, newReplicationCollectorFactory(mappings, pendingInterval))
+}
+
+// replicationMapping maps a source dataset and its descendents to the target dataset they are replicated to.
+type replicationMapping struct {
+	source string
+	target string
+}
+
+// replicationSnapshot holds the properties used to compare a snapshot between the source and target.
+type replicationSnapshot struct {
+	name      string
+	guid      string
+	createtxg uint64
+	creation  float64
+}
+
+type replicationCollector struct {
+	log      log.Logger
+	client   zfs.Client
+	mappings []replicationMapping
+	pending  *replicationPendingCache
+}
+
+func (c *replicationCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- replicationCommonInfoDesc
+	ch <- replicationCommonTimestampDesc
+	ch <- replicationLagDesc
+	ch <- replicationBehindDesc
+	ch <- replicationResumeTokenDesc
+	if c.pending.enabled() {
+		ch <- replicationPendingDesc
+	}
+}
+
+func (c *replicationCollector) start(ctx context.Context) {
+	c.pending.start(ctx, c.log, c.client)
+}
+
+// update compares the configured datasets, which may be in pools that are not otherwise collected, so the pools
+// selected for collection are ignored.
+func (c *replicationCollector) update(ctx context.Context, ch chan<- metric, pools []string, excludes regexpCollection) error {
+	snapshots := make(map[string]map[string][]replicationSnapshot)
+	resumeTokens := make(map[string]map[string]bool)
+	for _, mapping := range c.mappings {
+		for _, pool := range []string{poolName(mapping.source), poolName(mapping.target)} {
+			if _, ok := snapshots[pool]; ok {
+				continue
+			}
+			result, err := c.snapshots(ctx, pool)
+			if err != nil {
+				return err
+			}
+			snapshots[pool] = result
+		}
+		pool := poolName(mapping.target)
+		if _, ok := resumeTokens[pool]; !ok {
+			result, err := c.resumeTokens(ctx, pool)
+			if err != nil {
+				return err
+			}
+			resumeTokens[pool] = result
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, mapping := range c.mappings {
+		for source, sourceSnapshots := range snapshots[poolName(mapping.source)] {
+			if source != mapping.source && !strings.HasPrefix(source, mapping.source+`/`) {
+				continue
+			}
+			// The target may be a descendent of the source, when replicating within a pool.
+			if source == mapping.target || strings.HasPrefix(source, mapping.target+`/`) || excludes.MatchString(source) {
+				continue
+			}
+			target := mapping.target + strings.TrimPrefix(source, mapping.source)
+			seen[replicationPendingKey(source, target)] = true
+			c.updateDatasetMetrics(ch, source, target, sourceSnapshots, snapshots[poolName(mapping.target)][target], resumeTokens[poolName(mapping.target)][target])
+		}
+	}
+	c.pending.prune(seen)
+
+	return nil
+}
+
+func (c *replicationCollector) updateDatasetMetrics(ch chan<- metric, source, target string, sourceSnapshots, targetSnapshots []replicationSnapshot, resumeToken bool) {
+	labelValues := []string{source, target}
+
+	var resumeTokenValue float64
+	if resumeToken {
+		resumeTokenValue = 1
+	}
+	c.push(ch, replicationResumeTokenDesc, replicationResumeTokenDescName, resumeTokenValue, labelValues...)
+
+	guids := make(map[string]bool, len(targetSnapshots))
+	for _, snapshot := range targetSnapshots {
+		guids[snapshot.guid] = true
+	}
+	// Snapshots are ordered newest first, by transaction group, as creation times may not be unique.
+	var behind float64
+	for _, snapshot := range sourceSnapshots {
+		if !guids[snapshot.guid] {
+			behind++
+			continue
+		}
+		c.push(ch, replicationBehindDesc, replicationBehindDescName, behind, labelValues...)
+		c.push(ch, replicationCommonTimestampDesc, replicationCommonTimestampDescName, snapshot.creation, labelValues...)
+		c.push(ch, replicationLagDesc, replicationLagDescName, sourceSnapshots[0].creation-snapshot.creation, labelValues...)
+		ch <- metric{
+			name:       expandMetricName(replicationCommonInfoDescName, labelValues...),
+			prometheus: prometheus.MustNewConstMetric(replicationCommonInfoDesc, prometheus.GaugeValue, 1, append(labelValues, snapshot.name)...),
+		}
+		c.updatePendingMetrics(ch, source, target, snapshot.name, sourceSnapshots[0].name)
+		return
+	}
+	c.push(ch, replicationBehindDesc, replicationBehindDescName, behind, labelValues...)
+}
+
+func (c *replicationCollector) updatePendingMetrics(ch chan<- metric, source, target, common, newest string) {
+	if !c.pending.enabled() {
+		return
+	}
+	key := replicationPendingKey(source, target)
+	var pending uint64
+	if common == newest {
+		// Nothing is pending, so any estimate for earlier snapshots is no longer refreshed.
+		c.pending.remove(key)
+	} else {
+		var ok bool
+		pending, ok = c.pending.get(key, source+`@`+common, source+`@`+newest)
+		if !ok {
+			return
+		}
+	}
+	c.push(ch, replicationPendingDesc, replicationPendingDescName, float64(pending), source, target)
+}
+
+func (c *replicationCollector) push(ch chan<- metric, desc *prometheus.Desc, name string, value float64, labelValues ...string) {
+	ch <- metric{
+		name:       expandMetricName(name, labelValues...),
+		prometheus: prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, labelValues...),
+	}
+}
+
+// snapshots returns the snapshots of each dataset in the pool, ordered newest first.
+func (c *replicationCollector) snapshots(ctx context.Context, pool string) (map[string][]replicationSnapshot, error) {
+	datasets, err := c.client.Datasets(pool, zfs.DatasetSnapshot).Properties(ctx, replicationSnapshotProps...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string][]replicationSnapshot)
+	for _, dataset := range datasets {
+		name := dataset.DatasetName()
+		props := dataset.Properties()
+		snapshot := replicationSnapshot{
+			name: name[strings.IndexByte(name, '@')+1:],
+			guid: props[`guid`],
+		}
+		if snapshot.createtxg, err = strconv.ParseUint(props[`createtxg`], 10, 64); err != nil {
+			return nil, err
+		}
+		if snapshot.creation, err = transformNumeric(props[`creation`]); err != nil {
+			return nil, err
+		}
+		parent := parentDataset(name)
+		result[parent] = append(result[parent], snapshot)
+	}
+	for _, snapshots := range result {
+		sort.Slice(snapshots, func(i, j int) bool {
+			return snapshots[i].createtxg > snapshots[j].createtxg
+		})
+	}
+
+	return result, nil
+}
+
+// resumeTokens returns the datasets in the pool that have a receive_resume_token set.
+func (c *replicationCollector) resumeTokens(ctx context.Context, pool string) (map[string]bool, error) {
+	result := make(map[string]bool)
+	for _, kind := range []zfs.DatasetKind{zfs.DatasetFilesystem, zfs.DatasetVolume} {
+		datasets, err := c.client.Datasets(pool, kind).Properties(ctx, `receive_resume_token`)
+		if err != nil {
+			return nil, err
+		}
+		for _, dataset := range datasets {
+			if token := dataset.Properties()[`receive_resume_token`]; token != `` && token != `-` {
+				result[dataset.DatasetName()] = true
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// poolName returns the name of the pool containing the dataset.
+func poolName(dataset string) string {
+	if i := strings.IndexByte(dataset, '/'); i >= 0 {
+		return dataset[:i]
+	}
+	return dataset
+}
+
+func parseReplicationMappings(mappings []string) ([]replicationMapping, error) {
+	result := make([]replicationMapping, len(mappings))
+	for i, mapping := range mappings {
+		parts := strings.SplitN(mapping, `=`, 2)
+		if len(parts) != 2 || parts[0] == `` || parts[1] == `` {
+			return nil, fmt.Errorf("invalid replication mapping, expected source=target: %s", mapping)
+		}
+		result[i] = replicationMapping{
+			source: strings.TrimSuffix(parts[0], `/`),
+			target: strings.TrimSuffix(parts[1], `/`),
+		}
+	}
+
+	return result, nil
+}
+
+func newReplicationCollectorFactory(mappings *[]string, pendingInterval *time.Duration) factoryFunc {
+	pending := newReplicationPendingCache(pendingInterval)
+	return func(l log.Logger, c zfs.Client, props []string) (Collector, error) {
+		parsed, err := parseReplicationMappings(*mappings)
+		if err != nil {
+			return nil, err
+		}
+		return &replicationCollector{log: l, client: c, mappings: parsed, pending: pending}, nil
+	}
+}
+
+// replicationPendingCache holds the estimated pending send size for each source and target. Estimates may be
+// expensive, so they are refreshed in the background at their own interval, independently of collections.
+type replicationPendingCache struct {
+	interval *time.Duration
+	// ctx holds the lifetime context of the collector, which cancels any refresh in progress on shutdown. Refreshes
+	// are not started until it is set.
+	ctx     context.Context
+	log     log.Logger
+	client  zfs.Client
+	entries map[string]*replicationPendingEntry
+	sync.Mutex
+}
+
+type replicationPendingEntry struct {
+	// from and to hold the snapshots of the current estimate, or the estimate in progress.
+	from    string
+	to      string
+	bytes   uint64
+	valid   bool
+	updated time.Time
+	running bool
+}
+
+func (c *replicationPendingCache) enabled() bool {
+	return *c.interval > 0
+}
+
+// start begins refreshing the estimates once per interval, for the lifetime of the context.
+func (c *replicationPendingCache) start(ctx context.Context, l log.Logger, client zfs.Client) {
+	c.Lock()
+	defer c.Unlock()
+	if c.ctx != nil {
+		return
+	}
+	c.ctx, c.log, c.client = ctx, l, client
+	if c.enabled() {
+		go c.run(ctx, *c.interval)
+	}
+}
+
+func (c *replicationPendingCache) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.refreshAll()
+		}
+	}
+}
+
+// refreshAll starts a refresh for each estimate that is not already being refreshed.
+func (c *replicationPendingCache) refreshAll() {
+	c.Lock()
+	defer c.Unlock()
+	if c.ctx.Err() != nil {
+		return
+	}
+	for _, entry := range c.entries {
+		if entry.running {
+			continue
+		}
+		entry.running = true
+		go c.refresh(c.ctx, entry, entry.from, entry.to)
+	}
+}
+
+// get returns the most recent estimate for the key, if any. The estimate for previous snapshots is discarded when the
+// snapshots change, and a refresh for the new snapshots is started immediately, rather than waiting for the next
+// interval, but no estimate is returned until it completes.
+func (c *replicationPendingCache) get(key, from, to string) (uint64, bool) {
+	c.Lock()
+	defer c.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &replicationPendingEntry{}
+		c.entries[key] = entry
+	}
+	if entry.from != from || entry.to != to {
+		*entry = replicationPendingEntry{from: from, to: to, running: entry.running}
+	}
+	if !entry.running && entry.updated.IsZero() && c.ctx != nil && c.ctx.Err() == nil {
+		entry.running = true
+		go c.refresh(c.ctx, entry, from, to)
+	}
+
+	return entry.bytes, entry.valid
+}
+
+func (c *replicationPendingCache) refresh(ctx context.Context, entry *replicationPendingEntry, from, to string) {
+	ctx, cancel := context.WithTimeout(ctx, *c.interval)
+	defer cancel()
+	size, err := c.client.SendSize(ctx, from, to)
+
+	c.Lock()
+	defer c.Unlock()
+	entry.running = false
+	// The snapshots changed whilst the estimate was running, so it is discarded, and the next collection refreshes
+	// the estimate for the new snapshots.
+	if entry.from != from || entry.to != to {
+		return
+	}
+	entry.updated = time.Now()
+	if err != nil {
+		// Estimates interrupted by shutdown are not reported.
+		if ctx.Err() == context.Canceled {
+			return
+		}
+		_ = level.Warn(c.log).Log(`msg`, `Error estimating pending send size`, `from`, from, `to`, to, `err`, err)
+		return
+	}
+	entry.bytes, entry.valid = size, true
+}
+
+// remove discards the estimate for the key, if any.
+func (c *replicationPendingCache) remove(key string) {
+	c.Lock()
+	defer c.Unlock()
+	delete(c.entries, key)
+}
+
+// prune removes estimates for any source and target that were not seen in the most recent collection.
+func (c *replicationPendingCache) prune(seen map[string]bool) {
+	c.Lock()
+	defer c.Unlock()
+	for key := range c.entries {
+		if !seen[key] {
+			delete(c.entries, key)
+		}
+	}
+}
+
+func replicationPendingKey(source, target string) string {
+	return source + `=` + target
+}
+
+func newReplicationPendingCache(interval *time.Duration) *replicationPendingCache {
+	return &replicationPendingCache{
+		interval: interval,
+		entries:  make(map[string]*replicationPendingEntry),
+	}
+}
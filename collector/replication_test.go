@@ -0,0 +1,246 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/pdf/zfs_exporter/v2/zfs/mock_zfs"
+)
+
+func TestReplicationMetrics(t *testing.T) {
+	t.Parallel()
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	config := defaultConfig(zfsClient)
+	config.Excludes = []string{`^tank/excluded$`}
+
+	zfsClient.EXPECT().PoolNames(gomock.Any()).Return([]string{`tank`, `backup`}, nil).Times(1)
+	collector, err := NewZFS(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mappings := []replicationMapping{{source: `tank/data`, target: `backup/tank/data`}}
+	interval := time.Hour
+	pending := newReplicationPendingCache(&interval)
+	pending.entries[replicationPendingKey(`tank/data`, `backup/tank/data`)] = &replicationPendingEntry{
+		from:    `tank/data@daily-2`,
+		to:      `tank/data@daily-3`,
+		bytes:   4096,
+		valid:   true,
+		updated: time.Now(),
+	}
+	pending.entries[replicationPendingKey(`tank/removed`, `backup/tank/removed`)] = &replicationPendingEntry{}
+	collector.Collectors = map[string]State{
+		`replication`: {
+			Name:       "replication",
+			Enabled:    boolPointer(true),
+			Properties: stringPointer(``),
+			factory: func(l log.Logger, c zfs.Client, props []string) (Collector, error) {
+				return &replicationCollector{log: l, client: c, mappings: mappings, pending: pending}, nil
+			},
+		},
+	}
+
+	newDatasets := func(results []datasetResults) []zfs.DatasetProperties {
+		datasets := make([]zfs.DatasetProperties, len(results))
+		for i, result := range results {
+			dataset := mock_zfs.NewMockDatasetProperties(ctrl)
+			dataset.EXPECT().DatasetName().Return(result.name).AnyTimes()
+			dataset.EXPECT().Properties().Return(result.results).Times(1)
+			datasets[i] = dataset
+		}
+		return datasets
+	}
+	snapshot := func(name, guid, createtxg, creation string) datasetResults {
+		return datasetResults{name: name, results: map[string]string{`guid`: guid, `createtxg`: createtxg, `creation`: creation}}
+	}
+
+	sourceSnapshots := mock_zfs.NewMockDatasets(ctrl)
+	sourceSnapshots.EXPECT().Properties(gomock.Any(), replicationSnapshotProps).Return(newDatasets([]datasetResults{
+		snapshot(`tank/data@daily-1`, `1001`, `100`, `1660000000`),
+		snapshot(`tank/data@daily-3`, `1003`, `300`, `1660172800`),
+		snapshot(`tank/data@daily-2`, `1002`, `200`, `1660086400`),
+		snapshot(`tank/data/child@daily-1`, `2001`, `100`, `1660000000`),
+		snapshot(`tank/other@daily-1`, `3001`, `100`, `1660000000`),
+	}), nil).Times(1)
+	targetSnapshots := mock_zfs.NewMockDatasets(ctrl)
+	targetSnapshots.EXPECT().Properties(gomock.Any(), replicationSnapshotProps).Return(newDatasets([]datasetResults{
+		snapshot(`backup/tank/data@daily-1`, `1001`, `50`, `1660000000`),
+		snapshot(`backup/tank/data@daily-2`, `1002`, `60`, `1660086400`),
+	}), nil).Times(1)
+	targetFilesystems := mock_zfs.NewMockDatasets(ctrl)
+	targetFilesystems.EXPECT().Properties(gomock.Any(), `receive_resume_token`).Return(newDatasets([]datasetResults{
+		{name: `backup/tank/data`, results: map[string]string{`receive_resume_token`: `-`}},
+		{name: `backup/tank/data/child`, results: map[string]string{`receive_resume_token`: `1-e604ea4bf-e0-789c63a2`}},
+	}), nil).Times(1)
+	targetVolumes := mock_zfs.NewMockDatasets(ctrl)
+	targetVolumes.EXPECT().Properties(gomock.Any(), `receive_resume_token`).Return(nil, nil).Times(1)
+	zfsClient.EXPECT().Datasets(`tank`, zfs.DatasetSnapshot).Return(sourceSnapshots).Times(1)
+	zfsClient.EXPECT().Datasets(`backup`, zfs.DatasetSnapshot).Return(targetSnapshots).Times(1)
+	zfsClient.EXPECT().Datasets(`backup`, zfs.DatasetFilesystem).Return(targetFilesystems).Times(1)
+	zfsClient.EXPECT().Datasets(`backup`, zfs.DatasetVolume).Return(targetVolumes).Times(1)
+
+	metricResults := `# HELP zfs_replication_common_snapshot_info The newest snapshot common to the source and target datasets, as the snapshot label. The value is always 1.
+# TYPE zfs_replication_common_snapshot_info gauge
+zfs_replication_common_snapshot_info{snapshot="daily-2",source="tank/data",target="backup/tank/data"} 1
+# HELP zfs_replication_common_snapshot_timestamp_seconds Unix timestamp of the creation of the newest snapshot common to the source and target datasets.
+# TYPE zfs_replication_common_snapshot_timestamp_seconds gauge
+zfs_replication_common_snapshot_timestamp_seconds{source="tank/data",target="backup/tank/data"} 1.6600864e+09
+# HELP zfs_replication_lag_seconds Age in seconds of the newest snapshot common to the source and target datasets, relative to the newest snapshot of the source dataset.
+# TYPE zfs_replication_lag_seconds gauge
+zfs_replication_lag_seconds{source="tank/data",target="backup/tank/data"} 86400
+# HELP zfs_replication_pending_bytes Estimated size in bytes of the incremental stream from the newest snapshot common to the source and target datasets, to the newest snapshot of the source dataset. Refreshed at the configured pending interval.
+# TYPE zfs_replication_pending_bytes gauge
+zfs_replication_pending_bytes{source="tank/data",target="backup/tank/data"} 4096
+# HELP zfs_replication_resume_token_pending Whether the target dataset has a receive_resume_token from an interrupted receive [0: false, 1: true].
+# TYPE zfs_replication_resume_token_pending gauge
+zfs_replication_resume_token_pending{source="tank/data",target="backup/tank/data"} 0
+zfs_replication_resume_token_pending{source="tank/data/child",target="backup/tank/data/child"} 1
+# HELP zfs_replication_snapshots_behind Number of snapshots of the source dataset newer than the newest snapshot common to the target dataset, or all snapshots of the source dataset if there is no common snapshot.
+# TYPE zfs_replication_snapshots_behind gauge
+zfs_replication_snapshots_behind{source="tank/data",target="backup/tank/data"} 1
+zfs_replication_snapshots_behind{source="tank/data/child",target="backup/tank/data/child"} 1
+`
+	metricNames := []string{
+		`zfs_replication_common_snapshot_info`,
+		`zfs_replication_common_snapshot_timestamp_seconds`,
+		`zfs_replication_lag_seconds`,
+		`zfs_replication_pending_bytes`,
+		`zfs_replication_resume_token_pending`,
+		`zfs_replication_snapshots_behind`,
+	}
+	if err = callCollector(ctx, collector, []byte(metricResults), metricNames); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := pending.entries[replicationPendingKey(`tank/removed`, `backup/tank/removed`)]; ok {
+		t.Fatal(`Expected pending estimate for removed dataset to be pruned`)
+	}
+}
+
+func TestReplicationPendingCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	interval := time.Hour
+	pending := newReplicationPendingCache(&interval)
+	key := replicationPendingKey(`tank/data`, `backup/tank/data`)
+	wait := func() {
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+			pending.Lock()
+			running := pending.entries[key].running
+			pending.Unlock()
+			if !running {
+				return
+			}
+		}
+		t.Fatal(`Timed out waiting for refresh`)
+	}
+
+	if _, ok := pending.get(key, `tank/data@daily-1`, `tank/data@daily-2`); ok {
+		t.Fatal(`Expected no estimate before the cache is started`)
+	}
+	if pending.entries[key].running {
+		t.Fatal(`Expected no refresh before the cache is started`)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pending.start(ctx, logger, zfsClient)
+	zfsClient.EXPECT().SendSize(gomock.Any(), `tank/data@daily-1`, `tank/data@daily-2`).Return(uint64(1024), nil).Times(1)
+	if _, ok := pending.get(key, `tank/data@daily-1`, `tank/data@daily-2`); ok {
+		t.Fatal(`Expected no estimate before the first refresh completes`)
+	}
+	wait()
+	if size, ok := pending.get(key, `tank/data@daily-1`, `tank/data@daily-2`); !ok || size != 1024 {
+		t.Fatalf("Expected cached estimate of 1024, got %d (%t)", size, ok)
+	}
+
+	// A new snapshot discards the previous estimate, and a failed refresh reports no estimate.
+	zfsClient.EXPECT().SendSize(gomock.Any(), `tank/data@daily-1`, `tank/data@daily-3`).Return(uint64(0), errors.New(`send failed`)).Times(1)
+	if size, ok := pending.get(key, `tank/data@daily-1`, `tank/data@daily-3`); ok {
+		t.Fatalf("Expected no estimate for new snapshot, got %d", size)
+	}
+	wait()
+	if size, ok := pending.get(key, `tank/data@daily-1`, `tank/data@daily-3`); ok {
+		t.Fatalf("Expected no estimate after failed refresh, got %d", size)
+	}
+
+	// Refreshes are cancelled with the lifetime context.
+	blocked := make(chan struct{})
+	zfsClient.EXPECT().SendSize(gomock.Any(), `tank/data@daily-1`, `tank/data@daily-4`).DoAndReturn(func(ctx context.Context, from, to string) (uint64, error) {
+		close(blocked)
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}).Times(1)
+	pending.get(key, `tank/data@daily-1`, `tank/data@daily-4`)
+	<-blocked
+	cancel()
+	wait()
+	if size, ok := pending.get(key, `tank/data@daily-1`, `tank/data@daily-4`); ok {
+		t.Fatalf("Expected no estimate after cancelled refresh, got %d", size)
+	}
+}
+
+func TestReplicationPendingCacheInterval(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	interval := 10 * time.Millisecond
+	pending := newReplicationPendingCache(&interval)
+	key := replicationPendingKey(`tank/data`, `backup/tank/data`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pending.start(ctx, logger, zfsClient)
+	zfsClient.EXPECT().SendSize(gomock.Any(), `tank/data@daily-1`, `tank/data@daily-2`).Return(uint64(1024), nil).Times(1)
+	zfsClient.EXPECT().SendSize(gomock.Any(), `tank/data@daily-1`, `tank/data@daily-2`).Return(uint64(2048), nil).MinTimes(1)
+	pending.get(key, `tank/data@daily-1`, `tank/data@daily-2`)
+
+	// Estimates are refreshed on the interval without further collections.
+	refreshed := false
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		pending.Lock()
+		refreshed = pending.entries[key].bytes == 2048
+		pending.Unlock()
+		if refreshed {
+			break
+		}
+	}
+	if !refreshed {
+		t.Fatal(`Timed out waiting for the estimate to be refreshed on the interval`)
+	}
+
+	cancel()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		pending.Lock()
+		running := pending.entries[key].running
+		pending.Unlock()
+		if !running {
+			return
+		}
+	}
+	t.Fatal(`Timed out waiting for refresh`)
+}
+
+func TestParseReplicationMappings(t *testing.T) {
+	mappings, err := parseReplicationMappings([]string{`tank/data=backup/tank/data/`, `tank:vm=backup`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []replicationMapping{
+		{source: `tank/data`, target: `backup/tank/data`},
+		{source: `tank:vm`, target: `backup`},
+	}
+	if diff := cmp.Diff(mappings, expected, cmp.AllowUnexported(replicationMapping{})); diff != `` {
+		t.Fatalf("Parsed mappings are not equal to expected mappings: %s", diff)
+	}
+
+	for _, invalid := range []string{`tank`, `=backup`, `tank=`} {
+		if _, err = parseReplicationMappings([]string{invalid}); err == nil {
+			t.Fatalf("Expected error for mapping %q", invalid)
+		}
+	}
+}
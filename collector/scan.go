@@ -0,0 +1,244 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type scanStateCode int
+
+const (
+	scanNone scanStateCode = iota
+	scanFinished
+	scanInProgress
+	scanPaused
+	scanCanceled
+)
+
+var (
+	scanLabels = []string{`pool`, `function`}
+
+	scanStateDescName = prometheus.BuildFQName(namespace, subsystemPool, `scan_state`)
+	scanStateDesc     = prometheus.NewDesc(
+		scanStateDescName,
+		fmt.Sprintf("State of the most recent scrub or resilver of the pool [%d: %s, %d: %s, %d: %s, %d: %s, %d: %s].",
+			scanNone, zfs.ScanStateNone,
+			scanFinished, zfs.ScanStateFinished,
+			scanInProgress, zfs.ScanStateInProgress,
+			scanPaused, zfs.ScanStatePaused,
+			scanCanceled, zfs.ScanStateCanceled,
+		),
+		scanLabels,
+		nil,
+	)
+	scanStartDescName = prometheus.BuildFQName(namespace, subsystemPool, `scan_start_timestamp_seconds`)
+	scanStartDesc     = prometheus.NewDesc(
+		scanStartDescName,
+		`Unix timestamp at which the most recent scrub or resilver of the pool started.`,
+		scanLabels,
+		nil,
+	)
+	scanEndDescName = prometheus.BuildFQName(namespace, subsystemPool, `scan_end_timestamp_seconds`)
+	scanEndDesc     = prometheus.NewDesc(
+		scanEndDescName,
+		`Unix timestamp at which the most recent scrub or resilver of the pool finished or was canceled.`,
+		scanLabels,
+		nil,
+	)
+	scanLastCompletedDescName = prometheus.BuildFQName(namespace, subsystemPool, `scan_last_completed_timestamp_seconds`)
+	scanLastCompletedDesc     = prometheus.NewDesc(
+		scanLastCompletedDescName,
+		`Unix timestamp at which the most recent scrub or resilver of the pool completed successfully. Completions observed since the exporter started are remembered for each function, so they continue to be reported whilst a later scan is running, or after a scan of the other function.`,
+		scanLabels,
+		nil,
+	)
+	scanProgressDescName = prometheus.BuildFQName(namespace, subsystemPool, `scan_progress_ratio`)
+	scanProgressDesc     = prometheus.NewDesc(
+		scanProgressDescName,
+		`Completion ratio of the most recent scrub or resilver of the pool.`,
+		scanLabels,
+		nil,
+	)
+	scanScannedDescName = prometheus.BuildFQName(namespace, subsystemPool, `scan_scanned_bytes`)
+	scanScannedDesc     = prometheus.NewDesc(
+		scanScannedDescName,
+		`Amount of data in bytes scanned by the running scrub or resilver of the pool.`,
+		scanLabels,
+		nil,
+	)
+	scanIssuedDescName = prometheus.BuildFQName(namespace, subsystemPool, `scan_issued_bytes`)
+	scanIssuedDesc     = prometheus.NewDesc(
+		scanIssuedDescName,
+		`Amount of data in bytes issued for verification by the running scrub or resilver of the pool.`,
+		scanLabels,
+		nil,
+	)
+	scanTotalDescName = prometheus.BuildFQName(namespace, subsystemPool, `scan_total_bytes`)
+	scanTotalDesc     = prometheus.NewDesc(
+		scanTotalDescName,
+		`Amount of data in bytes to be scanned by the running scrub or resilver of the pool.`,
+		scanLabels,
+		nil,
+	)
+	scanRepairedDescName = prometheus.BuildFQName(namespace, subsystemPool, `scan_repaired_bytes`)
+	scanRepairedDesc     = prometheus.NewDesc(
+		scanRepairedDescName,
+		`Amount of data in bytes repaired by the most recent scrub, or resilvered by the most recent resilver of the pool.`,
+		scanLabels,
+		nil,
+	)
+	scanErrorsDescName = prometheus.BuildFQName(namespace, subsystemPool, `scan_errors`)
+	scanErrorsDesc     = prometheus.NewDesc(
+		scanErrorsDescName,
+		`Number of unrecoverable errors encountered by the most recent completed scrub or resilver of the pool.`,
+		scanLabels,
+		nil,
+	)
+
+	scanStateCodes = map[zfs.ScanState]scanStateCode{
+		zfs.ScanStateNone:       scanNone,
+		zfs.ScanStateFinished:   scanFinished,
+		zfs.ScanStateInProgress: scanInProgress,
+		zfs.ScanStatePaused:     scanPaused,
+		zfs.ScanStateCanceled:   scanCanceled,
+	}
+)
+
+func init() {
+	registerCollectorWithoutProperties(`pool-scan`, defaultDisabled, newPoolScanCollectorFactory())
+}
+
+type scanKey struct {
+	pool     string
+	function zfs.ScanFunction
+}
+
+// scanCompletions holds the time of the most recent successful completion of each scan function for each pool, as
+// `zpool status` only reports the most recent scan.
+type scanCompletions struct {
+	completed map[scanKey]time.Time
+	sync.Mutex
+}
+
+// observe records the completion of a finished scan, and returns the completions known for the pool.
+func (s *scanCompletions) observe(pool string, scan zfs.PoolScan) map[zfs.ScanFunction]time.Time {
+	s.Lock()
+	defer s.Unlock()
+	if scan.State == zfs.ScanStateFinished && !scan.End.IsZero() {
+		key := scanKey{pool: pool, function: scan.Function}
+		if scan.End.After(s.completed[key]) {
+			s.completed[key] = scan.End
+		}
+	}
+	result := make(map[zfs.ScanFunction]time.Time)
+	for key, end := range s.completed {
+		if key.pool == pool {
+			result[key.function] = end
+		}
+	}
+	return result
+}
+
+type poolScanCollector struct {
+	log         log.Logger
+	client      zfs.Client
+	completions *scanCompletions
+}
+
+func (c *poolScanCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- scanStateDesc
+	ch <- scanStartDesc
+	ch <- scanEndDesc
+	ch <- scanLastCompletedDesc
+	ch <- scanProgressDesc
+	ch <- scanScannedDesc
+	ch <- scanIssuedDesc
+	ch <- scanTotalDesc
+	ch <- scanRepairedDesc
+	ch <- scanErrorsDesc
+}
+
+func (c *poolScanCollector) update(ctx context.Context, ch chan<- metric, pools []string, excludes regexpCollection) error {
+	if len(pools) == 0 {
+		return nil
+	}
+	// The scan status is parsed from the same `zpool status` output as the vdev trees, which is shared with the
+	// pool-disks collector within a collection.
+	trees, err := c.client.VdevTrees(ctx, pools...)
+	if err != nil {
+		return err
+	}
+
+	var result error
+	for _, tree := range trees {
+		if tree.ScanErr != nil {
+			_ = level.Warn(c.log).Log(`msg`, `Unable to parse pool scan status`, `pool`, tree.Pool, `err`, tree.ScanErr)
+			result = tree.ScanErr
+			continue
+		}
+		if err := c.updatePoolMetrics(ch, tree.Pool, tree.Scan); err != nil {
+			result = err
+		}
+	}
+
+	return result
+}
+
+func (c *poolScanCollector) updatePoolMetrics(ch chan<- metric, pool string, scan zfs.PoolScan) error {
+	state, ok := scanStateCodes[scan.State]
+	if !ok {
+		return fmt.Errorf(`unknown scan state: %s`, scan.State)
+	}
+	for function, end := range c.completions.observe(pool, scan) {
+		pushScanGauge(ch, scanLastCompletedDesc, scanLastCompletedDescName, float64(end.Unix()), pool, string(function))
+	}
+
+	labelValues := []string{pool, string(scan.Function)}
+	pushScanGauge(ch, scanStateDesc, scanStateDescName, float64(state), labelValues...)
+	if scan.State == zfs.ScanStateNone {
+		return nil
+	}
+
+	if !scan.Start.IsZero() {
+		pushScanGauge(ch, scanStartDesc, scanStartDescName, float64(scan.Start.Unix()), labelValues...)
+	}
+	if !scan.End.IsZero() {
+		pushScanGauge(ch, scanEndDesc, scanEndDescName, float64(scan.End.Unix()), labelValues...)
+	}
+
+	switch scan.State {
+	case zfs.ScanStateFinished:
+		pushScanGauge(ch, scanProgressDesc, scanProgressDescName, scan.Progress, labelValues...)
+		pushScanGauge(ch, scanRepairedDesc, scanRepairedDescName, float64(scan.Repaired), labelValues...)
+		pushScanGauge(ch, scanErrorsDesc, scanErrorsDescName, float64(scan.Errors), labelValues...)
+	case zfs.ScanStateInProgress, zfs.ScanStatePaused:
+		pushScanGauge(ch, scanProgressDesc, scanProgressDescName, scan.Progress, labelValues...)
+		pushScanGauge(ch, scanScannedDesc, scanScannedDescName, float64(scan.Scanned), labelValues...)
+		pushScanGauge(ch, scanIssuedDesc, scanIssuedDescName, float64(scan.Issued), labelValues...)
+		pushScanGauge(ch, scanTotalDesc, scanTotalDescName, float64(scan.Total), labelValues...)
+		pushScanGauge(ch, scanRepairedDesc, scanRepairedDescName, float64(scan.Repaired), labelValues...)
+	}
+
+	return nil
+}
+
+func pushScanGauge(ch chan<- metric, desc *prometheus.Desc, name string, value float64, labelValues ...string) {
+	ch <- metric{
+		name:       expandMetricName(name, labelValues...),
+		prometheus: prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, labelValues...),
+	}
+}
+
+func newPoolScanCollectorFactory() factoryFunc {
+	completions := &scanCompletions{completed: make(map[scanKey]time.Time)}
+	return func(l log.Logger, c zfs.Client, _props []string) (Collector, error) {
+		return &poolScanCollector{log: l, client: c, completions: completions}, nil
+	}
+}
@@ -0,0 +1,251 @@
+package collector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/pdf/zfs_exporter/v2/zfs/mock_zfs"
+)
+
+func TestPoolScanMetrics(t *testing.T) {
+	testCases := []struct {
+		name          string
+		metricNames   []string
+		scanResult    zfs.PoolScan
+		metricResults string
+	}{
+		{
+			name:        `none requested`,
+			metricNames: []string{`zfs_pool_scan_state`, `zfs_pool_scan_progress_ratio`},
+			scanResult:  zfs.PoolScan{Function: zfs.ScanNone, State: zfs.ScanStateNone},
+			metricResults: `# HELP zfs_pool_scan_state State of the most recent scrub or resilver of the pool [0: none, 1: finished, 2: in_progress, 3: paused, 4: canceled].
+# TYPE zfs_pool_scan_state gauge
+zfs_pool_scan_state{function="none",pool="testpool"} 0
+`,
+		},
+		{
+			name: `scrub finished`,
+			metricNames: []string{
+				`zfs_pool_scan_state`,
+				`zfs_pool_scan_last_completed_timestamp_seconds`,
+				`zfs_pool_scan_progress_ratio`,
+				`zfs_pool_scan_errors`,
+				`zfs_pool_scan_scanned_bytes`,
+			},
+			scanResult: zfs.PoolScan{
+				Function: zfs.ScanScrub,
+				State:    zfs.ScanStateFinished,
+				Start:    time.Unix(1660400000, 0),
+				End:      time.Unix(1660410000, 0),
+				Errors:   2,
+				Progress: 1,
+			},
+			metricResults: `# HELP zfs_pool_scan_errors Number of unrecoverable errors encountered by the most recent completed scrub or resilver of the pool.
+# TYPE zfs_pool_scan_errors gauge
+zfs_pool_scan_errors{function="scrub",pool="testpool"} 2
+# HELP zfs_pool_scan_last_completed_timestamp_seconds Unix timestamp at which the most recent scrub or resilver of the pool completed successfully. Completions observed since the exporter started are remembered for each function, so they continue to be reported whilst a later scan is running, or after a scan of the other function.
+# TYPE zfs_pool_scan_last_completed_timestamp_seconds gauge
+zfs_pool_scan_last_completed_timestamp_seconds{function="scrub",pool="testpool"} 1.66041e+09
+# HELP zfs_pool_scan_progress_ratio Completion ratio of the most recent scrub or resilver of the pool.
+# TYPE zfs_pool_scan_progress_ratio gauge
+zfs_pool_scan_progress_ratio{function="scrub",pool="testpool"} 1
+# HELP zfs_pool_scan_state State of the most recent scrub or resilver of the pool [0: none, 1: finished, 2: in_progress, 3: paused, 4: canceled].
+# TYPE zfs_pool_scan_state gauge
+zfs_pool_scan_state{function="scrub",pool="testpool"} 1
+`,
+		},
+		{
+			name: `resilver in progress`,
+			metricNames: []string{
+				`zfs_pool_scan_state`,
+				`zfs_pool_scan_start_timestamp_seconds`,
+				`zfs_pool_scan_end_timestamp_seconds`,
+				`zfs_pool_scan_last_completed_timestamp_seconds`,
+				`zfs_pool_scan_progress_ratio`,
+				`zfs_pool_scan_issued_bytes`,
+				`zfs_pool_scan_total_bytes`,
+			},
+			scanResult: zfs.PoolScan{
+				Function: zfs.ScanResilver,
+				State:    zfs.ScanStateInProgress,
+				Start:    time.Unix(1660400000, 0),
+				Issued:   1024,
+				Total:    4096,
+				Progress: 0.25,
+			},
+			metricResults: `# HELP zfs_pool_scan_issued_bytes Amount of data in bytes issued for verification by the running scrub or resilver of the pool.
+# TYPE zfs_pool_scan_issued_bytes gauge
+zfs_pool_scan_issued_bytes{function="resilver",pool="testpool"} 1024
+# HELP zfs_pool_scan_progress_ratio Completion ratio of the most recent scrub or resilver of the pool.
+# TYPE zfs_pool_scan_progress_ratio gauge
+zfs_pool_scan_progress_ratio{function="resilver",pool="testpool"} 0.25
+# HELP zfs_pool_scan_start_timestamp_seconds Unix timestamp at which the most recent scrub or resilver of the pool started.
+# TYPE zfs_pool_scan_start_timestamp_seconds gauge
+zfs_pool_scan_start_timestamp_seconds{function="resilver",pool="testpool"} 1.6604e+09
+# HELP zfs_pool_scan_state State of the most recent scrub or resilver of the pool [0: none, 1: finished, 2: in_progress, 3: paused, 4: canceled].
+# TYPE zfs_pool_scan_state gauge
+zfs_pool_scan_state{function="resilver",pool="testpool"} 2
+# HELP zfs_pool_scan_total_bytes Amount of data in bytes to be scanned by the running scrub or resilver of the pool.
+# TYPE zfs_pool_scan_total_bytes gauge
+zfs_pool_scan_total_bytes{function="resilver",pool="testpool"} 4096
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			zfsClient := mock_zfs.NewMockClient(ctrl)
+			config := defaultConfig(zfsClient)
+
+			zfsClient.EXPECT().PoolNames(gomock.Any()).Return([]string{`testpool`}, nil).Times(1)
+			zfsClient.EXPECT().VdevTrees(gomock.Any(), `testpool`).Return([]zfs.VdevTree{{Pool: `testpool`, Scan: tc.scanResult}}, nil).Times(1)
+
+			collector, err := NewZFS(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			collector.Collectors = map[string]State{
+				`pool-scan`: {
+					Name:       "pool-scan",
+					Enabled:    boolPointer(true),
+					Properties: stringPointer(``),
+					factory:    newPoolScanCollectorFactory(),
+				},
+			}
+
+			if err = callCollector(ctx, collector, []byte(tc.metricResults), tc.metricNames); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestPoolScanLastCompleted(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	config := defaultConfig(zfsClient)
+
+	zfsClient.EXPECT().PoolNames(gomock.Any()).Return([]string{`testpool`}, nil).AnyTimes()
+	collector, err := NewZFS(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collector.Collectors = map[string]State{
+		`pool-scan`: {
+			Name:       "pool-scan",
+			Enabled:    boolPointer(true),
+			Properties: stringPointer(``),
+			factory:    newPoolScanCollectorFactory(),
+		},
+	}
+
+	header := `# HELP zfs_pool_scan_last_completed_timestamp_seconds Unix timestamp at which the most recent scrub or resilver of the pool completed successfully. Completions observed since the exporter started are remembered for each function, so they continue to be reported whilst a later scan is running, or after a scan of the other function.
+# TYPE zfs_pool_scan_last_completed_timestamp_seconds gauge
+`
+	for _, step := range []struct {
+		name          string
+		scanResult    zfs.PoolScan
+		metricResults string
+	}{
+		{
+			name: `scrub finished`,
+			scanResult: zfs.PoolScan{
+				Function: zfs.ScanScrub,
+				State:    zfs.ScanStateFinished,
+				Start:    time.Unix(1660400000, 0),
+				End:      time.Unix(1660410000, 0),
+				Progress: 1,
+			},
+			metricResults: header + `zfs_pool_scan_last_completed_timestamp_seconds{function="scrub",pool="testpool"} 1.66041e+09
+`,
+		},
+		{
+			name: `scrub in progress`,
+			scanResult: zfs.PoolScan{
+				Function: zfs.ScanScrub,
+				State:    zfs.ScanStateInProgress,
+				Start:    time.Unix(1661000000, 0),
+				Progress: 0.5,
+			},
+			metricResults: header + `zfs_pool_scan_last_completed_timestamp_seconds{function="scrub",pool="testpool"} 1.66041e+09
+`,
+		},
+		{
+			name: `resilver finished`,
+			scanResult: zfs.PoolScan{
+				Function: zfs.ScanResilver,
+				State:    zfs.ScanStateFinished,
+				Start:    time.Unix(1661100000, 0),
+				End:      time.Unix(1661110000, 0),
+				Progress: 1,
+			},
+			metricResults: header + `zfs_pool_scan_last_completed_timestamp_seconds{function="resilver",pool="testpool"} 1.66111e+09
+zfs_pool_scan_last_completed_timestamp_seconds{function="scrub",pool="testpool"} 1.66041e+09
+`,
+		},
+	} {
+		zfsClient.EXPECT().VdevTrees(gomock.Any(), `testpool`).Return([]zfs.VdevTree{{Pool: `testpool`, Scan: step.scanResult}}, nil).Times(1)
+
+		if err = callCollector(ctx, collector, []byte(step.metricResults), []string{`zfs_pool_scan_last_completed_timestamp_seconds`}); err != nil {
+			t.Fatalf("%s: %s", step.name, err)
+		}
+	}
+}
+
+func TestPoolScanSharesStatus(t *testing.T) {
+	const result = `# HELP zfs_disk_status zfs_exporter: Disk status
+# TYPE zfs_disk_status gauge
+zfs_disk_status{class="normal",disk="sda",kind="vdev",state="ONLINE",vdev="sda",zpool="testpool"} 1
+# HELP zfs_pool_scan_state State of the most recent scrub or resilver of the pool [0: none, 1: finished, 2: in_progress, 3: paused, 4: canceled].
+# TYPE zfs_pool_scan_state gauge
+zfs_pool_scan_state{function="none",pool="testpool"} 0
+`
+
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	config := defaultConfig(zfsClient)
+
+	zfsClient.EXPECT().PoolNames(gomock.Any()).Return([]string{`testpool`}, nil).Times(1)
+	// The pool-disks and pool-scan collectors share a single `zpool status` within a collection.
+	zfsClient.EXPECT().VdevTrees(gomock.Any(), `testpool`).Return([]zfs.VdevTree{
+		{
+			Pool: `testpool`,
+			Root: zfs.Vdev{
+				Name:     `testpool`,
+				State:    `ONLINE`,
+				Class:    zfs.VdevClassNormal,
+				Children: []*zfs.Vdev{{Name: `sda`, State: `ONLINE`, Class: zfs.VdevClassNormal}},
+			},
+			Scan: zfs.PoolScan{Function: zfs.ScanNone, State: zfs.ScanStateNone},
+		},
+	}, nil).Times(1)
+
+	collector, err := NewZFS(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collector.Collectors = map[string]State{
+		`pool-disks`: {
+			Name:       "pool-disks",
+			Enabled:    boolPointer(true),
+			Properties: stringPointer(``),
+			factory:    newPoolDiskCollector,
+		},
+		`pool-scan`: {
+			Name:       "pool-scan",
+			Enabled:    boolPointer(true),
+			Properties: stringPointer(``),
+			factory:    newPoolScanCollectorFactory(),
+		},
+	}
+
+	if err = callCollector(ctx, collector, []byte(result), []string{`zfs_disk_status`, `zfs_pool_scan_state`}); err != nil {
+		t.Fatal(err)
+	}
+}
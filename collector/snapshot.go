@@ -0,0 +1,184 @@
+package collector
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	snapshotLabels = []string{`name`, `pool`}
+
+	snapshotNewestDescName = prometheus.BuildFQName(namespace, subsystemDataset, `snapshot_newest_timestamp_seconds`)
+	snapshotNewestDesc     = prometheus.NewDesc(
+		snapshotNewestDescName,
+		`Unix timestamp of the creation of the newest snapshot of this dataset.`,
+		snapshotLabels,
+		nil,
+	)
+	snapshotOldestDescName = prometheus.BuildFQName(namespace, subsystemDataset, `snapshot_oldest_timestamp_seconds`)
+	snapshotOldestDesc     = prometheus.NewDesc(
+		snapshotOldestDescName,
+		`Unix timestamp of the creation of the oldest snapshot of this dataset.`,
+		snapshotLabels,
+		nil,
+	)
+	snapshotCountDescName = prometheus.BuildFQName(namespace, subsystemDataset, `snapshots`)
+	snapshotCountDesc     = prometheus.NewDesc(
+		snapshotCountDescName,
+		`Number of snapshots of this dataset.`,
+		snapshotLabels,
+		nil,
+	)
+	snapshotUsedDescName = prometheus.BuildFQName(namespace, subsystemDataset, `snapshot_used_bytes`)
+	snapshotUsedDesc     = prometheus.NewDesc(
+		snapshotUsedDescName,
+		`Sum of the space in bytes consumed by each snapshot of this dataset. Space shared between snapshots is not included, see the "used_by_snapshot_bytes" property.`,
+		snapshotLabels,
+		nil,
+	)
+	snapshotWrittenDescName = prometheus.BuildFQName(namespace, subsystemDataset, `snapshot_written_bytes`)
+	snapshotWrittenDesc     = prometheus.NewDesc(
+		snapshotWrittenDescName,
+		`Sum of the referenced space in bytes written between each snapshot of this dataset and the previous snapshot.`,
+		snapshotLabels,
+		nil,
+	)
+	snapshotReferencedDescName = prometheus.BuildFQName(namespace, subsystemDataset, `snapshot_max_referenced_bytes`)
+	snapshotReferencedDesc     = prometheus.NewDesc(
+		snapshotReferencedDescName,
+		`Maximum amount of data in bytes accessible by any snapshot of this dataset.`,
+		snapshotLabels,
+		nil,
+	)
+
+	// snapshotAggregateProps are the snapshot properties that may be rolled up to the dataset
+	snapshotAggregateProps = map[string]bool{
+		`creation`:   true,
+		`referenced`: true,
+		`used`:       true,
+		`written`:    true,
+	}
+)
+
+// snapshotAggregate holds the values derived from all snapshots of a single dataset
+type snapshotAggregate struct {
+	count      uint64
+	oldest     float64
+	newest     float64
+	used       float64
+	written    float64
+	referenced float64
+}
+
+// snapshotAggregator rolls snapshot properties up to the dataset they were taken from, so that they may be reported
+// without a series per snapshot.
+type snapshotAggregator struct {
+	props    map[string]bool
+	datasets map[string]*snapshotAggregate
+}
+
+func (a *snapshotAggregator) describe(ch chan<- *prometheus.Desc) {
+	ch <- snapshotCountDesc
+	if a.props[`creation`] {
+		ch <- snapshotNewestDesc
+		ch <- snapshotOldestDesc
+	}
+	if a.props[`used`] {
+		ch <- snapshotUsedDesc
+	}
+	if a.props[`written`] {
+		ch <- snapshotWrittenDesc
+	}
+	if a.props[`referenced`] {
+		ch <- snapshotReferencedDesc
+	}
+}
+
+func (a *snapshotAggregator) observe(snapshot string, props map[string]string) error {
+	name := parentDataset(snapshot)
+	aggregate, ok := a.datasets[name]
+	if !ok {
+		aggregate = &snapshotAggregate{}
+		a.datasets[name] = aggregate
+	}
+	aggregate.count++
+
+	for k, v := range props {
+		if !a.props[k] {
+			continue
+		}
+		value, err := transformNumeric(v)
+		if err != nil {
+			return err
+		}
+		switch k {
+		case `creation`:
+			if aggregate.count == 1 || value < aggregate.oldest {
+				aggregate.oldest = value
+			}
+			if value > aggregate.newest {
+				aggregate.newest = value
+			}
+		case `used`:
+			aggregate.used += value
+		case `written`:
+			aggregate.written += value
+		case `referenced`:
+			if value > aggregate.referenced {
+				aggregate.referenced = value
+			}
+		}
+	}
+
+	return nil
+}
+
+func (a *snapshotAggregator) push(ch chan<- metric, pool string) {
+	for name, aggregate := range a.datasets {
+		labelValues := []string{name, pool}
+		a.pushValue(ch, snapshotCountDesc, snapshotCountDescName, float64(aggregate.count), labelValues...)
+		if a.props[`creation`] {
+			a.pushValue(ch, snapshotNewestDesc, snapshotNewestDescName, aggregate.newest, labelValues...)
+			a.pushValue(ch, snapshotOldestDesc, snapshotOldestDescName, aggregate.oldest, labelValues...)
+		}
+		if a.props[`used`] {
+			a.pushValue(ch, snapshotUsedDesc, snapshotUsedDescName, aggregate.used, labelValues...)
+		}
+		if a.props[`written`] {
+			a.pushValue(ch, snapshotWrittenDesc, snapshotWrittenDescName, aggregate.written, labelValues...)
+		}
+		if a.props[`referenced`] {
+			a.pushValue(ch, snapshotReferencedDesc, snapshotReferencedDescName, aggregate.referenced, labelValues...)
+		}
+	}
+}
+
+func (a *snapshotAggregator) pushValue(ch chan<- metric, desc *prometheus.Desc, name string, value float64, labelValues ...string) {
+	ch <- metric{
+		name:       expandMetricName(name, labelValues...),
+		prometheus: prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, labelValues...),
+	}
+}
+
+// parentDataset returns the name of the dataset that the named snapshot or bookmark was taken from.
+func parentDataset(name string) string {
+	if i := strings.IndexAny(name, `@#`); i >= 0 {
+		return name[:i]
+	}
+	return name
+}
+
+// newSnapshotAggregator returns an aggregator for the requested properties that may be aggregated.
+func newSnapshotAggregator(props ...string) *snapshotAggregator {
+	a := &snapshotAggregator{
+		props:    make(map[string]bool),
+		datasets: make(map[string]*snapshotAggregate),
+	}
+	for _, k := range props {
+		if snapshotAggregateProps[k] {
+			a.props[k] = true
+		}
+	}
+	return a
+}
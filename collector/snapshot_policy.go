@@ -0,0 +1,312 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+const (
+	snapshotPolicyReasonMaxAge   = `max_age`
+	snapshotPolicyReasonMinCount = `min_count`
+)
+
+var (
+	snapshotPolicyViolationDescName = prometheus.BuildFQName(namespace, `snapshot_policy`, `violation`)
+	snapshotPolicyViolationDesc     = prometheus.NewDesc(
+		snapshotPolicyViolationDescName,
+		`Whether the snapshots of the dataset violate the policy for this class of snapshot [0: compliant, 1: violation]. The max_age reason indicates that the newest snapshot of the class is too old or missing, the min_count reason indicates that too few snapshots of the class exist.`,
+		[]string{`dataset`, `class`, `reason`},
+		nil,
+	)
+
+	errNoSnapshotPolicyFile      = errors.New(`no snapshot policy file configured`)
+	errSnapshotPolicyUnavailable = errors.New(`snapshot policy file has not been loaded successfully, see earlier errors`)
+)
+
+func init() {
+	file := kingpin.Flag(`collector.snapshot-policy.file`, `Path to a JSON file describing the expected snapshots for each dataset, used by the snapshot-policy collector.`).Default(``).String()
+	registerCollectorWithoutProperties(`snapshot-policy`, defaultDisabled, newSnapshotPolicyCollectorFactory(file))
+}
+
+// snapshotPolicies is the content of a snapshot policy file. Each dataset is checked against the first policy whose
+// datasets match its name.
+type snapshotPolicies struct {
+	Policies []*snapshotPolicy `json:"policies"`
+}
+
+type snapshotPolicy struct {
+	Datasets []string               `json:"datasets"`
+	Classes  []*snapshotPolicyClass `json:"classes"`
+
+	datasets regexpCollection
+}
+
+// snapshotPolicyClass describes a class of snapshot (ie - hourly, daily), identified by a pattern matched against the
+// snapshot name, excluding the dataset.
+type snapshotPolicyClass struct {
+	Name     string         `json:"name"`
+	Pattern  string         `json:"pattern"`
+	MaxAge   model.Duration `json:"max_age"`
+	MinCount uint64         `json:"min_count"`
+
+	pattern *regexp.Regexp
+}
+
+// find returns the policy that applies to the dataset, or nil if none apply.
+func (p *snapshotPolicies) find(dataset string) *snapshotPolicy {
+	for _, policy := range p.Policies {
+		if policy.datasets.MatchString(dataset) {
+			return policy
+		}
+	}
+	return nil
+}
+
+// snapshotPolicyStatus holds the newest snapshot creation time and snapshot count for a single class of a dataset.
+type snapshotPolicyStatus struct {
+	count  uint64
+	newest float64
+}
+
+type snapshotPolicyCollector struct {
+	log    log.Logger
+	client zfs.Client
+	loader *snapshotPolicyLoader
+	now    func() time.Time
+}
+
+func (c *snapshotPolicyCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- snapshotPolicyViolationDesc
+}
+
+func (c *snapshotPolicyCollector) update(ctx context.Context, ch chan<- metric, pools []string, excludes regexpCollection) error {
+	policies, err := c.loader.load(c.log)
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	errChan := make(chan error, len(pools))
+	for _, pool := range pools {
+		wg.Add(1)
+		go func(pool string) {
+			if err := c.updatePoolMetrics(ctx, ch, pool, policies, excludes); err != nil {
+				errChan <- err
+			}
+			wg.Done()
+		}(pool)
+	}
+	wg.Wait()
+
+	select {
+	case err := <-errChan:
+		return err
+	default:
+		return nil
+	}
+}
+
+func (c *snapshotPolicyCollector) updatePoolMetrics(ctx context.Context, ch chan<- metric, pool string, policies *snapshotPolicies, excludes regexpCollection) error {
+	// Datasets without any snapshots are only found by listing them explicitly.
+	status := make(map[string]map[*snapshotPolicyClass]*snapshotPolicyStatus)
+	for _, kind := range []zfs.DatasetKind{zfs.DatasetFilesystem, zfs.DatasetVolume} {
+		datasets, err := c.client.Datasets(pool, kind).List(ctx, `type`)
+		if err != nil {
+			return err
+		}
+		for _, dataset := range datasets {
+			c.track(status, policies, dataset.DatasetName(), excludes)
+		}
+	}
+
+	snapshots, err := c.client.Datasets(pool, zfs.DatasetSnapshot).Properties(ctx, `creation`)
+	if err != nil {
+		return err
+	}
+	for _, snapshot := range snapshots {
+		name := snapshot.DatasetName()
+		if excludes.MatchString(name) {
+			continue
+		}
+		classes := c.track(status, policies, parentDataset(name), excludes)
+		if classes == nil {
+			continue
+		}
+		creation, err := transformNumeric(snapshot.Properties()[`creation`])
+		if err != nil {
+			return err
+		}
+		short := name[strings.IndexByte(name, '@')+1:]
+		for class, s := range classes {
+			if !class.pattern.MatchString(short) {
+				continue
+			}
+			s.count++
+			if creation > s.newest {
+				s.newest = creation
+			}
+		}
+	}
+
+	now := float64(c.now().Unix())
+	for dataset, classes := range status {
+		for class, s := range classes {
+			if class.MaxAge > 0 {
+				violation := s.count == 0 || now-s.newest > time.Duration(class.MaxAge).Seconds()
+				c.pushViolation(ch, violation, dataset, class.Name, snapshotPolicyReasonMaxAge)
+			}
+			if class.MinCount > 0 {
+				c.pushViolation(ch, s.count < class.MinCount, dataset, class.Name, snapshotPolicyReasonMinCount)
+			}
+		}
+	}
+
+	return nil
+}
+
+// track returns the status of each class in the policy that applies to the dataset, or nil if the dataset is not
+// subject to a policy.
+func (c *snapshotPolicyCollector) track(status map[string]map[*snapshotPolicyClass]*snapshotPolicyStatus, policies *snapshotPolicies, dataset string, excludes regexpCollection) map[*snapshotPolicyClass]*snapshotPolicyStatus {
+	if classes, ok := status[dataset]; ok {
+		return classes
+	}
+	if excludes.MatchString(dataset) {
+		return nil
+	}
+	policy := policies.find(dataset)
+	if policy == nil {
+		return nil
+	}
+	classes := make(map[*snapshotPolicyClass]*snapshotPolicyStatus, len(policy.Classes))
+	for _, class := range policy.Classes {
+		classes[class] = &snapshotPolicyStatus{}
+	}
+	status[dataset] = classes
+	return classes
+}
+
+func (c *snapshotPolicyCollector) pushViolation(ch chan<- metric, violation bool, labelValues ...string) {
+	var value float64
+	if violation {
+		value = 1
+	}
+	ch <- metric{
+		name:       expandMetricName(snapshotPolicyViolationDescName, labelValues...),
+		prometheus: prometheus.MustNewConstMetric(snapshotPolicyViolationDesc, prometheus.GaugeValue, value, labelValues...),
+	}
+}
+
+// snapshotPolicyLoader holds the most recently loaded policies, reloading the policy file only when it changes, so
+// that changes apply without restarting the exporter. If the file becomes invalid, the error is logged once, and the
+// previous policies remain in effect.
+type snapshotPolicyLoader struct {
+	file     *string
+	policies *snapshotPolicies
+	// modTime and size identify the version of the file most recently loaded, successfully or otherwise.
+	modTime time.Time
+	size    int64
+	lastErr string
+	sync.Mutex
+}
+
+func (p *snapshotPolicyLoader) load(l log.Logger) (*snapshotPolicies, error) {
+	p.Lock()
+	defer p.Unlock()
+	if *p.file == `` {
+		return nil, errNoSnapshotPolicyFile
+	}
+
+	info, err := os.Stat(*p.file)
+	if err != nil {
+		p.modTime, p.size = time.Time{}, 0
+		p.report(l, err)
+		return p.current()
+	}
+	if info.ModTime().Equal(p.modTime) && info.Size() == p.size {
+		return p.current()
+	}
+	p.modTime, p.size = info.ModTime(), info.Size()
+
+	policies, err := loadSnapshotPolicies(*p.file)
+	if err != nil {
+		p.report(l, err)
+		return p.current()
+	}
+	p.policies, p.lastErr = policies, ``
+
+	return policies, nil
+}
+
+func (p *snapshotPolicyLoader) current() (*snapshotPolicies, error) {
+	if p.policies == nil {
+		return nil, errSnapshotPolicyUnavailable
+	}
+	return p.policies, nil
+}
+
+// report logs the error, unless it is the same as the previous error.
+func (p *snapshotPolicyLoader) report(l log.Logger, err error) {
+	if err.Error() == p.lastErr {
+		return
+	}
+	p.lastErr = err.Error()
+	_ = level.Error(l).Log(`msg`, `Error loading snapshot policy file`, `file`, *p.file, `previousPoliciesInEffect`, p.policies != nil, `err`, err)
+}
+
+// loadSnapshotPolicies reads and validates the policy file.
+func loadSnapshotPolicies(file string) (*snapshotPolicies, error) {
+	if file == `` {
+		return nil, errNoSnapshotPolicyFile
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	policies := &snapshotPolicies{}
+	if err = json.Unmarshal(b, policies); err != nil {
+		return nil, fmt.Errorf("invalid snapshot policy file %s: %w", file, err)
+	}
+
+	for i, policy := range policies.Policies {
+		if len(policy.Datasets) == 0 {
+			return nil, fmt.Errorf("invalid snapshot policy file %s: policy %d has no datasets", file, i)
+		}
+		policy.datasets = make(regexpCollection, len(policy.Datasets))
+		for j, dataset := range policy.Datasets {
+			if policy.datasets[j], err = regexp.Compile(dataset); err != nil {
+				return nil, fmt.Errorf("invalid snapshot policy file %s: policy %d has an invalid datasets pattern: %w", file, i, err)
+			}
+		}
+		for _, class := range policy.Classes {
+			if class.Name == `` {
+				return nil, fmt.Errorf("invalid snapshot policy file %s: policy %d has a class with no name", file, i)
+			}
+			if class.pattern, err = regexp.Compile(class.Pattern); err != nil {
+				return nil, fmt.Errorf("invalid snapshot policy file %s: policy %d class %s has an invalid pattern: %w", file, i, class.Name, err)
+			}
+		}
+	}
+
+	return policies, nil
+}
+
+func newSnapshotPolicyCollectorFactory(file *string) factoryFunc {
+	loader := &snapshotPolicyLoader{file: file}
+	return func(l log.Logger, c zfs.Client, props []string) (Collector, error) {
+		return &snapshotPolicyCollector{log: l, client: c, loader: loader, now: time.Now}, nil
+	}
+}
@@ -0,0 +1,198 @@
+package collector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/golang/mock/gomock"
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/pdf/zfs_exporter/v2/zfs/mock_zfs"
+)
+
+func TestSnapshotPolicyMetrics(t *testing.T) {
+	t.Parallel()
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	config := defaultConfig(zfsClient)
+	config.Excludes = []string{`^testpool/excluded`}
+
+	zfsClient.EXPECT().PoolNames(gomock.Any()).Return([]string{`testpool`}, nil).Times(1)
+	collector, err := NewZFS(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1660100000, 0)
+	file := `testdata/snapshot_policy.json`
+	loader := &snapshotPolicyLoader{file: &file}
+	collector.Collectors = map[string]State{
+		`snapshot-policy`: {
+			Name:       "snapshot-policy",
+			Enabled:    boolPointer(true),
+			Properties: stringPointer(``),
+			factory: func(l log.Logger, c zfs.Client, props []string) (Collector, error) {
+				return &snapshotPolicyCollector{log: l, client: c, loader: loader, now: func() time.Time { return now }}, nil
+			},
+		},
+	}
+
+	datasetResults := func(names ...string) []zfs.DatasetProperties {
+		results := make([]zfs.DatasetProperties, len(names))
+		for i, name := range names {
+			dataset := mock_zfs.NewMockDatasetProperties(ctrl)
+			dataset.EXPECT().DatasetName().Return(name).MinTimes(1)
+			results[i] = dataset
+		}
+		return results
+	}
+	snapshotResults := map[string]string{
+		`testpool/db@autosnap_1_hourly`:      `1660092800`,
+		`testpool/db@autosnap_2_hourly`:      `1660096400`,
+		`testpool/db@autosnap_1_daily`:       `1660000000`,
+		`testpool/logs@autosnap_1_hourly`:    `1660000000`,
+		`testpool/logs@manual`:               `1660099000`,
+		`testpool/scratch@autosnap_1_hourly`: `1660000000`,
+		`testpool/excluded@autosnap_1_daily`: `1660000000`,
+	}
+	snapshots := make([]zfs.DatasetProperties, 0, len(snapshotResults))
+	for name, creation := range snapshotResults {
+		snapshot := mock_zfs.NewMockDatasetProperties(ctrl)
+		snapshot.EXPECT().DatasetName().Return(name).Times(1)
+		snapshot.EXPECT().Properties().Return(map[string]string{`creation`: creation}).AnyTimes()
+		snapshots = append(snapshots, snapshot)
+	}
+
+	filesystems := mock_zfs.NewMockDatasets(ctrl)
+	filesystems.EXPECT().List(gomock.Any(), `type`).Return(datasetResults(`testpool`, `testpool/db`, `testpool/logs`, `testpool/empty`, `testpool/scratch`, `testpool/excluded`), nil).Times(1)
+	volumes := mock_zfs.NewMockDatasets(ctrl)
+	volumes.EXPECT().List(gomock.Any(), `type`).Return(nil, nil).Times(1)
+	snapshotDatasets := mock_zfs.NewMockDatasets(ctrl)
+	snapshotDatasets.EXPECT().Properties(gomock.Any(), `creation`).Return(snapshots, nil).Times(1)
+	zfsClient.EXPECT().Datasets(`testpool`, zfs.DatasetFilesystem).Return(filesystems).Times(1)
+	zfsClient.EXPECT().Datasets(`testpool`, zfs.DatasetVolume).Return(volumes).Times(1)
+	zfsClient.EXPECT().Datasets(`testpool`, zfs.DatasetSnapshot).Return(snapshotDatasets).Times(1)
+
+	metricResults := `# HELP zfs_snapshot_policy_violation Whether the snapshots of the dataset violate the policy for this class of snapshot [0: compliant, 1: violation]. The max_age reason indicates that the newest snapshot of the class is too old or missing, the min_count reason indicates that too few snapshots of the class exist.
+# TYPE zfs_snapshot_policy_violation gauge
+zfs_snapshot_policy_violation{class="daily",dataset="testpool/db",reason="max_age"} 0
+zfs_snapshot_policy_violation{class="daily",dataset="testpool/empty",reason="max_age"} 1
+zfs_snapshot_policy_violation{class="daily",dataset="testpool/logs",reason="max_age"} 1
+zfs_snapshot_policy_violation{class="hourly",dataset="testpool/db",reason="max_age"} 0
+zfs_snapshot_policy_violation{class="hourly",dataset="testpool/db",reason="min_count"} 0
+zfs_snapshot_policy_violation{class="hourly",dataset="testpool/empty",reason="max_age"} 1
+zfs_snapshot_policy_violation{class="hourly",dataset="testpool/empty",reason="min_count"} 1
+zfs_snapshot_policy_violation{class="hourly",dataset="testpool/logs",reason="max_age"} 1
+zfs_snapshot_policy_violation{class="hourly",dataset="testpool/logs",reason="min_count"} 1
+`
+	if err = callCollector(ctx, collector, []byte(metricResults), []string{`zfs_snapshot_policy_violation`}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSnapshotPolicyFailed(t *testing.T) {
+	const result = `# HELP zfs_scrape_collector_success zfs_exporter: Whether a collector succeeded [0: failed, 1: succeeded, -1: cancelled].
+# TYPE zfs_scrape_collector_success gauge
+zfs_scrape_collector_success{collector="snapshot-policy"} 0
+`
+
+	for _, file := range []string{``, `testdata/missing.json`} {
+		file := file
+		t.Run(file, func(t *testing.T) {
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			zfsClient := mock_zfs.NewMockClient(ctrl)
+			zfsClient.EXPECT().PoolNames(gomock.Any()).Return([]string{`testpool`}, nil).Times(1)
+
+			config := defaultConfig(zfsClient)
+			config.DisableMetrics = false
+			collector, err := NewZFS(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			collector.Collectors = map[string]State{
+				`snapshot-policy`: {
+					Name:       "snapshot-policy",
+					Enabled:    boolPointer(true),
+					Properties: stringPointer(``),
+					factory:    newSnapshotPolicyCollectorFactory(&file),
+				},
+			}
+
+			if err = callCollector(ctx, collector, []byte(result), []string{`zfs_scrape_collector_success`}); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestLoadSnapshotPolicies(t *testing.T) {
+	if _, err := loadSnapshotPolicies(``); err != errNoSnapshotPolicyFile {
+		t.Fatalf("Expected error %v, got %v", errNoSnapshotPolicyFile, err)
+	}
+
+	policies, err := loadSnapshotPolicies(`testdata/snapshot_policy.json`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policies.find(`otherpool/db`) != nil {
+		t.Fatal(`Expected no policy for unmatched dataset`)
+	}
+	policy := policies.find(`testpool/db`)
+	if policy == nil || len(policy.Classes) != 2 {
+		t.Fatalf("Expected second policy for matched dataset, got %+v", policy)
+	}
+	if time.Duration(policy.Classes[1].MaxAge) != 48*time.Hour {
+		t.Fatalf("Expected max age of 48h, got %v", policy.Classes[1].MaxAge)
+	}
+}
+
+func TestSnapshotPolicyLoader(t *testing.T) {
+	valid, err := os.ReadFile(`testdata/snapshot_policy.json`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), `policy.json`)
+	loader := &snapshotPolicyLoader{file: &file}
+	write := func(b []byte, modTime time.Time) {
+		if err := os.WriteFile(file, b, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(file, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err = loader.load(logger); err != errSnapshotPolicyUnavailable {
+		t.Fatalf("Expected error %v for missing file, got %v", errSnapshotPolicyUnavailable, err)
+	}
+
+	write(valid, time.Unix(1660000000, 0))
+	first, err := loader.load(logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second, _ := loader.load(logger); second != first {
+		t.Fatal(`Expected unchanged file not to be reloaded`)
+	}
+
+	// An invalid file leaves the previous policies in effect.
+	write([]byte(`{"policies": [{"datasets": ["("]}]}`), time.Unix(1660000100, 0))
+	policies, err := loader.load(logger)
+	if err != nil || policies != first {
+		t.Fatalf("Expected previous policies after invalid change, got %v", err)
+	}
+	if loader.lastErr == `` {
+		t.Fatal(`Expected error for invalid file to be recorded`)
+	}
+
+	write(valid, time.Unix(1660000200, 0))
+	policies, err = loader.load(logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policies == first {
+		t.Fatal(`Expected changed file to be reloaded`)
+	}
+}
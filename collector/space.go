@@ -0,0 +1,191 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+const (
+	defaultSpaceProps = `group,user`
+)
+
+var (
+	spaceLabels = []string{`name`, `pool`, `space`, `principal_type`, `principal`}
+
+	spaceUsedDescName = prometheus.BuildFQName(namespace, subsystemDataset, `space_used_bytes`)
+	spaceUsedDesc     = prometheus.NewDesc(
+		spaceUsedDescName,
+		`The amount of space in bytes consumed by the user, group or project in this dataset.`,
+		spaceLabels,
+		nil,
+	)
+	spaceQuotaDescName = prometheus.BuildFQName(namespace, subsystemDataset, `space_quota_bytes`)
+	spaceQuotaDesc     = prometheus.NewDesc(
+		spaceQuotaDescName,
+		`The maximum amount of space in bytes the user, group or project can consume in this dataset, 0 when no quota is set.`,
+		spaceLabels,
+		nil,
+	)
+	spaceObjectsUsedDescName = prometheus.BuildFQName(namespace, subsystemDataset, `space_objects_used`)
+	spaceObjectsUsedDesc     = prometheus.NewDesc(
+		spaceObjectsUsedDescName,
+		`The number of objects owned by the user, group or project in this dataset.`,
+		spaceLabels,
+		nil,
+	)
+	spaceObjectsQuotaDescName = prometheus.BuildFQName(namespace, subsystemDataset, `space_objects_quota`)
+	spaceObjectsQuotaDesc     = prometheus.NewDesc(
+		spaceObjectsQuotaDescName,
+		`The maximum number of objects the user, group or project can own in this dataset, 0 when no quota is set.`,
+		spaceLabels,
+		nil,
+	)
+
+	errNoSpaceDatasets = errors.New(`no datasets allowed for space accounting`)
+)
+
+func init() {
+	datasets := kingpin.Flag(`collector.dataset-space.dataset`, `Include filesystems that match the provided regex (e.g. '^tank/home/') in the dataset-space collector, may be specified multiple times.`).Strings()
+	registerCollector(`dataset-space`, defaultDisabled, defaultSpaceProps, newSpaceCollectorFactory(datasets))
+}
+
+type spaceCollector struct {
+	log      log.Logger
+	client   zfs.Client
+	kinds    []zfs.SpaceKind
+	datasets regexpCollection
+}
+
+func (c *spaceCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- spaceUsedDesc
+	ch <- spaceQuotaDesc
+	ch <- spaceObjectsUsedDesc
+	ch <- spaceObjectsQuotaDesc
+}
+
+func (c *spaceCollector) update(ctx context.Context, ch chan<- metric, pools []string, excludes regexpCollection) error {
+	if len(c.datasets) == 0 {
+		return errNoSpaceDatasets
+	}
+
+	var wg sync.WaitGroup
+	errChan := make(chan error, len(pools))
+	for _, pool := range pools {
+		wg.Add(1)
+		go func(pool string) {
+			if err := c.updatePoolMetrics(ctx, ch, pool, excludes); err != nil {
+				errChan <- err
+			}
+			wg.Done()
+		}(pool)
+	}
+	wg.Wait()
+
+	select {
+	case err := <-errChan:
+		return err
+	default:
+		return nil
+	}
+}
+
+func (c *spaceCollector) updatePoolMetrics(ctx context.Context, ch chan<- metric, pool string, excludes regexpCollection) error {
+	datasets, err := c.client.Datasets(pool, zfs.DatasetFilesystem).List(ctx, `type`)
+	if err != nil {
+		return err
+	}
+
+	for _, dataset := range datasets {
+		name := dataset.DatasetName()
+		if !c.datasets.MatchString(name) || excludes.MatchString(name) {
+			continue
+		}
+		for _, kind := range c.kinds {
+			if err = c.updateDatasetMetrics(ctx, ch, pool, name, kind); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *spaceCollector) updateDatasetMetrics(ctx context.Context, ch chan<- metric, pool, dataset string, kind zfs.SpaceKind) error {
+	var (
+		spaces []zfs.Space
+		err    error
+	)
+	switch kind {
+	case zfs.SpaceUser:
+		spaces, err = c.client.UserSpace(ctx, dataset)
+	case zfs.SpaceGroup:
+		spaces, err = c.client.GroupSpace(ctx, dataset)
+	case zfs.SpaceProject:
+		spaces, err = c.client.ProjectSpace(ctx, dataset)
+		// Project accounting requires the project_quota feature, which may not be enabled on every pool, so a failure
+		// only omits the project metrics of the dataset, rather than those of users and groups.
+		if err != nil && ctx.Err() == nil {
+			_ = level.Warn(c.log).Log(`msg`, `Unable to query project space`, `dataset`, dataset, `err`, err)
+			return nil
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, space := range spaces {
+		labelValues := []string{dataset, pool, string(kind), spacePrincipalType(space.Type), space.Name}
+		for _, m := range []struct {
+			desc  *prometheus.Desc
+			name  string
+			value uint64
+		}{
+			{spaceUsedDesc, spaceUsedDescName, space.Used},
+			{spaceQuotaDesc, spaceQuotaDescName, space.Quota},
+			{spaceObjectsUsedDesc, spaceObjectsUsedDescName, space.ObjectsUsed},
+			{spaceObjectsQuotaDesc, spaceObjectsQuotaDescName, space.ObjectsQuota},
+		} {
+			ch <- metric{
+				name:       expandMetricName(m.name, labelValues...),
+				prometheus: prometheus.MustNewConstMetric(m.desc, prometheus.GaugeValue, float64(m.value), labelValues...),
+			}
+		}
+	}
+
+	return nil
+}
+
+// spacePrincipalType converts the principal type reported by ZFS to a label value (eg - `POSIX User` to `posix_user`).
+func spacePrincipalType(kind string) string {
+	return strings.ReplaceAll(strings.ToLower(kind), ` `, `_`)
+}
+
+func newSpaceCollectorFactory(datasets *[]string) factoryFunc {
+	return func(l log.Logger, c zfs.Client, props []string) (Collector, error) {
+		allowed, err := newRegexpCollection(*datasets)
+		if err != nil {
+			return nil, err
+		}
+
+		kinds := make([]zfs.SpaceKind, 0, len(props))
+		for _, p := range props {
+			switch kind := zfs.SpaceKind(p); kind {
+			case zfs.SpaceUser, zfs.SpaceGroup, zfs.SpaceProject:
+				kinds = append(kinds, kind)
+			case ``:
+			default:
+				_ = level.Warn(l).Log(`msg`, propertyUnsupportedMsg, `help`, helpIssue, `collector`, `dataset-space`, `property`, p, `err`, errUnsupportedProperty)
+			}
+		}
+
+		return &spaceCollector{log: l, client: c, kinds: kinds, datasets: allowed}, nil
+	}
+}
@@ -0,0 +1,120 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/pdf/zfs_exporter/v2/zfs/mock_zfs"
+)
+
+func TestSpaceMetrics(t *testing.T) {
+	t.Parallel()
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	config := defaultConfig(zfsClient)
+	config.Excludes = []string{`^testpool/home/excluded$`}
+
+	zfsClient.EXPECT().PoolNames(gomock.Any()).Return([]string{`testpool`}, nil).Times(1)
+	collector, err := NewZFS(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collector.Collectors = map[string]State{
+		`dataset-space`: {
+			Name:       "dataset-space",
+			Enabled:    boolPointer(true),
+			Properties: stringPointer(`user,project`),
+			factory:    newSpaceCollectorFactory(&[]string{`^testpool/home`}),
+		},
+	}
+
+	datasets := make([]zfs.DatasetProperties, 0)
+	for _, name := range []string{`testpool`, `testpool/home`, `testpool/home/excluded`} {
+		dataset := mock_zfs.NewMockDatasetProperties(ctrl)
+		dataset.EXPECT().DatasetName().Return(name).Times(1)
+		datasets = append(datasets, dataset)
+	}
+	zfsDatasets := mock_zfs.NewMockDatasets(ctrl)
+	zfsDatasets.EXPECT().List(gomock.Any(), `type`).Return(datasets, nil).Times(1)
+	zfsClient.EXPECT().Datasets(`testpool`, zfs.DatasetFilesystem).Return(zfsDatasets).Times(1)
+	zfsClient.EXPECT().UserSpace(gomock.Any(), `testpool/home`).Return([]zfs.Space{
+		{Type: `POSIX User`, Name: `alice`, Used: 2048, Quota: 1073741824, ObjectsUsed: 8, ObjectsQuota: 1000},
+		{Type: `SMB User`, Name: `alice`, Used: 1024, ObjectsUsed: 2},
+	}, nil).Times(1)
+	zfsClient.EXPECT().ProjectSpace(gomock.Any(), `testpool/home`).Return([]zfs.Space{
+		{Type: `Project`, Name: `100`, Used: 4096, Quota: 8192, ObjectsUsed: 3},
+	}, nil).Times(1)
+
+	metricResults := `# HELP zfs_dataset_space_objects_quota The maximum number of objects the user, group or project can own in this dataset, 0 when no quota is set.
+# TYPE zfs_dataset_space_objects_quota gauge
+zfs_dataset_space_objects_quota{name="testpool/home",pool="testpool",principal="100",principal_type="project",space="project"} 0
+zfs_dataset_space_objects_quota{name="testpool/home",pool="testpool",principal="alice",principal_type="posix_user",space="user"} 1000
+zfs_dataset_space_objects_quota{name="testpool/home",pool="testpool",principal="alice",principal_type="smb_user",space="user"} 0
+# HELP zfs_dataset_space_objects_used The number of objects owned by the user, group or project in this dataset.
+# TYPE zfs_dataset_space_objects_used gauge
+zfs_dataset_space_objects_used{name="testpool/home",pool="testpool",principal="100",principal_type="project",space="project"} 3
+zfs_dataset_space_objects_used{name="testpool/home",pool="testpool",principal="alice",principal_type="posix_user",space="user"} 8
+zfs_dataset_space_objects_used{name="testpool/home",pool="testpool",principal="alice",principal_type="smb_user",space="user"} 2
+# HELP zfs_dataset_space_quota_bytes The maximum amount of space in bytes the user, group or project can consume in this dataset, 0 when no quota is set.
+# TYPE zfs_dataset_space_quota_bytes gauge
+zfs_dataset_space_quota_bytes{name="testpool/home",pool="testpool",principal="100",principal_type="project",space="project"} 8192
+zfs_dataset_space_quota_bytes{name="testpool/home",pool="testpool",principal="alice",principal_type="posix_user",space="user"} 1.073741824e+09
+zfs_dataset_space_quota_bytes{name="testpool/home",pool="testpool",principal="alice",principal_type="smb_user",space="user"} 0
+# HELP zfs_dataset_space_used_bytes The amount of space in bytes consumed by the user, group or project in this dataset.
+# TYPE zfs_dataset_space_used_bytes gauge
+zfs_dataset_space_used_bytes{name="testpool/home",pool="testpool",principal="100",principal_type="project",space="project"} 4096
+zfs_dataset_space_used_bytes{name="testpool/home",pool="testpool",principal="alice",principal_type="posix_user",space="user"} 2048
+zfs_dataset_space_used_bytes{name="testpool/home",pool="testpool",principal="alice",principal_type="smb_user",space="user"} 1024
+`
+	metricNames := []string{`zfs_dataset_space_objects_quota`, `zfs_dataset_space_objects_used`, `zfs_dataset_space_quota_bytes`, `zfs_dataset_space_used_bytes`}
+	if err = callCollector(ctx, collector, []byte(metricResults), metricNames); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSpaceProjectUnsupported(t *testing.T) {
+	t.Parallel()
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	config := defaultConfig(zfsClient)
+	config.DisableMetrics = false
+
+	zfsClient.EXPECT().PoolNames(gomock.Any()).Return([]string{`testpool`}, nil).Times(1)
+	collector, err := NewZFS(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collector.Collectors = map[string]State{
+		`dataset-space`: {
+			Name:       "dataset-space",
+			Enabled:    boolPointer(true),
+			Properties: stringPointer(`user,project`),
+			factory:    newSpaceCollectorFactory(&[]string{`^testpool/home$`}),
+		},
+	}
+
+	dataset := mock_zfs.NewMockDatasetProperties(ctrl)
+	dataset.EXPECT().DatasetName().Return(`testpool/home`).Times(1)
+	zfsDatasets := mock_zfs.NewMockDatasets(ctrl)
+	zfsDatasets.EXPECT().List(gomock.Any(), `type`).Return([]zfs.DatasetProperties{dataset}, nil).Times(1)
+	zfsClient.EXPECT().Datasets(`testpool`, zfs.DatasetFilesystem).Return(zfsDatasets).Times(1)
+	zfsClient.EXPECT().UserSpace(gomock.Any(), `testpool/home`).Return([]zfs.Space{
+		{Type: `POSIX User`, Name: `alice`, Used: 2048},
+	}, nil).Times(1)
+	zfsClient.EXPECT().ProjectSpace(gomock.Any(), `testpool/home`).Return(nil, errors.New(`cannot get used/quota for testpool/home: unsupported version or feature`)).Times(1)
+
+	metricResults := `# HELP zfs_dataset_space_used_bytes The amount of space in bytes consumed by the user, group or project in this dataset.
+# TYPE zfs_dataset_space_used_bytes gauge
+zfs_dataset_space_used_bytes{name="testpool/home",pool="testpool",principal="alice",principal_type="posix_user",space="user"} 2048
+# HELP zfs_scrape_collector_success zfs_exporter: Whether a collector succeeded [0: failed, 1: succeeded, -1: cancelled].
+# TYPE zfs_scrape_collector_success gauge
+zfs_scrape_collector_success{collector="dataset-space"} 1
+`
+	metricNames := []string{`zfs_dataset_space_used_bytes`, `zfs_scrape_collector_success`}
+	if err = callCollector(ctx, collector, []byte(metricResults), metricNames); err != nil {
+		t.Fatal(err)
+	}
+}
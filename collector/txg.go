@@ -0,0 +1,224 @@
+package collector
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	txgSyncDurationBuckets = prometheus.ExponentialBuckets(0.001, 2, 16)
+	txgDirtyBytesBuckets   = prometheus.ExponentialBuckets(1<<20, 2, 13)
+
+	txgSyncDurationDescName = prometheus.BuildFQName(namespace, subsystemTxg, `sync_duration_seconds`)
+	txgSyncDurationDesc     = prometheus.NewDesc(
+		txgSyncDurationDescName,
+		`Duration in seconds of committed transaction group syncs.`,
+		poolLabels,
+		nil,
+	)
+	txgDirtyBytesDescName = prometheus.BuildFQName(namespace, subsystemTxg, `dirty_bytes`)
+	txgDirtyBytesDesc     = prometheus.NewDesc(
+		txgDirtyBytesDescName,
+		`Amount of dirty data in bytes synced by committed transaction groups.`,
+		poolLabels,
+		nil,
+	)
+	txgOpenDescName = prometheus.BuildFQName(namespace, subsystemTxg, `open`)
+	txgOpenDesc     = prometheus.NewDesc(
+		txgOpenDescName,
+		`Number of the currently open transaction group.`,
+		poolLabels,
+		nil,
+	)
+)
+
+func init() {
+	registerCollectorWithoutProperties(`txg`, defaultDisabled, newTxgCollectorFactory())
+}
+
+// histogram accumulates observations for a const histogram across collections.
+type histogram struct {
+	count   uint64
+	sum     float64
+	buckets map[float64]uint64
+}
+
+func (h *histogram) observe(v float64) {
+	h.count++
+	h.sum += v
+	for upper := range h.buckets {
+		if v <= upper {
+			h.buckets[upper]++
+		}
+	}
+}
+
+func (h *histogram) push(ch chan<- metric, desc *prometheus.Desc, name string, labelValues ...string) {
+	ch <- metric{
+		name:       expandMetricName(name, labelValues...),
+		prometheus: prometheus.MustNewConstHistogram(desc, h.count, h.sum, h.buckets, labelValues...),
+	}
+}
+
+func (h *histogram) copy() *histogram {
+	buckets := make(map[float64]uint64, len(h.buckets))
+	for upper, count := range h.buckets {
+		buckets[upper] = count
+	}
+	return &histogram{count: h.count, sum: h.sum, buckets: buckets}
+}
+
+func newHistogram(buckets []float64) *histogram {
+	h := &histogram{buckets: make(map[float64]uint64, len(buckets))}
+	for _, upper := range buckets {
+		h.buckets[upper] = 0
+	}
+	return h
+}
+
+// txgPoolHistory tracks the last committed txg observed for a pool, so that each txg is only counted once, despite
+// appearing in the kstat ring buffer for many collections.
+type txgPoolHistory struct {
+	lastTxg      uint64
+	syncDuration *histogram
+	dirtyBytes   *histogram
+}
+
+type txgHistory struct {
+	pools map[string]*txgPoolHistory
+	sync.Mutex
+}
+
+// update the history for the pool, returning a copy of the resulting histograms.
+func (h *txgHistory) update(pool string, txgs []zfs.Txg) (syncDuration, dirtyBytes *histogram) {
+	h.Lock()
+	defer h.Unlock()
+
+	history, ok := h.pools[pool]
+	if !ok {
+		history = &txgPoolHistory{
+			syncDuration: newHistogram(txgSyncDurationBuckets),
+			dirtyBytes:   newHistogram(txgDirtyBytesBuckets),
+		}
+		h.pools[pool] = history
+	}
+
+	var newest uint64
+	for _, txg := range txgs {
+		if txg.Txg > newest {
+			newest = txg.Txg
+		}
+	}
+	// The pool has been recreated, or the txg history reset, so start tracking from scratch.
+	if len(txgs) > 0 && newest < history.lastTxg {
+		history.lastTxg = 0
+	}
+
+	lastTxg := history.lastTxg
+	for _, txg := range txgs {
+		if txg.State != zfs.TxgCommitted || txg.Txg <= history.lastTxg {
+			continue
+		}
+		history.syncDuration.observe(txg.SyncTime.Seconds())
+		history.dirtyBytes.observe(float64(txg.Dirty))
+		if txg.Txg > lastTxg {
+			lastTxg = txg.Txg
+		}
+	}
+	history.lastTxg = lastTxg
+
+	return history.syncDuration.copy(), history.dirtyBytes.copy()
+}
+
+// prune removes the history of any pool that is not in pools, so that pools that have been exported or destroyed,
+// or are no longer selected for collection, are not retained for the lifetime of the exporter.
+func (h *txgHistory) prune(pools []string) {
+	h.Lock()
+	defer h.Unlock()
+	seen := make(map[string]bool, len(pools))
+	for _, pool := range pools {
+		seen[pool] = true
+	}
+	for pool := range h.pools {
+		if !seen[pool] {
+			delete(h.pools, pool)
+		}
+	}
+}
+
+type txgCollector struct {
+	log     log.Logger
+	client  zfs.Client
+	history *txgHistory
+}
+
+func (c *txgCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- txgSyncDurationDesc
+	ch <- txgDirtyBytesDesc
+	ch <- txgOpenDesc
+}
+
+func (c *txgCollector) update(ctx context.Context, ch chan<- metric, pools []string, excludes regexpCollection) error {
+	c.history.prune(pools)
+
+	var wg sync.WaitGroup
+	errChan := make(chan error, len(pools))
+	for _, pool := range pools {
+		wg.Add(1)
+		go func(pool string) {
+			if err := c.updatePoolMetrics(ctx, ch, pool); err != nil {
+				errChan <- err
+			}
+			wg.Done()
+		}(pool)
+	}
+	wg.Wait()
+
+	select {
+	case err := <-errChan:
+		return err
+	default:
+		return nil
+	}
+}
+
+func (c *txgCollector) updatePoolMetrics(ctx context.Context, ch chan<- metric, pool string) error {
+	txgs, err := c.client.Txgs(ctx, pool)
+	if err != nil {
+		return err
+	}
+
+	syncDuration, dirtyBytes := c.history.update(pool, txgs)
+	syncDuration.push(ch, txgSyncDurationDesc, txgSyncDurationDescName, pool)
+	dirtyBytes.push(ch, txgDirtyBytesDesc, txgDirtyBytesDescName, pool)
+
+	for _, txg := range txgs {
+		if txg.State != zfs.TxgOpen {
+			continue
+		}
+		ch <- metric{
+			name: expandMetricName(txgOpenDescName, pool),
+			prometheus: prometheus.MustNewConstMetric(
+				txgOpenDesc,
+				prometheus.GaugeValue,
+				float64(txg.Txg),
+				pool,
+			),
+		}
+	}
+
+	return nil
+}
+
+// newTxgCollectorFactory returns a factory sharing txg history between collector instances, since a new collector
+// is instantiated for every collection.
+func newTxgCollectorFactory() factoryFunc {
+	history := &txgHistory{pools: make(map[string]*txgPoolHistory)}
+	return func(l log.Logger, c zfs.Client, _props []string) (Collector, error) {
+		return &txgCollector{log: l, client: c, history: history}, nil
+	}
+}
@@ -0,0 +1,156 @@
+package collector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/golang/mock/gomock"
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/pdf/zfs_exporter/v2/zfs/mock_zfs"
+)
+
+func TestTxgMetrics(t *testing.T) {
+	collections := []struct {
+		txgs          []zfs.Txg
+		metricResults string
+	}{
+		{
+			txgs: []zfs.Txg{
+				{Txg: 100, State: zfs.TxgCommitted, Dirty: 1 << 20, SyncTime: 3 * time.Millisecond},
+				{Txg: 101, State: zfs.TxgCommitted, Dirty: 3 << 20, SyncTime: 20 * time.Millisecond},
+				{Txg: 102, State: zfs.TxgSyncing, Dirty: 2 << 20},
+				{Txg: 103, State: zfs.TxgOpen},
+			},
+			metricResults: `# HELP zfs_txg_open Number of the currently open transaction group.
+# TYPE zfs_txg_open gauge
+zfs_txg_open{pool="testpool"} 103
+# HELP zfs_txg_sync_duration_seconds Duration in seconds of committed transaction group syncs.
+# TYPE zfs_txg_sync_duration_seconds histogram
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="0.001"} 0
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="0.002"} 0
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="0.004"} 1
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="0.008"} 1
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="0.016"} 1
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="0.032"} 2
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="0.064"} 2
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="0.128"} 2
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="0.256"} 2
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="0.512"} 2
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="1.024"} 2
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="2.048"} 2
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="4.096"} 2
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="8.192"} 2
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="16.384"} 2
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="32.768"} 2
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="+Inf"} 2
+zfs_txg_sync_duration_seconds_sum{pool="testpool"} 0.023
+zfs_txg_sync_duration_seconds_count{pool="testpool"} 2
+`,
+		},
+		{
+			// Previously counted txgs remain in the ring buffer, and must not be counted again.
+			txgs: []zfs.Txg{
+				{Txg: 101, State: zfs.TxgCommitted, Dirty: 3 << 20, SyncTime: 20 * time.Millisecond},
+				{Txg: 102, State: zfs.TxgCommitted, Dirty: 2 << 20, SyncTime: 40 * time.Millisecond},
+				{Txg: 103, State: zfs.TxgQuiescing},
+				{Txg: 104, State: zfs.TxgOpen},
+			},
+			metricResults: `# HELP zfs_txg_open Number of the currently open transaction group.
+# TYPE zfs_txg_open gauge
+zfs_txg_open{pool="testpool"} 104
+# HELP zfs_txg_sync_duration_seconds Duration in seconds of committed transaction group syncs.
+# TYPE zfs_txg_sync_duration_seconds histogram
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="0.001"} 0
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="0.002"} 0
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="0.004"} 1
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="0.008"} 1
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="0.016"} 1
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="0.032"} 2
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="0.064"} 3
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="0.128"} 3
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="0.256"} 3
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="0.512"} 3
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="1.024"} 3
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="2.048"} 3
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="4.096"} 3
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="8.192"} 3
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="16.384"} 3
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="32.768"} 3
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="+Inf"} 3
+zfs_txg_sync_duration_seconds_sum{pool="testpool"} 0.063
+zfs_txg_sync_duration_seconds_count{pool="testpool"} 3
+`,
+		},
+	}
+
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	config := defaultConfig(zfsClient)
+
+	collector, err := NewZFS(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collector.Collectors = map[string]State{
+		`txg`: {
+			Name:       "txg",
+			Enabled:    boolPointer(true),
+			Properties: stringPointer(``),
+			factory:    newTxgCollectorFactory(),
+		},
+	}
+
+	for _, collection := range collections {
+		zfsClient.EXPECT().PoolNames(gomock.Any()).Return([]string{`testpool`}, nil).Times(1)
+		zfsClient.EXPECT().Txgs(gomock.Any(), `testpool`).Return(collection.txgs, nil).Times(1)
+
+		if err = callCollector(ctx, collector, []byte(collection.metricResults), []string{`zfs_txg_open`, `zfs_txg_sync_duration_seconds`}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestTxgHistoryPrune(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	config := defaultConfig(zfsClient)
+
+	zfsClient.EXPECT().PoolNames(gomock.Any()).Return([]string{`testpool`}, nil).Times(1)
+	zfsClient.EXPECT().Txgs(gomock.Any(), `testpool`).Return([]zfs.Txg{{Txg: 100, State: zfs.TxgOpen}}, nil).Times(1)
+	collector, err := NewZFS(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	history := &txgHistory{pools: make(map[string]*txgPoolHistory)}
+	history.update(`testpool`, nil)
+	history.update(`removed`, nil)
+	collector.Collectors = map[string]State{
+		`txg`: {
+			Name:       "txg",
+			Enabled:    boolPointer(true),
+			Properties: stringPointer(``),
+			factory: func(l log.Logger, c zfs.Client, props []string) (Collector, error) {
+				return &txgCollector{log: l, client: c, history: history}, nil
+			},
+		},
+	}
+
+	metricResults := `# HELP zfs_txg_open Number of the currently open transaction group.
+# TYPE zfs_txg_open gauge
+zfs_txg_open{pool="testpool"} 100
+`
+	if err = callCollector(ctx, collector, []byte(metricResults), []string{`zfs_txg_open`}); err != nil {
+		t.Fatal(err)
+	}
+
+	history.Lock()
+	defer history.Unlock()
+	if _, ok := history.pools[`removed`]; ok {
+		t.Fatal(`Expected history for removed pool to be pruned`)
+	}
+	if _, ok := history.pools[`testpool`]; !ok {
+		t.Fatal(`Expected history for collected pool to be retained`)
+	}
+}
@@ -0,0 +1,79 @@
+package collector
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	versionInfoDescName = prometheus.BuildFQName(namespace, `version`, `info`)
+	versionInfoDesc     = prometheus.NewDesc(
+		versionInfoDescName,
+		`Version of the OpenZFS userland tools and kernel module, as the userland and kernel labels, either is empty if unknown. The value is always 1.`,
+		[]string{`userland`, `kernel`},
+		nil,
+	)
+)
+
+func init() {
+	registerCollectorWithoutProperties(`version`, defaultDisabled, newVersionCollectorFactory())
+}
+
+type versionCollector struct {
+	log    log.Logger
+	client zfs.Client
+	warned *versionWarnings
+}
+
+func (c *versionCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- versionInfoDesc
+}
+
+func (c *versionCollector) update(ctx context.Context, ch chan<- metric, pools []string, excludes regexpCollection) error {
+	version, err := c.client.Version(ctx)
+	if err != nil {
+		return err
+	}
+
+	if version.Mismatch() && c.warned.first(version) {
+		_ = level.Warn(c.log).Log(`msg`, `OpenZFS userland and kernel module versions differ, some commands may fail or report unexpected results`, `userland`, version.Userland, `kernel`, version.Kernel)
+	}
+
+	labelValues := []string{version.Userland, version.Kernel}
+	ch <- metric{
+		name:       expandMetricName(versionInfoDescName, labelValues...),
+		prometheus: prometheus.MustNewConstMetric(versionInfoDesc, prometheus.GaugeValue, 1, labelValues...),
+	}
+
+	return nil
+}
+
+// versionWarnings records the versions that have been warned about, so that a mismatch is only logged once, rather
+// than on every collection.
+type versionWarnings struct {
+	seen map[zfs.Version]bool
+	sync.Mutex
+}
+
+// first returns true the first time it is called for the version.
+func (w *versionWarnings) first(version zfs.Version) bool {
+	w.Lock()
+	defer w.Unlock()
+	if w.seen[version] {
+		return false
+	}
+	w.seen[version] = true
+	return true
+}
+
+func newVersionCollectorFactory() factoryFunc {
+	warned := &versionWarnings{seen: make(map[zfs.Version]bool)}
+	return func(l log.Logger, c zfs.Client, props []string) (Collector, error) {
+		return &versionCollector{log: l, client: c, warned: warned}, nil
+	}
+}
@@ -0,0 +1,87 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/pdf/zfs_exporter/v2/zfs/mock_zfs"
+)
+
+func TestVersionMetrics(t *testing.T) {
+	testCases := []struct {
+		name          string
+		version       zfs.Version
+		metricResults string
+	}{
+		{
+			name:    `matching`,
+			version: zfs.Version{Userland: `2.2.2-0ubuntu9`, Kernel: `2.2.2-0ubuntu9`},
+			metricResults: `# HELP zfs_version_info Version of the OpenZFS userland tools and kernel module, as the userland and kernel labels, either is empty if unknown. The value is always 1.
+# TYPE zfs_version_info gauge
+zfs_version_info{kernel="2.2.2-0ubuntu9",userland="2.2.2-0ubuntu9"} 1
+`,
+		},
+		{
+			name:    `mismatch`,
+			version: zfs.Version{Userland: `2.3.1-1`, Kernel: `2.2.7-1`},
+			metricResults: `# HELP zfs_version_info Version of the OpenZFS userland tools and kernel module, as the userland and kernel labels, either is empty if unknown. The value is always 1.
+# TYPE zfs_version_info gauge
+zfs_version_info{kernel="2.2.7-1",userland="2.3.1-1"} 1
+`,
+		},
+		{
+			name:    `kernel only`,
+			version: zfs.Version{Kernel: `0.7.13-1`},
+			metricResults: `# HELP zfs_version_info Version of the OpenZFS userland tools and kernel module, as the userland and kernel labels, either is empty if unknown. The value is always 1.
+# TYPE zfs_version_info gauge
+zfs_version_info{kernel="0.7.13-1",userland=""} 1
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			zfsClient := mock_zfs.NewMockClient(ctrl)
+			config := defaultConfig(zfsClient)
+
+			zfsClient.EXPECT().PoolNames(gomock.Any()).Return([]string{`testpool`}, nil).Times(1)
+			zfsClient.EXPECT().Version(gomock.Any()).Return(tc.version, nil).Times(1)
+
+			collector, err := NewZFS(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			collector.Collectors = map[string]State{
+				`version`: {
+					Name:       "version",
+					Enabled:    boolPointer(true),
+					Properties: stringPointer(``),
+					factory:    newVersionCollectorFactory(),
+				},
+			}
+
+			if err = callCollector(ctx, collector, []byte(tc.metricResults), []string{`zfs_version_info`}); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestVersionWarnings(t *testing.T) {
+	warned := &versionWarnings{seen: make(map[zfs.Version]bool)}
+	version := zfs.Version{Userland: `2.3.1-1`, Kernel: `2.2.7-1`}
+	if !warned.first(version) {
+		t.Fatal(`Expected first warning for version`)
+	}
+	if warned.first(version) {
+		t.Fatal(`Expected no repeated warning for version`)
+	}
+	if !warned.first(zfs.Version{Userland: `2.3.1-1`, Kernel: `2.3.0-1`}) {
+		t.Fatal(`Expected first warning for changed version`)
+	}
+}
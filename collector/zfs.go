@@ -2,6 +2,7 @@ package collector
 
 import (
 	"context"
+	"errors"
 	"regexp"
 	"sort"
 	"strings"
@@ -26,27 +27,45 @@ func (c regexpCollection) MatchString(input string) bool {
 	return false
 }
 
+// newRegexpCollection compiles each of the expressions.
+func newRegexpCollection(exprs []string) (regexpCollection, error) {
+	result := make(regexpCollection, len(exprs))
+	for i, expr := range exprs {
+		r, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = r
+	}
+	return result, nil
+}
+
 // ZFSConfig configures a ZFS collector
 type ZFSConfig struct {
-	DisableMetrics bool
-	Deadline       time.Duration
-	Pools          []string
-	Excludes       []string
-	Logger         log.Logger
-	ZFSClient      zfs.Client
+	// Context bounds the lifetime of all collection runs, any running commands will be killed when it is done.
+	Context          context.Context
+	DisableMetrics   bool
+	Deadline         time.Duration
+	MaxBackgroundRun time.Duration
+	Pools            []string
+	Excludes         []string
+	Logger           log.Logger
+	ZFSClient        zfs.Client
 }
 
 // ZFS collector
 type ZFS struct {
-	Pools          []string
-	Collectors     map[string]State
-	client         zfs.Client
-	disableMetrics bool
-	deadline       time.Duration
-	cache          *metricCache
-	ready          chan struct{}
-	logger         log.Logger
-	excludes       regexpCollection
+	Pools            []string
+	Collectors       map[string]State
+	client           zfs.Client
+	ctx              context.Context
+	disableMetrics   bool
+	deadline         time.Duration
+	maxBackgroundRun time.Duration
+	cache            *metricCache
+	ready            chan struct{}
+	logger           log.Logger
+	excludes         regexpCollection
 }
 
 // Describe implements the prometheus.Collector interface.
@@ -79,6 +98,9 @@ func (c *ZFS) Collect(ch chan<- prometheus.Metric) {
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), c.deadline)
 	defer cancel()
+	// The run context may outlive this call, allowing collection to complete in the background after the deadline,
+	// but bounds the total run time so that stuck commands are eventually killed.
+	runCtx, runCancel := context.WithTimeout(c.ctx, c.deadline+c.maxBackgroundRun)
 
 	cache := newMetricCache()
 	proxy := make(chan metric)
@@ -116,12 +138,14 @@ func (c *ZFS) Collect(ch chan<- prometheus.Metric) {
 		}
 		// Signal completion and update full cache.
 		c.cache.replace(cache)
+		runCancel()
 		cancel()
 		// Notify next collection that we're ready to collect again
 		c.ready <- struct{}{}
 	}()
 
-	pools, poolErr := c.getPools(c.Pools)
+	pools, poolErr := c.getPools(runCtx, c.Pools)
+	client := newCollectionClient(c.client)
 
 	for name, state := range c.Collectors {
 		if !*state.Enabled {
@@ -135,14 +159,17 @@ func (c *ZFS) Collect(ch chan<- prometheus.Metric) {
 			continue
 		}
 
-		collector, err := state.factory(c.logger, c.client, strings.Split(*state.Properties, `,`))
+		collector, err := state.factory(c.logger, client, strings.Split(*state.Properties, `,`))
 		if err != nil {
 			_ = level.Error(c.logger).Log("Error instantiating collector", "collector", name, "err", err)
 			wg.Done()
 			continue
 		}
+		if b, ok := collector.(backgroundCollector); ok {
+			b.start(c.ctx)
+		}
 		go func(name string, collector Collector) {
-			c.execute(ctx, name, collector, proxy, pools)
+			c.execute(ctx, runCtx, name, collector, proxy, pools)
 			wg.Done()
 		}(name, collector)
 	}
@@ -163,6 +190,25 @@ func (c *ZFS) Collect(ch chan<- prometheus.Metric) {
 	<-finalized
 }
 
+// startBackground starts the background tasks of all enabled collectors, so that they gather data from startup,
+// rather than from the first collection.
+func (c *ZFS) startBackground() {
+	for _, state := range c.Collectors {
+		if !*state.Enabled {
+			continue
+		}
+
+		// Instantiation errors are reported on each collection.
+		collector, err := state.factory(c.logger, c.client, strings.Split(*state.Properties, `,`))
+		if err != nil {
+			continue
+		}
+		if b, ok := collector.(backgroundCollector); ok {
+			b.start(c.ctx)
+		}
+	}
+}
+
 // sendCached values that do not appear in the current cacheIndex.
 func (c *ZFS) sendCached(ch chan<- prometheus.Metric, cacheIndex map[string]struct{}) {
 	c.cache.RLock()
@@ -175,8 +221,8 @@ func (c *ZFS) sendCached(ch chan<- prometheus.Metric, cacheIndex map[string]stru
 	}
 }
 
-func (c *ZFS) getPools(pools []string) ([]string, error) {
-	poolNames, err := c.client.PoolNames()
+func (c *ZFS) getPools(ctx context.Context, pools []string) ([]string, error) {
+	poolNames, err := c.client.PoolNames(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -204,9 +250,9 @@ func (c *ZFS) getPools(pools []string) ([]string, error) {
 	return result, nil
 }
 
-func (c *ZFS) execute(ctx context.Context, name string, collector Collector, ch chan<- metric, pools []string) {
+func (c *ZFS) execute(ctx, runCtx context.Context, name string, collector Collector, ch chan<- metric, pools []string) {
 	begin := time.Now()
-	err := collector.update(ch, pools, c.excludes)
+	err := collector.update(runCtx, ch, pools, c.excludes)
 	duration := time.Since(begin)
 
 	c.publishCollectorMetrics(ctx, name, err, duration, ch)
@@ -215,9 +261,12 @@ func (c *ZFS) execute(ctx context.Context, name string, collector Collector, ch
 func (c *ZFS) publishCollectorMetrics(ctx context.Context, name string, err error, duration time.Duration, ch chan<- metric) {
 	var success float64
 
-	if err != nil {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		_ = level.Warn(c.logger).Log("msg", "Executing collector", "status", "cancelled", "collector", name, "durationSeconds", duration.Seconds(), "err", err)
+		success = collectorCancelled
+	} else if err != nil {
 		_ = level.Error(c.logger).Log("msg", "Executing collector", "status", "error", "collector", name, "durationSeconds", duration.Seconds(), "err", err)
-		success = 0
+		success = collectorFailed
 	} else {
 		select {
 		case <-ctx.Done():
@@ -227,10 +276,10 @@ func (c *ZFS) publishCollectorMetrics(ctx context.Context, name string, err erro
 		}
 		if err != nil && err != context.Canceled {
 			_ = level.Warn(c.logger).Log("msg", "Executing collector", "status", "delayed", "collector", name, "durationSeconds", duration.Seconds(), "err", ctx.Err())
-			success = 0
+			success = collectorFailed
 		} else {
 			_ = level.Debug(c.logger).Log("msg", "Executing collector", "status", "ok", "collector", name, "durationSeconds", duration.Seconds())
-			success = 1
+			success = collectorSucceeded
 		}
 	}
 
@@ -247,6 +296,40 @@ func (c *ZFS) publishCollectorMetrics(ctx context.Context, name string, err erro
 	}
 }
 
+// collectionClient shares the output of `zpool status` between the collectors of a single collection, so that the
+// pool-disks and pool-scan collectors only run it once.
+type collectionClient struct {
+	zfs.Client
+	mu    sync.Mutex
+	trees map[string]*vdevTreesResult
+}
+
+type vdevTreesResult struct {
+	once  sync.Once
+	trees []zfs.VdevTree
+	err   error
+}
+
+func (c *collectionClient) VdevTrees(ctx context.Context, pools ...string) ([]zfs.VdevTree, error) {
+	key := strings.Join(pools, "\x00")
+	c.mu.Lock()
+	result, ok := c.trees[key]
+	if !ok {
+		result = &vdevTreesResult{}
+		c.trees[key] = result
+	}
+	c.mu.Unlock()
+
+	result.once.Do(func() {
+		result.trees, result.err = c.Client.VdevTrees(ctx, pools...)
+	})
+	return result.trees, result.err
+}
+
+func newCollectionClient(client zfs.Client) *collectionClient {
+	return &collectionClient{Client: client, trees: make(map[string]*vdevTreesResult)}
+}
+
 // NewZFS instantiates a ZFS collector with the provided ZFSConfig
 func NewZFS(config ZFSConfig) (*ZFS, error) {
 	sort.Strings(config.Pools)
@@ -255,17 +338,26 @@ func NewZFS(config ZFSConfig) (*ZFS, error) {
 	for i, v := range config.Excludes {
 		excludes[i] = regexp.MustCompile(v)
 	}
+	ctx := config.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
 	ready := make(chan struct{}, 1)
 	ready <- struct{}{}
-	return &ZFS{
-		disableMetrics: config.DisableMetrics,
-		client:         config.ZFSClient,
-		deadline:       config.Deadline,
-		Pools:          config.Pools,
-		Collectors:     collectorStates,
-		excludes:       excludes,
-		cache:          newMetricCache(),
-		ready:          ready,
-		logger:         config.Logger,
-	}, nil
+	c := &ZFS{
+		disableMetrics:   config.DisableMetrics,
+		client:           config.ZFSClient,
+		ctx:              ctx,
+		deadline:         config.Deadline,
+		maxBackgroundRun: config.MaxBackgroundRun,
+		Pools:            config.Pools,
+		Collectors:       collectorStates,
+		excludes:         excludes,
+		cache:            newMetricCache(),
+		ready:            ready,
+		logger:           config.Logger,
+	}
+	c.startBackground()
+
+	return c, nil
 }
@@ -14,14 +14,14 @@ func TestZFSCollectInvalidPools(t *testing.T) {
 	const result = `# HELP zfs_scrape_collector_duration_seconds zfs_exporter: Duration of a collector scrape.
 # TYPE zfs_scrape_collector_duration_seconds gauge
 zfs_scrape_collector_duration_seconds{collector="pool"} 0
-# HELP zfs_scrape_collector_success zfs_exporter: Whether a collector succeeded.
+# HELP zfs_scrape_collector_success zfs_exporter: Whether a collector succeeded [0: failed, 1: succeeded, -1: cancelled].
 # TYPE zfs_scrape_collector_success gauge
 zfs_scrape_collector_success{collector="pool"} 0
 `
 
 	ctrl, ctx := gomock.WithContext(context.Background(), t)
 	zfsClient := mock_zfs.NewMockClient(ctrl)
-	zfsClient.EXPECT().PoolNames().Return(nil, fmt.Errorf(`Error returned from PoolNames()`)).Times(1)
+	zfsClient.EXPECT().PoolNames(gomock.Any()).Return(nil, fmt.Errorf(`Error returned from PoolNames()`)).Times(1)
 
 	config := defaultConfig(zfsClient)
 	config.DisableMetrics = false
@@ -46,61 +46,114 @@ zfs_scrape_collector_success{collector="pool"} 0
 func TestZFSCollectDisks(t *testing.T) {
 	const result = `# HELP zfs_disk_checksum_error zfs_exporter: Disk checksum errors
 # TYPE zfs_disk_checksum_error gauge
-zfs_disk_checksum_error{disk="mirror-0",kind="vdev",state="ONLINE",vdev="mirror-0",zpool="ssd_tank"} 27
-zfs_disk_checksum_error{disk="sdc",kind="disk",state="ONLINE",vdev="mirror-0",zpool="ssd_tank"} 28
+zfs_disk_checksum_error{class="log",disk="sde",kind="vdev",state="ONLINE",vdev="sde",zpool="ssd_tank"} 0
+zfs_disk_checksum_error{class="normal",disk="mirror-0",kind="vdev",state="ONLINE",vdev="mirror-0",zpool="ssd_tank"} 27
+zfs_disk_checksum_error{class="normal",disk="replacing-1",kind="vdev",state="DEGRADED",vdev="mirror-0",zpool="ssd_tank"} 0
+zfs_disk_checksum_error{class="normal",disk="sda",kind="disk",state="UNAVAIL",vdev="replacing-1",zpool="ssd_tank"} 3
+zfs_disk_checksum_error{class="normal",disk="sdc",kind="disk",state="ONLINE",vdev="mirror-0",zpool="ssd_tank"} 28
+zfs_disk_checksum_error{class="normal",disk="sdd",kind="disk",state="ONLINE",vdev="replacing-1",zpool="ssd_tank"} 0
 # HELP zfs_disk_read_error zfs_exporter: Disk read errors
 # TYPE zfs_disk_read_error gauge
-zfs_disk_read_error{disk="mirror-0",kind="vdev",state="ONLINE",vdev="mirror-0",zpool="ssd_tank"} 1
-zfs_disk_read_error{disk="sdc",kind="disk",state="ONLINE",vdev="mirror-0",zpool="ssd_tank"} 2
+zfs_disk_read_error{class="log",disk="sde",kind="vdev",state="ONLINE",vdev="sde",zpool="ssd_tank"} 0
+zfs_disk_read_error{class="normal",disk="mirror-0",kind="vdev",state="ONLINE",vdev="mirror-0",zpool="ssd_tank"} 1
+zfs_disk_read_error{class="normal",disk="replacing-1",kind="vdev",state="DEGRADED",vdev="mirror-0",zpool="ssd_tank"} 0
+zfs_disk_read_error{class="normal",disk="sda",kind="disk",state="UNAVAIL",vdev="replacing-1",zpool="ssd_tank"} 0
+zfs_disk_read_error{class="normal",disk="sdc",kind="disk",state="ONLINE",vdev="mirror-0",zpool="ssd_tank"} 2
+zfs_disk_read_error{class="normal",disk="sdd",kind="disk",state="ONLINE",vdev="replacing-1",zpool="ssd_tank"} 0
 # HELP zfs_disk_status zfs_exporter: Disk status
 # TYPE zfs_disk_status gauge
-zfs_disk_status{disk="mirror-0",kind="vdev",state="ONLINE",vdev="mirror-0",zpool="ssd_tank"} 1
-zfs_disk_status{disk="sdc",kind="disk",state="ONLINE",vdev="mirror-0",zpool="ssd_tank"} 1
-zfs_disk_status{disk="sdj",kind="spare",state="AVAIL",vdev="",zpool="spares"} 1
+zfs_disk_status{class="cache",disk="sdf",kind="vdev",state="ONLINE",vdev="sdf",zpool="ssd_tank"} 1
+zfs_disk_status{class="log",disk="sde",kind="vdev",state="ONLINE",vdev="sde",zpool="ssd_tank"} 1
+zfs_disk_status{class="normal",disk="mirror-0",kind="vdev",state="ONLINE",vdev="mirror-0",zpool="ssd_tank"} 1
+zfs_disk_status{class="normal",disk="replacing-1",kind="vdev",state="DEGRADED",vdev="mirror-0",zpool="ssd_tank"} 1
+zfs_disk_status{class="normal",disk="sda",kind="disk",state="UNAVAIL",vdev="replacing-1",zpool="ssd_tank"} 1
+zfs_disk_status{class="normal",disk="sdc",kind="disk",state="ONLINE",vdev="mirror-0",zpool="ssd_tank"} 1
+zfs_disk_status{class="normal",disk="sdd",kind="disk",state="ONLINE",vdev="replacing-1",zpool="ssd_tank"} 1
+zfs_disk_status{class="spare",disk="sdj",kind="spare",state="AVAIL",vdev="",zpool="ssd_tank"} 1
 # HELP zfs_disk_write_error zfs_exporter: Disk write errors
 # TYPE zfs_disk_write_error gauge
-zfs_disk_write_error{disk="mirror-0",kind="vdev",state="ONLINE",vdev="mirror-0",zpool="ssd_tank"} 14
-zfs_disk_write_error{disk="sdc",kind="disk",state="ONLINE",vdev="mirror-0",zpool="ssd_tank"} 15
+zfs_disk_write_error{class="log",disk="sde",kind="vdev",state="ONLINE",vdev="sde",zpool="ssd_tank"} 0
+zfs_disk_write_error{class="normal",disk="mirror-0",kind="vdev",state="ONLINE",vdev="mirror-0",zpool="ssd_tank"} 14
+zfs_disk_write_error{class="normal",disk="replacing-1",kind="vdev",state="DEGRADED",vdev="mirror-0",zpool="ssd_tank"} 0
+zfs_disk_write_error{class="normal",disk="sda",kind="disk",state="UNAVAIL",vdev="replacing-1",zpool="ssd_tank"} 0
+zfs_disk_write_error{class="normal",disk="sdc",kind="disk",state="ONLINE",vdev="mirror-0",zpool="ssd_tank"} 15
+zfs_disk_write_error{class="normal",disk="sdd",kind="disk",state="ONLINE",vdev="replacing-1",zpool="ssd_tank"} 0
 `
 
 	ctrl, ctx := gomock.WithContext(context.Background(), t)
 	zfsClient := mock_zfs.NewMockClient(ctrl)
-	toReturn := []zfs.PoolDisk{
+	toReturn := []zfs.VdevTree{
 		{
-			Zpool:          "ssd_tank",
-			Name:           "mirror-0",
-			Vdev:           "mirror-0",
-			Kind:           "vdev",
-			State:          "ONLINE",
-			ReadErrors:     1,
-			WriteErrors:    14,
-			ChecksumErrors: 27,
-		},
-		{
-			Zpool:          "ssd_tank",
-			Vdev:           "mirror-0",
-			Name:           "sdc",
-			Kind:           "disk",
-			State:          "ONLINE",
-			ReadErrors:     2,
-			WriteErrors:    15,
-			ChecksumErrors: 28,
-		},
-		{
-			Zpool:          "spares",
-			Name:           "sdj",
-			Kind:           "spare",
-			State:          "AVAIL",
-			ReadErrors:     0,
-			WriteErrors:    0,
-			ChecksumErrors: 0,
+			Pool: `ssd_tank`,
+			Root: zfs.Vdev{
+				Name:  `ssd_tank`,
+				State: `DEGRADED`,
+				Class: zfs.VdevClassNormal,
+				Children: []*zfs.Vdev{
+					{
+						Name:           `mirror-0`,
+						State:          `ONLINE`,
+						Class:          zfs.VdevClassNormal,
+						ReadErrors:     1,
+						WriteErrors:    14,
+						ChecksumErrors: 27,
+						Children: []*zfs.Vdev{
+							{
+								Name:           `sdc`,
+								State:          `ONLINE`,
+								Class:          zfs.VdevClassNormal,
+								ReadErrors:     2,
+								WriteErrors:    15,
+								ChecksumErrors: 28,
+							},
+							{
+								Name:  `replacing-1`,
+								State: `DEGRADED`,
+								Class: zfs.VdevClassNormal,
+								Children: []*zfs.Vdev{
+									{
+										Name:           `sda`,
+										State:          `UNAVAIL`,
+										Class:          zfs.VdevClassNormal,
+										ChecksumErrors: 3,
+										Annotation:     `was /dev/sda1`,
+									},
+									{
+										Name:  `sdd`,
+										State: `ONLINE`,
+										Class: zfs.VdevClassNormal,
+									},
+								},
+							},
+						},
+					},
+					{
+						Name:  `sde`,
+						State: `ONLINE`,
+						Class: zfs.VdevClassLog,
+					},
+					{
+						Name:  `sdf`,
+						State: `ONLINE`,
+						Class: zfs.VdevClassCache,
+						Err:   zfs.ErrInvalidOutput,
+					},
+					{
+						Name:  `sdj`,
+						State: `AVAIL`,
+						Class: zfs.VdevClassSpare,
+					},
+				},
+			},
 		},
 	}
-	zfsClient.EXPECT().PoolNames().Return([]string{}, nil)
-	zfsClient.EXPECT().PoolDisks().Return(toReturn, nil)
+	zfsClient.EXPECT().PoolNames(gomock.Any()).Return([]string{`ssd_tank`}, nil)
+	zfsClient.EXPECT().VdevTrees(gomock.Any(), `ssd_tank`).Return(toReturn, nil)
 
 	config := defaultConfig(zfsClient)
 	config.DisableMetrics = false
+	// Dataset excludes that also match the pool name must not hide the disks of the pool.
+	config.Excludes = []string{`^ssd_tank/`, `ssd_tank`}
 	collector, err := NewZFS(config)
 	collector.Collectors = map[string]State{
 		`pool-disks`: {
@@ -124,3 +177,36 @@ zfs_disk_write_error{disk="sdc",kind="disk",state="ONLINE",vdev="mirror-0",zpool
 		t.Fatal(err)
 	}
 }
+
+func TestZFSCollectCancelled(t *testing.T) {
+	const result = `# HELP zfs_scrape_collector_success zfs_exporter: Whether a collector succeeded [0: failed, 1: succeeded, -1: cancelled].
+# TYPE zfs_scrape_collector_success gauge
+zfs_scrape_collector_success{collector="pool"} -1
+`
+
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	zfsPool := mock_zfs.NewMockPool(ctrl)
+	zfsPool.EXPECT().Properties(gomock.Any(), gomock.Any()).Return(nil, context.DeadlineExceeded).Times(1)
+	zfsClient.EXPECT().PoolNames(gomock.Any()).Return([]string{`testpool`}, nil).Times(1)
+	zfsClient.EXPECT().Pool(`testpool`).Return(zfsPool).Times(1)
+
+	config := defaultConfig(zfsClient)
+	config.DisableMetrics = false
+	collector, err := NewZFS(config)
+	collector.Collectors = map[string]State{
+		`pool`: {
+			Name:       "pool",
+			Enabled:    boolPointer(true),
+			Properties: stringPointer(`allocated`),
+			factory:    newPoolCollector,
+		},
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = callCollector(ctx, collector, []byte(result), []string{`zfs_scrape_collector_success`}); err != nil {
+		t.Fatal(err)
+	}
+}
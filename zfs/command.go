@@ -0,0 +1,79 @@
+package zfs
+
+import (
+	"context"
+	"os/exec"
+	"sync"
+	"syscall"
+)
+
+// command wraps exec.Cmd, running the child in its own process group so that the entire group may be killed when
+// the context is done. Any error returned from Wait after the context is done will be the context error, allowing
+// callers to distinguish cancelled commands from failed ones.
+type command struct {
+	*exec.Cmd
+	ctx     context.Context
+	done    chan struct{}
+	started bool
+	once    sync.Once
+	err     error
+}
+
+// Start the command, and kill the process group if the context is done before the command exits.
+func (c *command) Start() error {
+	if err := c.ctx.Err(); err != nil {
+		return err
+	}
+	if err := c.Cmd.Start(); err != nil {
+		return err
+	}
+	c.started = true
+
+	go func(pid int) {
+		select {
+		case <-c.ctx.Done():
+			_ = syscall.Kill(-pid, syscall.SIGKILL)
+		case <-c.done:
+		}
+	}(c.Process.Pid)
+
+	return nil
+}
+
+// Wait for the command to exit, it is safe to call Wait multiple times.
+func (c *command) Wait() error {
+	c.once.Do(func() {
+		c.err = c.Cmd.Wait()
+		close(c.done)
+		if c.err != nil && c.ctx.Err() != nil {
+			c.err = c.ctx.Err()
+		}
+	})
+
+	return c.err
+}
+
+// close kills the process group if the command is still running, and waits for it to exit. It is intended to be
+// deferred, so that returning early on parse errors does not leak child processes.
+func (c *command) close() {
+	if !c.started {
+		return
+	}
+	select {
+	case <-c.done:
+		return
+	default:
+	}
+	_ = syscall.Kill(-c.Process.Pid, syscall.SIGKILL)
+	_ = c.Wait()
+}
+
+func newCommand(ctx context.Context, name string, args ...string) *command {
+	cmd := exec.Command(name, args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	return &command{
+		Cmd:  cmd,
+		ctx:  ctx,
+		done: make(chan struct{}),
+	}
+}
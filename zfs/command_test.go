@@ -0,0 +1,41 @@
+package zfs
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCommandCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// The backgrounded sleep inherits stdout, so Wait will not return unless the whole process group is killed.
+	cmd := newCommand(ctx, `sh`, `-c`, `sleep 30 & sleep 30`)
+	cmd.Stdout = &bytes.Buffer{}
+	defer cmd.close()
+
+	begin := time.Now()
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	err := cmd.Wait()
+	if err != context.DeadlineExceeded {
+		t.Fatalf("Expected %v, got %v", context.DeadlineExceeded, err)
+	}
+	if elapsed := time.Since(begin); elapsed > 5*time.Second {
+		t.Fatalf("Command was not killed on cancellation, ran for %s", elapsed)
+	}
+}
+
+func TestCommandCancelledBeforeStart(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cmd := newCommand(ctx, `true`)
+	defer cmd.close()
+	if err := cmd.Start(); err != context.Canceled {
+		t.Fatalf("Expected %v, got %v", context.Canceled, err)
+	}
+}
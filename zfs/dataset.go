@@ -1,6 +1,7 @@
 package zfs
 
 import (
+	"context"
 	"strings"
 )
 
@@ -14,11 +15,14 @@ const (
 	DatasetVolume DatasetKind = `volume`
 	// DatasetSnapshot enum entry
 	DatasetSnapshot DatasetKind = `snapshot`
+	// DatasetBookmark enum entry
+	DatasetBookmark DatasetKind = `bookmark`
 )
 
 type datasetsImpl struct {
 	pool string
 	kind DatasetKind
+	caps *capabilityProbe
 }
 
 func (d datasetsImpl) Pool() string {
@@ -29,14 +33,43 @@ func (d datasetsImpl) Kind() DatasetKind {
 	return d.kind
 }
 
-func (d datasetsImpl) Properties(props ...string) ([]DatasetProperties, error) {
+func (d datasetsImpl) Properties(ctx context.Context, props ...string) ([]DatasetProperties, error) {
+	if ok, err := d.supported(ctx); !ok {
+		return nil, err
+	}
 	handler := newDatasetHandler()
-	if err := execute(d.pool, handler, `zfs`, `get`, `-Hprt`, string(d.kind), `-o`, `name,property,value`, strings.Join(props, `,`)); err != nil {
+	if err := execute(ctx, d.pool, handler, `zfs`, `get`, `-Hprt`, string(d.kind), `-o`, `name,property,value`, strings.Join(props, `,`)); err != nil {
 		return nil, err
 	}
 	return handler.datasets(), nil
 }
 
+// List the properties of each dataset via `zfs list`, which returns a single row per dataset, and is considerably
+// cheaper than Properties for large numbers of datasets.
+func (d datasetsImpl) List(ctx context.Context, props ...string) ([]DatasetProperties, error) {
+	if ok, err := d.supported(ctx); !ok {
+		return nil, err
+	}
+	handler := newDatasetListHandler(props)
+	if err := execute(ctx, d.pool, handler, `zfs`, `list`, `-Hprt`, string(d.kind), `-o`, strings.Join(append([]string{`name`}, props...), `,`)); err != nil {
+		return nil, err
+	}
+	return handler.datasets(), nil
+}
+
+// supported returns false if the installed version cannot query datasets of this kind, in which case no datasets are
+// returned, since `zfs get -t bookmark` fails prior to ZFS on Linux 0.6.4.
+func (d datasetsImpl) supported(ctx context.Context) (bool, error) {
+	if d.kind != DatasetBookmark {
+		return true, nil
+	}
+	c, err := d.caps.get(ctx)
+	if err != nil {
+		return false, err
+	}
+	return c.BookmarkType, nil
+}
+
 type datasetPropertiesImpl struct {
 	datasetName string
 	properties  map[string]string
@@ -67,6 +100,25 @@ func (h *datasetHandler) processLine(pool string, line []string) error {
 	return nil
 }
 
+// datasetListHandler handles parsing of the rows returned from `zfs list` into Dataset structs
+type datasetListHandler struct {
+	*datasetHandler
+	props []string
+}
+
+// processLine implements the handler interface
+func (h *datasetListHandler) processLine(pool string, line []string) error {
+	if len(line) != len(h.props)+1 {
+		return ErrInvalidOutput
+	}
+	for i, prop := range h.props {
+		if err := h.datasetHandler.processLine(pool, []string{line[0], prop, line[i+1]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (h *datasetHandler) datasets() []DatasetProperties {
 	result := make([]DatasetProperties, len(h.store))
 	i := 0
@@ -84,10 +136,11 @@ func newDatasetPropertiesImpl(name string) *datasetPropertiesImpl {
 	}
 }
 
-func newDatasetsImpl(pool string, kind DatasetKind) datasetsImpl {
+func newDatasetsImpl(pool string, kind DatasetKind, caps *capabilityProbe) datasetsImpl {
 	return datasetsImpl{
 		pool: pool,
 		kind: kind,
+		caps: caps,
 	}
 }
 
@@ -96,3 +149,10 @@ func newDatasetHandler() *datasetHandler {
 		store: make(map[string]*datasetPropertiesImpl),
 	}
 }
+
+func newDatasetListHandler(props []string) *datasetListHandler {
+	return &datasetListHandler{
+		datasetHandler: newDatasetHandler(),
+		props:          props,
+	}
+}
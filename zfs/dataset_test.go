@@ -0,0 +1,53 @@
+package zfs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDatasetListHandler(t *testing.T) {
+	handler := newDatasetListHandler([]string{`used`, `creation`})
+	for _, line := range [][]string{
+		{`testpool/fs@daily-1`, `1024`, `1660000000`},
+		{`testpool/fs@daily-2`, `2048`, `1660086400`},
+	} {
+		if err := handler.processLine(`testpool`, line); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result := make(map[string]map[string]string)
+	for _, dataset := range handler.datasets() {
+		result[dataset.DatasetName()] = dataset.Properties()
+	}
+	expected := map[string]map[string]string{
+		`testpool/fs@daily-1`: {`used`: `1024`, `creation`: `1660000000`},
+		`testpool/fs@daily-2`: {`used`: `2048`, `creation`: `1660086400`},
+	}
+	if diff := cmp.Diff(result, expected); diff != `` {
+		t.Fatalf("Parsed datasets are not equal to expected datasets: %s", diff)
+	}
+
+	for _, line := range [][]string{
+		{`testpool/fs@daily-3`, `1024`},
+		{`otherpool/fs@daily-1`, `1024`, `1660000000`},
+	} {
+		if err := handler.processLine(`testpool`, line); err != ErrInvalidOutput {
+			t.Fatalf("Expected error %v for %v, got %v", ErrInvalidOutput, line, err)
+		}
+	}
+}
+
+func TestDatasetsBookmarkUnsupported(t *testing.T) {
+	ctx := context.Background()
+	d := newDatasetsImpl(`testpool`, DatasetBookmark, &capabilityProbe{detected: &Capabilities{}})
+	datasets, err := d.Properties(ctx, `used`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(datasets) != 0 {
+		t.Fatalf("Expected no datasets, got %d", len(datasets))
+	}
+}
@@ -0,0 +1,154 @@
+package zfs
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+var (
+	dedupSummaryRegexp = regexp.MustCompile(`^dedup: DDT entries (\S+), size (\S+) on disk, (\S+) in core$`)
+	dedupClassRegexp   = regexp.MustCompile(`^(DDT-\S+): (\S+) entries, size (\S+) on disk, (\S+) in core$`)
+	dedupEmptySummary  = `dedup: no DDT entries`
+)
+
+// DedupStats holds the dedup table (DDT) statistics of a pool, as reported by `zpool status -DD`
+type DedupStats struct {
+	// Entries holds the number of entries in the DDT
+	Entries uint64
+	// EntrySizeOnDisk and EntrySizeInCore hold the average size in bytes of each entry
+	EntrySizeOnDisk uint64
+	EntrySizeInCore uint64
+	// Classes holds the statistics of each DDT (eg - DDT-sha256-zap-duplicate), where reported
+	Classes []DedupClass
+	// Histogram holds the DDT histogram aggregated over all DDTs, with a bucket for each power of two reference count
+	Histogram []DedupBucket
+}
+
+// DedupClass holds the statistics of a single DDT, identified by checksum, object type and class
+type DedupClass struct {
+	Name            string
+	Entries         uint64
+	EntrySizeOnDisk uint64
+	EntrySizeInCore uint64
+}
+
+// DedupBucket holds the blocks with a reference count in the range [RefCount, 2*RefCount)
+type DedupBucket struct {
+	RefCount   uint64
+	Allocated  DedupBlocks
+	Referenced DedupBlocks
+}
+
+// DedupBlocks holds the block count and sizes of a DDT histogram bucket
+type DedupBlocks struct {
+	Blocks uint64
+	// LogicalSize holds the uncompressed size in bytes
+	LogicalSize uint64
+	// PhysicalSize holds the compressed size in bytes
+	PhysicalSize uint64
+	// AllocatedSize holds the size in bytes allocated on disk, including any parity or redundancy
+	AllocatedSize uint64
+}
+
+func (p poolImpl) Dedup(ctx context.Context) (DedupStats, error) {
+	lines, err := poolStatus(ctx, p.caps, `-DD`, p.name)
+	if err != nil {
+		return DedupStats{}, err
+	}
+
+	return parseDedupStats(lines)
+}
+
+// Example string to parse, following the config and errors sections:
+//
+//	 dedup: DDT entries 2435, size 1234 on disk, 320 in core
+//
+//	DDT-sha256-zap-duplicate: 198 entries, size 1102 on disk, 356 in core
+//	DDT-sha256-zap-unique: 2237 entries, size 1245 on disk, 317 in core
+//
+//	bucket              allocated                       referenced
+//	______   ______________________________   ______________________________
+//	refcnt   blocks   LSIZE   PSIZE   DSIZE   blocks   LSIZE   PSIZE   DSIZE
+//	------   ------   -----   -----   -----   ------   -----   -----   -----
+//	     1    2.22K    284M    284M    284M    2.22K    284M    284M    284M
+//	     2      198   24.8M   24.8M   24.8M      396   49.5M   49.5M   49.5M
+//	 Total    2.41K    309M    309M    309M    2.61K    333M    333M    333M
+func parseDedupStats(lines []string) (DedupStats, error) {
+	stats := DedupStats{}
+	summary := -1
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == dedupEmptySummary {
+			return stats, nil
+		}
+		matches := dedupSummaryRegexp.FindStringSubmatch(trimmed)
+		if matches == nil {
+			continue
+		}
+		for j, value := range []*uint64{&stats.Entries, &stats.EntrySizeOnDisk, &stats.EntrySizeInCore} {
+			v, err := parseNiceNumber(matches[j+1])
+			if err != nil {
+				return DedupStats{}, err
+			}
+			*value = v
+		}
+		summary = i
+		break
+	}
+	if summary < 0 {
+		return DedupStats{}, ErrInvalidOutput
+	}
+
+	for _, line := range lines {
+		matches := dedupClassRegexp.FindStringSubmatch(strings.TrimSpace(line))
+		if matches == nil {
+			continue
+		}
+		class := DedupClass{Name: matches[1]}
+		for j, value := range []*uint64{&class.Entries, &class.EntrySizeOnDisk, &class.EntrySizeInCore} {
+			v, err := parseNiceNumber(matches[j+2])
+			if err != nil {
+				return DedupStats{}, err
+			}
+			*value = v
+		}
+		stats.Classes = append(stats.Classes, class)
+	}
+
+	inHistogram := false
+	for _, line := range lines[summary+1:] {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			if inHistogram {
+				break
+			}
+			continue
+		}
+		if !inHistogram {
+			inHistogram = strings.HasPrefix(fields[0], `---`)
+			continue
+		}
+		if fields[0] == `Total` {
+			break
+		}
+		if len(fields) != 9 {
+			return DedupStats{}, ErrInvalidOutput
+		}
+		values := make([]uint64, len(fields))
+		for i, field := range fields {
+			v, err := parseNiceNumber(field)
+			if err != nil {
+				return DedupStats{}, err
+			}
+			values[i] = v
+		}
+		stats.Histogram = append(stats.Histogram, DedupBucket{
+			RefCount:   values[0],
+			Allocated:  DedupBlocks{Blocks: values[1], LogicalSize: values[2], PhysicalSize: values[3], AllocatedSize: values[4]},
+			Referenced: DedupBlocks{Blocks: values[5], LogicalSize: values[6], PhysicalSize: values[7], AllocatedSize: values[8]},
+		})
+	}
+
+	return stats, nil
+}
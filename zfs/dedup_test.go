@@ -0,0 +1,137 @@
+package zfs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDedupStatsParse(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected DedupStats
+		err      error
+	}{
+		{
+			name: `parsable`,
+			input: `  pool: testpool
+ state: ONLINE
+config:
+
+	NAME        STATE     READ WRITE CKSUM
+	testpool    ONLINE       0     0     0
+	  sda       ONLINE       0     0     0
+
+errors: No known data errors
+
+ dedup: DDT entries 2435, size 1234 on disk, 320 in core
+
+DDT-sha256-zap-duplicate: 198 entries, size 1102 on disk, 356 in core
+DDT-sha256-zap-unique: 2237 entries, size 1245 on disk, 317 in core
+
+bucket              allocated                       referenced
+______   ______________________________   ______________________________
+refcnt   blocks   LSIZE   PSIZE   DSIZE   blocks   LSIZE   PSIZE   DSIZE
+------   ------   -----   -----   -----   ------   -----   -----   -----
+     1     2237  297795584  297795584  297795584     2237  297795584  297795584  297795584
+     2      198  26004685  26004685  26004685      396  51904512  51904512  51904512
+ Total     2435  323800269  323800269  323800269     2633  349700096  349700096  349700096
+`,
+			expected: DedupStats{
+				Entries:         2435,
+				EntrySizeOnDisk: 1234,
+				EntrySizeInCore: 320,
+				Classes: []DedupClass{
+					{Name: `DDT-sha256-zap-duplicate`, Entries: 198, EntrySizeOnDisk: 1102, EntrySizeInCore: 356},
+					{Name: `DDT-sha256-zap-unique`, Entries: 2237, EntrySizeOnDisk: 1245, EntrySizeInCore: 317},
+				},
+				Histogram: []DedupBucket{
+					{
+						RefCount:   1,
+						Allocated:  DedupBlocks{Blocks: 2237, LogicalSize: 297795584, PhysicalSize: 297795584, AllocatedSize: 297795584},
+						Referenced: DedupBlocks{Blocks: 2237, LogicalSize: 297795584, PhysicalSize: 297795584, AllocatedSize: 297795584},
+					},
+					{
+						RefCount:   2,
+						Allocated:  DedupBlocks{Blocks: 198, LogicalSize: 26004685, PhysicalSize: 26004685, AllocatedSize: 26004685},
+						Referenced: DedupBlocks{Blocks: 396, LogicalSize: 51904512, PhysicalSize: 51904512, AllocatedSize: 51904512},
+					},
+				},
+			},
+		},
+		{
+			name: `human readable`,
+			input: `errors: No known data errors
+
+ dedup: DDT entries 2435, size 1.5K on disk, 320B in core
+
+DDT-sha256-zap-unique: 2.38K entries, size 1.5K on disk, 320B in core
+
+bucket              allocated                       referenced
+______   ______________________________   ______________________________
+refcnt   blocks   LSIZE   PSIZE   DSIZE   blocks   LSIZE   PSIZE   DSIZE
+------   ------   -----   -----   -----   ------   -----   -----   -----
+    1K        2    256K    128K    128K    2.50K    320M    160M    160M
+ Total        2    256K    128K    128K    2.50K    320M    160M    160M
+`,
+			expected: DedupStats{
+				Entries:         2435,
+				EntrySizeOnDisk: 1536,
+				EntrySizeInCore: 320,
+				Classes: []DedupClass{
+					{Name: `DDT-sha256-zap-unique`, Entries: 2437, EntrySizeOnDisk: 1536, EntrySizeInCore: 320},
+				},
+				Histogram: []DedupBucket{
+					{
+						RefCount:   1024,
+						Allocated:  DedupBlocks{Blocks: 2, LogicalSize: 262144, PhysicalSize: 131072, AllocatedSize: 131072},
+						Referenced: DedupBlocks{Blocks: 2560, LogicalSize: 335544320, PhysicalSize: 167772160, AllocatedSize: 167772160},
+					},
+				},
+			},
+		},
+		{
+			name: `no entries`,
+			input: `errors: No known data errors
+
+ dedup: no DDT entries
+`,
+			expected: DedupStats{},
+		},
+		{
+			name: `missing summary`,
+			input: `errors: No known data errors
+`,
+			err: ErrInvalidOutput,
+		},
+		{
+			name: `invalid histogram`,
+			input: ` dedup: DDT entries 2435, size 1234 on disk, 320 in core
+
+refcnt   blocks   LSIZE   PSIZE   DSIZE   blocks   LSIZE   PSIZE   DSIZE
+------   ------   -----   -----   -----   ------   -----   -----   -----
+     1     2237  297795584  297795584
+`,
+			err: ErrInvalidOutput,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			stats, err := parseDedupStats(strings.Split(tc.input, "\n"))
+			if err != tc.err {
+				t.Fatalf("Expected error %v, got %v", tc.err, err)
+			}
+			if tc.err != nil {
+				return
+			}
+			if diff := cmp.Diff(stats, tc.expected); diff != `` {
+				t.Fatalf("Parsed dedup stats are not equal to expected stats: %s", diff)
+			}
+		})
+	}
+}
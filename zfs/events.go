@@ -0,0 +1,160 @@
+package zfs
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event holds a single event from the ZFS event log, as reported by `zpool events -v`
+type Event struct {
+	// Class holds the full event class (eg - ereport.fs.zfs.checksum)
+	Class string
+	// EID holds the event identifier, which increases monotonically until the ZFS module is reloaded
+	EID  uint64
+	Time time.Time
+	// Pool holds the name of the pool the event relates to, if any
+	Pool string
+	// Vdev holds the path of the vdev the event relates to if known, otherwise the vdev GUID, if any
+	Vdev string
+}
+
+// followEvents runs `zpool events -f`, calling the handler for each event until the context is done or the command
+// exits. Events already in the kernel event log are delivered first.
+func followEvents(ctx context.Context, handler func(Event)) error {
+	cmd := newCommand(ctx, `zpool`, `events`, `-f`, `-H`, `-v`)
+	defer cmd.close()
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err = cmd.Start(); err != nil {
+		return err
+	}
+	if err = parseEvents(out, handler); err != nil {
+		return err
+	}
+
+	return cmd.Wait()
+}
+
+// parseEvents reads verbose event output, calling the handler as each event is completed.
+//
+// Example string to parse:
+//
+//	Oct 16 2026 10:00:00.123456789	ereport.fs.zfs.checksum
+//	        class = "ereport.fs.zfs.checksum"
+//	        ena = 0x2f3b1a4b5c00001
+//	        detector = (embedded nvlist)
+//	                version = 0x0
+//	                scheme = "zfs"
+//	                pool = 0x8c2c2d1f6b0e5a21
+//	                vdev = 0x5e1c0d6bd4a0f0a2
+//	        (end detector)
+//	        pool = "tank"
+//	        pool_guid = 0x8c2c2d1f6b0e5a21
+//	        vdev_guid = 0x5e1c0d6bd4a0f0a2
+//	        vdev_type = "disk"
+//	        vdev_path = "/dev/sda1"
+//	        time = 0x6531a5a0 0x75bcd15
+//	        eid = 0x2a
+func parseEvents(r io.Reader, handler func(Event)) error {
+	var (
+		event  *Event
+		guid   string
+		nested int
+	)
+	flush := func() {
+		if event == nil {
+			return
+		}
+		if event.Vdev == `` {
+			event.Vdev = guid
+		}
+		handler(*event)
+		event, guid, nested = nil, ``, 0
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == `` {
+			flush()
+			continue
+		}
+		if line[0] != ' ' && line[0] != '\t' {
+			// Header line, the time and class of a new event.
+			flush()
+			fields := strings.Split(line, "\t")
+			event = &Event{Class: strings.TrimSpace(fields[len(fields)-1])}
+			continue
+		}
+		if event == nil {
+			continue
+		}
+
+		// Embedded nvlists hold details of other objects (ie - the detector), which must not override the
+		// properties of the event itself.
+		if strings.HasPrefix(trimmed, `(start `) {
+			nested++
+			continue
+		}
+		if strings.HasPrefix(trimmed, `(end `) {
+			if nested > 0 {
+				nested--
+			}
+			continue
+		}
+		parts := strings.SplitN(trimmed, ` = `, 2)
+		if len(parts) != 2 {
+			continue
+		}
+		name, value := parts[0], parts[1]
+		if value == `(embedded nvlist)` {
+			nested++
+			continue
+		}
+		if nested > 0 {
+			continue
+		}
+
+		switch name {
+		case `class`:
+			event.Class = strings.Trim(value, `"`)
+		case `pool`:
+			event.Pool = strings.Trim(value, `"`)
+		case `vdev_path`:
+			event.Vdev = strings.Trim(value, `"`)
+		case `vdev_guid`:
+			guid = value
+		case `eid`:
+			if eid, err := strconv.ParseUint(value, 0, 64); err == nil {
+				event.EID = eid
+			}
+		case `time`:
+			fields := strings.Fields(value)
+			if len(fields) != 2 {
+				continue
+			}
+			sec, err := strconv.ParseInt(fields[0], 0, 64)
+			if err != nil {
+				continue
+			}
+			nsec, err := strconv.ParseInt(fields[1], 0, 64)
+			if err != nil {
+				continue
+			}
+			event.Time = time.Unix(sec, nsec)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	flush()
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package zfs
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseEvents(t *testing.T) {
+	testCases := []struct {
+		name     string
+		output   string
+		expected []Event
+	}{
+		{
+			name: `vdev events`,
+			output: "Oct 16 2026 10:00:00.123456789\tereport.fs.zfs.checksum\n" +
+				"        class = \"ereport.fs.zfs.checksum\"\n" +
+				"        ena = 0x2f3b1a4b5c00001\n" +
+				"        detector = (embedded nvlist)\n" +
+				"                version = 0x0\n" +
+				"                scheme = \"zfs\"\n" +
+				"                pool = 0x8c2c2d1f6b0e5a21\n" +
+				"                vdev = 0x5e1c0d6bd4a0f0a2\n" +
+				"        (end detector)\n" +
+				"        pool = \"tank\"\n" +
+				"        pool_guid = 0x8c2c2d1f6b0e5a21\n" +
+				"        vdev_guid = 0x5e1c0d6bd4a0f0a2\n" +
+				"        vdev_type = \"disk\"\n" +
+				"        vdev_path = \"/dev/sda1\"\n" +
+				"        time = 0x6531a5a0 0x75bcd15\n" +
+				"        eid = 0x2a\n" +
+				"\n" +
+				"Oct 16 2026 10:00:01.000000000\tresource.fs.zfs.statechange\n" +
+				"        class = \"resource.fs.zfs.statechange\"\n" +
+				"        pool = \"tank\"\n" +
+				"        vdev_guid = 0x1234\n" +
+				"        vdev_state = \"FAULTED\" (0x5)\n" +
+				"        time = 0x6531a5a1 0x0\n" +
+				"        eid = 0x2b\n" +
+				"\n",
+			expected: []Event{
+				{
+					Class: `ereport.fs.zfs.checksum`,
+					EID:   42,
+					Time:  time.Unix(0x6531a5a0, 0x75bcd15),
+					Pool:  `tank`,
+					Vdev:  `/dev/sda1`,
+				},
+				{
+					Class: `resource.fs.zfs.statechange`,
+					EID:   43,
+					Time:  time.Unix(0x6531a5a1, 0),
+					Pool:  `tank`,
+					Vdev:  `0x1234`,
+				},
+			},
+		},
+		{
+			name: `pool events without trailing blank line`,
+			output: "Oct 16 2026 10:00:00.000000000\tsysevent.fs.zfs.scrub_finish\n" +
+				"        class = \"sysevent.fs.zfs.scrub_finish\"\n" +
+				"        pool = \"tank\"\n" +
+				"        eid = 0x2c\n" +
+				"Oct 16 2026 10:00:01.000000000\tsysevent.fs.zfs.config_sync\n" +
+				"        class = \"sysevent.fs.zfs.config_sync\"\n" +
+				"        eid = 0x2d\n",
+			expected: []Event{
+				{
+					Class: `sysevent.fs.zfs.scrub_finish`,
+					EID:   44,
+					Pool:  `tank`,
+				},
+				{
+					Class: `sysevent.fs.zfs.config_sync`,
+					EID:   45,
+				},
+			},
+		},
+		{
+			name: `array of nvlists`,
+			output: "Oct 16 2026 10:00:00.000000000\tsysevent.fs.zfs.vdev_attach\n" +
+				"        class = \"sysevent.fs.zfs.vdev_attach\"\n" +
+				"        children = (array of embedded nvlists)\n" +
+				"        (start children[0])\n" +
+				"                pool = \"other\"\n" +
+				"        (end children[0])\n" +
+				"        pool = \"tank\"\n" +
+				"        eid = 0x2e\n",
+			expected: []Event{
+				{
+					Class: `sysevent.fs.zfs.vdev_attach`,
+					EID:   46,
+					Pool:  `tank`,
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var events []Event
+			if err := parseEvents(strings.NewReader(tc.output), func(e Event) { events = append(events, e) }); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(events, tc.expected); diff != `` {
+				t.Fatalf("Parsed events are not equal to expected events: %s", diff)
+			}
+		})
+	}
+}
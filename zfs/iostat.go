@@ -0,0 +1,131 @@
+package zfs
+
+import (
+	"bufio"
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LatencyHistogram holds the I/O latency histograms for a pool or vdev, as reported by `zpool iostat -w`
+type LatencyHistogram struct {
+	// Vdev is the name of the vdev, or the pool name for the root vdev
+	Vdev string
+	// Buckets holds the inclusive upper bound of each histogram bucket
+	Buckets []time.Duration
+	// Counts holds the number of I/Os in each bucket, keyed by column name (eg - total_wait_read, scrub)
+	Counts map[string][]uint64
+}
+
+var (
+	// latencyTopLabels are the column groups, since headers are not reported in scripted mode
+	latencyTopLabels = []string{`total_wait`, `disk_wait`, `syncq_wait`, `asyncq_wait`}
+	// latencyBottomLabels are the columns beneath each group, later releases append trim and rebuild
+	latencyBottomLabels = []string{`read`, `write`, `read`, `write`, `read`, `write`, `read`, `write`, `scrub`, `trim`, `rebuild`}
+)
+
+func (p poolImpl) LatencyHistograms(ctx context.Context, vdevs bool) ([]LatencyHistogram, error) {
+	args := []string{`iostat`, `-wpH`}
+	if vdevs {
+		args = append(args, `-v`)
+	}
+	cmd := newCommand(ctx, `zpool`, append(args, p.name)...)
+	defer cmd.close()
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(out)
+
+	if err = cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	lines := make([]string, 0)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err = cmd.Wait(); err != nil {
+		return nil, err
+	}
+
+	return parseLatencyHistograms(p.name, lines)
+}
+
+// Example string to parse, scripted mode omits the column headers and separators, leaving the name of each vdev on
+// its own line, followed by a tab-separated row for each bucket:
+//
+//	tank
+//	1	0	0	0	0	0	0	0	0	0	0	0
+//	3	0	0	0	0	0	0	0	0	0	0	0
+//	...
+//	137438953471	0	0	0	0	0	0	0	0	0	0	0
+//	sda
+//	1	0	0	0	0	0	0	0	0	0	0	0
+//	...
+func parseLatencyHistograms(pool string, lines []string) ([]LatencyHistogram, error) {
+	result := make([]LatencyHistogram, 0)
+	var (
+		current *LatencyHistogram
+		columns []string
+	)
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		// A new section begins with the vdev name, on a line of its own.
+		if len(fields) == 1 {
+			result = append(result, LatencyHistogram{Vdev: fields[0], Counts: make(map[string][]uint64)})
+			current = &result[len(result)-1]
+			continue
+		}
+
+		// Any other line must be a bucket, headers are never output in scripted mode.
+		bound, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			return nil, ErrInvalidOutput
+		}
+		if current == nil {
+			// Output without section names only includes the root vdev.
+			result = append(result, LatencyHistogram{Vdev: pool, Counts: make(map[string][]uint64)})
+			current = &result[len(result)-1]
+		}
+		values := fields[1:]
+		if columns == nil {
+			if len(values) > len(latencyBottomLabels) {
+				return nil, ErrInvalidOutput
+			}
+			columns = latencyColumns(latencyTopLabels, latencyBottomLabels[:len(values)])
+		}
+		if len(values) != len(columns) {
+			return nil, ErrInvalidOutput
+		}
+		current.Buckets = append(current.Buckets, time.Duration(bound))
+		for i, column := range columns {
+			v, err := strconv.ParseUint(values[i], 10, 64)
+			if err != nil {
+				return nil, ErrInvalidOutput
+			}
+			current.Counts[column] = append(current.Counts[column], v)
+		}
+	}
+
+	return result, nil
+}
+
+// latencyColumns combines the group labels with the read/write labels beneath them, trailing labels without a group
+// are used as-is.
+func latencyColumns(top, bottom []string) []string {
+	result := make([]string, len(bottom))
+	for i, label := range bottom {
+		if i/2 < len(top) {
+			result[i] = top[i/2] + `_` + label
+			continue
+		}
+		result[i] = label
+	}
+	return result
+}
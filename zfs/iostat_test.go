@@ -0,0 +1,181 @@
+package zfs
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLatencyHistogramsParse(t *testing.T) {
+	input, err := os.ReadFile(`testdata/zpool_iostat_w.txt`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	histograms, err := parseLatencyHistograms(`testpool`, strings.Split(string(input), "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(histograms) != 3 {
+		t.Fatalf("Expected exactly 3 histograms, got %d", len(histograms))
+	}
+	for i, expected := range []string{`testpool`, `mirror-0`, `sda`} {
+		if histograms[i].Vdev != expected {
+			t.Fatalf("Expected vdev %s, got %s", expected, histograms[i].Vdev)
+		}
+		if len(histograms[i].Buckets) != 37 {
+			t.Fatalf("Expected exactly 37 buckets for %s, got %d", expected, len(histograms[i].Buckets))
+		}
+	}
+
+	pool := histograms[0]
+	if pool.Buckets[0] != time.Nanosecond || pool.Buckets[36] != 137438953471*time.Nanosecond {
+		t.Fatalf("Unexpected bucket bounds: %s - %s", pool.Buckets[0], pool.Buckets[36])
+	}
+
+	columns := make([]string, 0, len(pool.Counts))
+	for column := range pool.Counts {
+		columns = append(columns, column)
+	}
+	expectedColumns := []string{`asyncq_wait_read`, `asyncq_wait_write`, `disk_wait_read`, `disk_wait_write`, `rebuild`, `scrub`, `syncq_wait_read`, `syncq_wait_write`, `total_wait_read`, `total_wait_write`, `trim`}
+	sort.Strings(columns)
+	if diff := cmp.Diff(columns, expectedColumns); diff != `` {
+		t.Fatalf("Parsed columns are not equal to expected columns: %s", diff)
+	}
+	if pool.Counts[`total_wait_write`][11] != 21 || pool.Counts[`trim`][11] != 29 {
+		t.Fatalf("Unexpected counts in bucket %s: %v", pool.Buckets[11], pool.Counts)
+	}
+}
+
+func TestLatencyHistogramsParseScripted(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected []LatencyHistogram
+		err      error
+	}{
+		{
+			name: `without headers`,
+			input: `1	0	0	0	0	0	0	0	0	0
+3	1	2	3	4	5	6	7	8	9
+`,
+			expected: []LatencyHistogram{
+				{
+					Vdev:    `testpool`,
+					Buckets: []time.Duration{1, 3},
+					Counts: map[string][]uint64{
+						`total_wait_read`:   {0, 1},
+						`total_wait_write`:  {0, 2},
+						`disk_wait_read`:    {0, 3},
+						`disk_wait_write`:   {0, 4},
+						`syncq_wait_read`:   {0, 5},
+						`syncq_wait_write`:  {0, 6},
+						`asyncq_wait_read`:  {0, 7},
+						`asyncq_wait_write`: {0, 8},
+						`scrub`:             {0, 9},
+					},
+				},
+			},
+		},
+		{
+			name: `section names only`,
+			input: `testpool
+1	1	2	3	4	5	6	7	8	9	10	11
+sdb
+1	0	0	0	0	0	0	0	0	0	0	1
+`,
+			expected: []LatencyHistogram{
+				{
+					Vdev:    `testpool`,
+					Buckets: []time.Duration{1},
+					Counts: map[string][]uint64{
+						`total_wait_read`:   {1},
+						`total_wait_write`:  {2},
+						`disk_wait_read`:    {3},
+						`disk_wait_write`:   {4},
+						`syncq_wait_read`:   {5},
+						`syncq_wait_write`:  {6},
+						`asyncq_wait_read`:  {7},
+						`asyncq_wait_write`: {8},
+						`scrub`:             {9},
+						`trim`:              {10},
+						`rebuild`:           {11},
+					},
+				},
+				{
+					Vdev:    `sdb`,
+					Buckets: []time.Duration{1},
+					Counts: map[string][]uint64{
+						`total_wait_read`:   {0},
+						`total_wait_write`:  {0},
+						`disk_wait_read`:    {0},
+						`disk_wait_write`:   {0},
+						`syncq_wait_read`:   {0},
+						`syncq_wait_write`:  {0},
+						`asyncq_wait_read`:  {0},
+						`asyncq_wait_write`: {0},
+						`scrub`:             {0},
+						`trim`:              {0},
+						`rebuild`:           {1},
+					},
+				},
+			},
+		},
+		{
+			name: `numeric vdev name`,
+			input: `1234
+1	0	0	0	0	0	0	0	0	1
+`,
+			expected: []LatencyHistogram{
+				{
+					Vdev:    `1234`,
+					Buckets: []time.Duration{1},
+					Counts: map[string][]uint64{
+						`total_wait_read`:   {0},
+						`total_wait_write`:  {0},
+						`disk_wait_read`:    {0},
+						`disk_wait_write`:   {0},
+						`syncq_wait_read`:   {0},
+						`syncq_wait_write`:  {0},
+						`asyncq_wait_read`:  {0},
+						`asyncq_wait_write`: {0},
+						`scrub`:             {1},
+					},
+				},
+			},
+		},
+		{
+			name: `header line`,
+			input: `testpool      total_wait     disk_wait    syncq_wait   asyncq_wait
+latency      read  write   read  write   read  write   read  write  scrub
+1	0	0	0	0	0	0	0	0	0
+`,
+			err: ErrInvalidOutput,
+		},
+		{
+			name:  `invalid count`,
+			input: "1	0	0	0	0	0	0	0	0	x\n",
+			err:   ErrInvalidOutput,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			histograms, err := parseLatencyHistograms(`testpool`, strings.Split(tc.input, "\n"))
+			if err != tc.err {
+				t.Fatalf("Expected error %v, got %v", tc.err, err)
+			}
+			if tc.err != nil {
+				return
+			}
+			if diff := cmp.Diff(histograms, tc.expected); diff != `` {
+				t.Fatalf("Parsed histograms are not equal to expected output: %s", diff)
+			}
+		})
+	}
+}
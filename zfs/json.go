@@ -0,0 +1,611 @@
+package zfs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Backend enum contains the supported output formats for the zfs/zpool CLI
+type Backend string
+
+const (
+	// BackendAuto enum entry, selects BackendJSON if supported by the installed version
+	BackendAuto Backend = `auto`
+	// BackendText enum entry
+	BackendText Backend = `text`
+	// BackendJSON enum entry, requires OpenZFS 2.3 or later
+	BackendJSON Backend = `json`
+)
+
+var (
+	// jsonVdevSections maps the allocation class sections of `zpool status -j` to their allocation class
+	jsonVdevSections = []struct {
+		key   string
+		class VdevClass
+	}{
+		{key: `dedup`, class: VdevClassDedup},
+		{key: `special`, class: VdevClassSpecial},
+		{key: `logs`, class: VdevClassLog},
+		{key: `l2cache`, class: VdevClassCache},
+		{key: `spares`, class: VdevClassSpare},
+	}
+)
+
+// jsonClient uses the JSON output available from OpenZFS 2.3, falling back to clientImpl for commands that do not
+// support it.
+type jsonClient struct {
+	clientImpl
+}
+
+func (z jsonClient) Pool(name string) Pool {
+	return jsonPool{poolImpl: newPoolImpl(name, z.caps)}
+}
+
+func (z jsonClient) Datasets(pool string, kind DatasetKind) Datasets {
+	return jsonDatasets{datasetsImpl: newDatasetsImpl(pool, kind, z.caps)}
+}
+
+func (z jsonClient) VdevTrees(ctx context.Context, pools ...string) ([]VdevTree, error) {
+	var result []VdevTree
+	err := executeJSON(ctx, func(r io.Reader) error {
+		var err error
+		result, err = parseJSONVdevTrees(r)
+		return err
+	}, `zpool`, append([]string{`status`, `-jLp`}, pools...)...)
+	return result, err
+}
+
+type jsonPool struct {
+	poolImpl
+}
+
+func (p jsonPool) Properties(ctx context.Context, props ...string) (PoolProperties, error) {
+	var result *poolPropertiesImpl
+	err := executeJSON(ctx, func(r io.Reader) error {
+		var err error
+		result, err = parseJSONPoolProperties(p.name, r)
+		return err
+	}, `zpool`, `get`, `-jp`, strings.Join(props, `,`), p.name)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+type jsonDatasets struct {
+	datasetsImpl
+}
+
+func (d jsonDatasets) Properties(ctx context.Context, props ...string) ([]DatasetProperties, error) {
+	var result []DatasetProperties
+	err := executeJSON(ctx, func(r io.Reader) error {
+		var err error
+		result, err = parseJSONDatasets(d.pool, r)
+		return err
+	}, `zfs`, `get`, `-jprt`, string(d.kind), strings.Join(props, `,`), d.pool)
+	return result, err
+}
+
+func (d jsonDatasets) List(ctx context.Context, props ...string) ([]DatasetProperties, error) {
+	var result []DatasetProperties
+	err := executeJSON(ctx, func(r io.Reader) error {
+		var err error
+		result, err = parseJSONDatasets(d.pool, r)
+		return err
+	}, `zfs`, `list`, `-jprt`, string(d.kind), `-o`, strings.Join(props, `,`), d.pool)
+	return result, err
+}
+
+// autoClient selects a backend on first use, based on whether the installed version of the userland tools supports
+// JSON output.
+type autoClient struct {
+	text     Client
+	json     Client
+	caps     *capabilityProbe
+	detected Client
+	sync.Mutex
+}
+
+func (z *autoClient) backend(ctx context.Context) Client {
+	z.Lock()
+	defer z.Unlock()
+	if z.detected != nil {
+		return z.detected
+	}
+
+	caps, err := z.caps.get(ctx)
+	// Don't record the result of detection that was interrupted, so that it will be retried.
+	if err != nil {
+		return z.text
+	}
+	z.detected = z.text
+	if caps.JSON {
+		z.detected = z.json
+	}
+	return z.detected
+}
+
+func (z *autoClient) PoolNames(ctx context.Context) ([]string, error) {
+	return z.backend(ctx).PoolNames(ctx)
+}
+
+func (z *autoClient) Pool(name string) Pool {
+	return autoPool{client: z, name: name}
+}
+
+func (z *autoClient) VdevTrees(ctx context.Context, pools ...string) ([]VdevTree, error) {
+	return z.backend(ctx).VdevTrees(ctx, pools...)
+}
+
+func (z *autoClient) Datasets(pool string, kind DatasetKind) Datasets {
+	return autoDatasets{client: z, pool: pool, kind: kind}
+}
+
+func (z *autoClient) Kstat(ctx context.Context, module, name string) (Kstat, error) {
+	return z.text.Kstat(ctx, module, name)
+}
+
+func (z *autoClient) ObjsetKstats(ctx context.Context, pool string) ([]Kstat, error) {
+	return z.text.ObjsetKstats(ctx, pool)
+}
+
+func (z *autoClient) Txgs(ctx context.Context, pool string) ([]Txg, error) {
+	return z.text.Txgs(ctx, pool)
+}
+
+func (z *autoClient) SendSize(ctx context.Context, from, to string) (uint64, error) {
+	return z.text.SendSize(ctx, from, to)
+}
+
+func (z *autoClient) UserSpace(ctx context.Context, dataset string) ([]Space, error) {
+	return z.text.UserSpace(ctx, dataset)
+}
+
+func (z *autoClient) GroupSpace(ctx context.Context, dataset string) ([]Space, error) {
+	return z.text.GroupSpace(ctx, dataset)
+}
+
+func (z *autoClient) ProjectSpace(ctx context.Context, dataset string) ([]Space, error) {
+	return z.text.ProjectSpace(ctx, dataset)
+}
+
+func (z *autoClient) Events(ctx context.Context, handler func(Event)) error {
+	return z.text.Events(ctx, handler)
+}
+
+func (z *autoClient) ModuleParameters(ctx context.Context) ([]ModuleParameter, error) {
+	return z.text.ModuleParameters(ctx)
+}
+
+func (z *autoClient) Version(ctx context.Context) (Version, error) {
+	return z.text.Version(ctx)
+}
+
+type autoPool struct {
+	client *autoClient
+	name   string
+}
+
+func (p autoPool) Name() string {
+	return p.name
+}
+
+func (p autoPool) Properties(ctx context.Context, props ...string) (PoolProperties, error) {
+	return p.client.backend(ctx).Pool(p.name).Properties(ctx, props...)
+}
+
+func (p autoPool) LatencyHistograms(ctx context.Context, vdevs bool) ([]LatencyHistogram, error) {
+	return p.client.backend(ctx).Pool(p.name).LatencyHistograms(ctx, vdevs)
+}
+
+func (p autoPool) Dedup(ctx context.Context) (DedupStats, error) {
+	return p.client.backend(ctx).Pool(p.name).Dedup(ctx)
+}
+
+type autoDatasets struct {
+	client *autoClient
+	pool   string
+	kind   DatasetKind
+}
+
+func (d autoDatasets) Pool() string {
+	return d.pool
+}
+
+func (d autoDatasets) Kind() DatasetKind {
+	return d.kind
+}
+
+func (d autoDatasets) Properties(ctx context.Context, props ...string) ([]DatasetProperties, error) {
+	return d.client.backend(ctx).Datasets(d.pool, d.kind).Properties(ctx, props...)
+}
+
+func (d autoDatasets) List(ctx context.Context, props ...string) ([]DatasetProperties, error) {
+	return d.client.backend(ctx).Datasets(d.pool, d.kind).List(ctx, props...)
+}
+
+// jsonValue accepts both the string values output by default, and the numeric values output with `--json-int`.
+type jsonValue string
+
+func (v *jsonValue) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*v = jsonValue(s)
+		return nil
+	}
+	*v = jsonValue(data)
+	return nil
+}
+
+// jsonScanFunctions maps the scan functions of `zpool status -j` to their ScanFunction
+var jsonScanFunctions = map[string]ScanFunction{
+	`SCRUB`:    ScanScrub,
+	`RESILVER`: ScanResilver,
+}
+
+type jsonProperties map[string]struct {
+	Value jsonValue `json:"value"`
+}
+
+type jsonVdev struct {
+	Name           string    `json:"name"`
+	State          string    `json:"state"`
+	ReadErrors     jsonValue `json:"read_errors"`
+	WriteErrors    jsonValue `json:"write_errors"`
+	ChecksumErrors jsonValue `json:"checksum_errors"`
+	Vdevs          jsonVdevs `json:"vdevs"`
+}
+
+type jsonPoolScan struct {
+	Function   string    `json:"function"`
+	State      string    `json:"state"`
+	StartTime  jsonValue `json:"start_time"`
+	EndTime    jsonValue `json:"end_time"`
+	ToExamine  jsonValue `json:"to_examine"`
+	Examined   jsonValue `json:"examined"`
+	Issued     jsonValue `json:"issued"`
+	Processed  jsonValue `json:"processed"`
+	Errors     jsonValue `json:"errors"`
+	ScrubPause jsonValue `json:"scrub_pause"`
+}
+
+// jsonVdevs decodes a JSON object of vdevs, preserving the order of the vdevs in the output.
+type jsonVdevs []jsonVdev
+
+func (v *jsonVdevs) UnmarshalJSON(data []byte) error {
+	return decodeJSONObject(data, func(_ string, value json.RawMessage) error {
+		var vdev jsonVdev
+		if err := json.Unmarshal(value, &vdev); err != nil {
+			return err
+		}
+		*v = append(*v, vdev)
+		return nil
+	})
+}
+
+// decodeJSONObject calls fn for each member of a JSON object, in order.
+func decodeJSONObject(data []byte, fn func(key string, value json.RawMessage) error) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return ErrInvalidOutput
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return ErrInvalidOutput
+		}
+		var value json.RawMessage
+		if err = dec.Decode(&value); err != nil {
+			return err
+		}
+		if err = fn(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func executeJSON(ctx context.Context, parse func(r io.Reader) error, cmd string, args ...string) error {
+	c := newCommand(ctx, cmd, args...)
+	defer c.close()
+	out, err := c.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err = c.Start(); err != nil {
+		return err
+	}
+
+	parseErr := parse(out)
+	// Drain any remaining output, so that the command does not block on a full pipe.
+	_, _ = io.Copy(io.Discard, out)
+	if err = c.Wait(); err != nil {
+		return err
+	}
+	return parseErr
+}
+
+// Example output to parse:
+//
+//	{
+//	  "output_version": {"command": "zpool get", "vers_major": 0, "vers_minor": 1},
+//	  "pools": {
+//	    "tank": {
+//	      "name": "tank",
+//	      "properties": {
+//	        "size": {"value": "10737418240", "source": {"type": "NONE", "data": "-"}}
+//	      }
+//	    }
+//	  }
+//	}
+func parseJSONPoolProperties(pool string, r io.Reader) (*poolPropertiesImpl, error) {
+	var v struct {
+		Pools map[string]struct {
+			Properties jsonProperties `json:"properties"`
+		} `json:"pools"`
+	}
+	if err := json.NewDecoder(r).Decode(&v); err != nil {
+		return nil, ErrInvalidOutput
+	}
+	p, ok := v.Pools[pool]
+	if !ok || len(v.Pools) != 1 {
+		return nil, ErrInvalidOutput
+	}
+
+	result := newPoolPropertiesImpl()
+	for k, prop := range p.Properties {
+		result.properties[k] = string(prop.Value)
+	}
+	return result, nil
+}
+
+// Example output to parse:
+//
+//	{
+//	  "output_version": {"command": "zfs get", "vers_major": 0, "vers_minor": 1},
+//	  "datasets": {
+//	    "tank/fs": {
+//	      "name": "tank/fs",
+//	      "type": "FILESYSTEM",
+//	      "pool": "tank",
+//	      "properties": {
+//	        "used": {"value": "24576", "source": {"type": "NONE", "data": "-"}}
+//	      }
+//	    }
+//	  }
+//	}
+func parseJSONDatasets(pool string, r io.Reader) ([]DatasetProperties, error) {
+	var v struct {
+		Datasets map[string]struct {
+			Name       string         `json:"name"`
+			Properties jsonProperties `json:"properties"`
+		} `json:"datasets"`
+	}
+	if err := json.NewDecoder(r).Decode(&v); err != nil {
+		return nil, ErrInvalidOutput
+	}
+
+	handler := newDatasetHandler()
+	for _, dataset := range v.Datasets {
+		for k, prop := range dataset.Properties {
+			if err := handler.processLine(pool, []string{dataset.Name, k, string(prop.Value)}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return handler.datasets(), nil
+}
+
+// Example output to parse, top-level vdevs of allocation classes other than normal are listed under their own keys:
+//
+//	{
+//	  "output_version": {"command": "zpool status", "vers_major": 0, "vers_minor": 1},
+//	  "pools": {
+//	    "tank": {
+//	      "name": "tank",
+//	      "state": "ONLINE",
+//	      "vdevs": {
+//	        "tank": {
+//	          "name": "tank",
+//	          "vdev_type": "root",
+//	          "state": "ONLINE",
+//	          "read_errors": "0",
+//	          "write_errors": "0",
+//	          "checksum_errors": "0",
+//	          "vdevs": {
+//	            "mirror-0": {...}
+//	          }
+//	        }
+//	      },
+//	      "logs": {
+//	        "sdd": {...}
+//	      },
+//	      "scan_stats": {
+//	        "function": "SCRUB",
+//	        "state": "FINISHED",
+//	        "start_time": "1660436642",
+//	        ...
+//	      }
+//	    }
+//	  }
+//	}
+func parseJSONVdevTrees(r io.Reader) ([]VdevTree, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var v struct {
+		Pools json.RawMessage `json:"pools"`
+	}
+	if err = json.Unmarshal(data, &v); err != nil || v.Pools == nil {
+		return nil, ErrInvalidOutput
+	}
+
+	result := make([]VdevTree, 0)
+	err = decodeJSONObject(v.Pools, func(name string, value json.RawMessage) error {
+		var members map[string]json.RawMessage
+		if err := json.Unmarshal(value, &members); err != nil {
+			return err
+		}
+		var root jsonVdevs
+		if err := json.Unmarshal(members[`vdevs`], &root); err != nil || len(root) != 1 {
+			return ErrInvalidOutput
+		}
+
+		tree := VdevTree{Pool: name, Root: *newJSONVdev(root[0], VdevClassNormal)}
+		tree.Scan, tree.ScanErr = parseJSONPoolScan(members[`scan_stats`], time.Local)
+		for _, section := range jsonVdevSections {
+			raw, ok := members[section.key]
+			if !ok {
+				continue
+			}
+			var vdevs jsonVdevs
+			if err := json.Unmarshal(raw, &vdevs); err != nil {
+				return err
+			}
+			for _, vdev := range vdevs {
+				tree.Root.Children = append(tree.Root.Children, newJSONVdev(vdev, section.class))
+			}
+		}
+		result = append(result, tree)
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrInvalidOutput) {
+			return nil, err
+		}
+		return nil, ErrInvalidOutput
+	}
+
+	return result, nil
+}
+
+// parseJSONPoolScan parses the scan_stats of a pool, which are absent if the pool has never been scanned.
+func parseJSONPoolScan(raw json.RawMessage, loc *time.Location) (PoolScan, error) {
+	result := PoolScan{Function: ScanNone, State: ScanStateNone}
+	if raw == nil {
+		return result, nil
+	}
+	var v jsonPoolScan
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return result, ErrInvalidOutput
+	}
+	if v.State == `NONE` {
+		return result, nil
+	}
+	function, ok := jsonScanFunctions[v.Function]
+	if !ok {
+		return result, ErrInvalidOutput
+	}
+	result.Function = function
+
+	var err error
+	switch v.State {
+	case `FINISHED`:
+		result.State = ScanStateFinished
+		result.Progress = 1
+		if result.Start, err = parseJSONScanTime(v.StartTime, loc); err != nil {
+			return result, err
+		}
+		if result.End, err = parseJSONScanTime(v.EndTime, loc); err != nil {
+			return result, err
+		}
+		if result.Repaired, err = parseNiceNumber(string(v.Processed)); err != nil {
+			return result, err
+		}
+		result.Errors, err = parseNiceNumber(string(v.Errors))
+		return result, err
+	case `CANCELED`:
+		result.State = ScanStateCanceled
+		result.End, err = parseJSONScanTime(v.EndTime, loc)
+		return result, err
+	case `SCANNING`:
+		result.State = ScanStateInProgress
+		if pause := string(v.ScrubPause); pause != `` && pause != `-` && pause != `0` {
+			result.State = ScanStatePaused
+		}
+	default:
+		return result, ErrInvalidOutput
+	}
+
+	if result.Start, err = parseJSONScanTime(v.StartTime, loc); err != nil {
+		return result, err
+	}
+	for _, count := range []struct {
+		value jsonValue
+		dest  *uint64
+	}{
+		{value: v.Examined, dest: &result.Scanned},
+		{value: v.Issued, dest: &result.Issued},
+		{value: v.ToExamine, dest: &result.Total},
+		{value: v.Processed, dest: &result.Repaired},
+	} {
+		if *count.dest, err = parseNiceNumber(string(count.value)); err != nil {
+			return result, err
+		}
+	}
+	if result.Total > 0 {
+		result.Progress = float64(result.Issued) / float64(result.Total)
+	}
+
+	return result, nil
+}
+
+// parseJSONScanTime handles both the epoch seconds output with `-p`, and the formatted time output otherwise.
+func parseJSONScanTime(value jsonValue, loc *time.Location) (time.Time, error) {
+	if v, err := strconv.ParseInt(string(value), 10, 64); err == nil {
+		if v == 0 {
+			return time.Time{}, nil
+		}
+		return time.Unix(v, 0).In(loc), nil
+	}
+	return parseScanTime(string(value), loc)
+}
+
+func newJSONVdev(v jsonVdev, class VdevClass) *Vdev {
+	vdev := &Vdev{Name: v.Name, State: v.State, Class: class}
+	if class != VdevClassSpare {
+		for _, count := range []struct {
+			value jsonValue
+			dest  *uint64
+		}{
+			{value: v.ReadErrors, dest: &vdev.ReadErrors},
+			{value: v.WriteErrors, dest: &vdev.WriteErrors},
+			{value: v.ChecksumErrors, dest: &vdev.ChecksumErrors},
+		} {
+			n, err := parseNiceNumber(string(count.value))
+			if err != nil {
+				vdev.ReadErrors, vdev.WriteErrors, vdev.ChecksumErrors = 0, 0, 0
+				vdev.Err = err
+				break
+			}
+			*count.dest = n
+		}
+	}
+	for _, child := range v.Vdevs {
+		vdev.Children = append(vdev.Children, newJSONVdev(child, class))
+	}
+	return vdev
+}
@@ -0,0 +1,240 @@
+package zfs
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestJSONVdevTreesParse(t *testing.T) {
+	f, err := os.Open(`testdata/zpool_status.json`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	trees, err := parseJSONVdevTrees(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedOutput := []VdevTree{
+		{
+			Pool: `tank`,
+			Root: Vdev{
+				Name: `tank`, State: `DEGRADED`, Class: VdevClassNormal,
+				Children: []*Vdev{
+					{
+						Name: `mirror-0`, State: `DEGRADED`, Class: VdevClassNormal,
+						Children: []*Vdev{
+							{Name: `sdb`, State: `ONLINE`, Class: VdevClassNormal},
+							{Name: `sda`, State: `FAULTED`, Class: VdevClassNormal, ReadErrors: 1234, ChecksumErrors: 12},
+						},
+					},
+					{Name: `sdc`, State: `ONLINE`, Class: VdevClassLog, WriteErrors: 3},
+					{Name: `sdd`, State: `ONLINE`, Class: VdevClassCache},
+					{Name: `sde`, State: `AVAIL`, Class: VdevClassSpare},
+				},
+			},
+			Scan: PoolScan{
+				Function: ScanScrub,
+				State:    ScanStateFinished,
+				Start:    time.Unix(1660436642, 0),
+				End:      time.Unix(1660446534, 0),
+				Repaired: 4096,
+				Progress: 1,
+			},
+		},
+		{
+			Pool: `bpool`,
+			Root: Vdev{
+				Name: `bpool`, State: `ONLINE`, Class: VdevClassNormal,
+				Children: []*Vdev{
+					{Name: `sdf`, State: `ONLINE`, Class: VdevClassNormal, Err: ErrInvalidOutput},
+				},
+			},
+			Scan: PoolScan{
+				Function: ScanResilver,
+				State:    ScanStateInProgress,
+				Start:    time.Unix(1660557651, 0),
+				Scanned:  1500,
+				Issued:   800,
+				Total:    2000,
+				Repaired: 100,
+				Progress: 0.4,
+			},
+		},
+	}
+
+	if diff := cmp.Diff(trees, expectedOutput, cmpopts.EquateErrors()); diff != `` {
+		t.Fatalf("Parsed vdev trees are not equal to expected output: %s", diff)
+	}
+}
+
+func TestJSONPoolScanParse(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected PoolScan
+		err      error
+	}{
+		{
+			name:     `never scanned`,
+			expected: PoolScan{Function: ScanNone, State: ScanStateNone},
+		},
+		{
+			name:     `none`,
+			input:    `{"function": "NONE", "state": "NONE"}`,
+			expected: PoolScan{Function: ScanNone, State: ScanStateNone},
+		},
+		{
+			name: `scrub paused`,
+			input: `{"function": "SCRUB", "state": "SCANNING", "start_time": "1660557651", "to_examine": "2000",
+				"examined": "1000", "issued": "500", "processed": "0", "errors": "0", "scrub_pause": "1660560000"}`,
+			expected: PoolScan{
+				Function: ScanScrub,
+				State:    ScanStatePaused,
+				Start:    time.Unix(1660557651, 0),
+				Scanned:  1000,
+				Issued:   500,
+				Total:    2000,
+				Progress: 0.25,
+			},
+		},
+		{
+			name:  `scrub canceled`,
+			input: `{"function": "SCRUB", "state": "CANCELED", "start_time": "1660557651", "end_time": "1660560000"}`,
+			expected: PoolScan{
+				Function: ScanScrub,
+				State:    ScanStateCanceled,
+				End:      time.Unix(1660560000, 0),
+			},
+		},
+		{
+			name: `human-readable`,
+			input: `{"function": "SCRUB", "state": "FINISHED", "start_time": "Sun Aug 14 00:24:02 2022",
+				"end_time": "Sun Aug 14 03:08:54 2022", "processed": "1.50K", "errors": "2"}`,
+			expected: PoolScan{
+				Function: ScanScrub,
+				State:    ScanStateFinished,
+				Start:    time.Date(2022, time.August, 14, 0, 24, 2, 0, time.UTC),
+				End:      time.Date(2022, time.August, 14, 3, 8, 54, 0, time.UTC),
+				Repaired: 1536,
+				Errors:   2,
+				Progress: 1,
+			},
+		},
+		{
+			name:  `unknown function`,
+			input: `{"function": "ERRORSCRUB", "state": "FINISHED"}`,
+			err:   ErrInvalidOutput,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var raw json.RawMessage
+			if tc.input != `` {
+				raw = json.RawMessage(tc.input)
+			}
+			scan, err := parseJSONPoolScan(raw, time.UTC)
+			if err != tc.err {
+				t.Fatalf("Expected error %v, got %v", tc.err, err)
+			}
+			if tc.err != nil {
+				return
+			}
+			if diff := cmp.Diff(scan, tc.expected); diff != `` {
+				t.Fatalf("Parsed scan is not equal to expected scan: %s", diff)
+			}
+		})
+	}
+}
+
+func TestJSONPoolPropertiesParse(t *testing.T) {
+	f, err := os.Open(`testdata/zpool_get.json`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	props, err := parseJSONPoolProperties(`tank`, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		`size`:          `10737418240`,
+		`health`:        `ONLINE`,
+		`fragmentation`: `3`,
+	}
+	if diff := cmp.Diff(props.Properties(), expected); diff != `` {
+		t.Fatalf("Parsed properties are not equal to expected properties: %s", diff)
+	}
+
+	if _, err = parseJSONPoolProperties(`other`, strings.NewReader(`{"pools": {"tank": {}}}`)); err != ErrInvalidOutput {
+		t.Fatalf("Expected error %v, got %v", ErrInvalidOutput, err)
+	}
+}
+
+func TestJSONDatasetsParse(t *testing.T) {
+	f, err := os.Open(`testdata/zfs_get.json`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	datasets, err := parseJSONDatasets(`tank`, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := make(map[string]map[string]string, len(datasets))
+	for _, dataset := range datasets {
+		result[dataset.DatasetName()] = dataset.Properties()
+	}
+	expected := map[string]map[string]string{
+		`tank`:    {`used`: `1282048`, `compression`: `lz4`},
+		`tank/fs`: {`used`: `24576`, `compression`: `lz4`},
+	}
+	if diff := cmp.Diff(result, expected); diff != `` {
+		t.Fatalf("Parsed datasets are not equal to expected datasets: %s", diff)
+	}
+
+	if _, err = parseJSONDatasets(`tank`, strings.NewReader(`not json`)); err != ErrInvalidOutput {
+		t.Fatalf("Expected error %v, got %v", ErrInvalidOutput, err)
+	}
+}
+
+func TestDecodeJSONObjectOrder(t *testing.T) {
+	keys := make([]string, 0)
+	err := decodeJSONObject([]byte(`{"c": 1, "a": {"nested": true}, "b": "x"}`), func(key string, _ json.RawMessage) error {
+		keys = append(keys, key)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(keys, []string{`c`, `a`, `b`}); diff != `` {
+		t.Fatalf("Decoded keys are not in document order: %s", diff)
+	}
+}
+
+func TestNewBackend(t *testing.T) {
+	if _, ok := New(Config{Backend: BackendText}).(clientImpl); !ok {
+		t.Fatal(`Expected text backend to return the text client`)
+	}
+	if _, ok := New(Config{Backend: BackendJSON}).(jsonClient); !ok {
+		t.Fatal(`Expected json backend to return the JSON client`)
+	}
+	if _, ok := New(Config{}).(*autoClient); !ok {
+		t.Fatal(`Expected default backend to detect the client`)
+	}
+}
@@ -0,0 +1,164 @@
+package zfs
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultKstatRoot is the default location of the SPL kstat tree on Linux
+	DefaultKstatRoot = `/proc/spl/kstat`
+)
+
+// KstatDataType enum of named kstat data types
+type KstatDataType int
+
+const (
+	// KstatDataChar enum entry
+	KstatDataChar KstatDataType = iota
+	// KstatDataInt32 enum entry
+	KstatDataInt32
+	// KstatDataUint32 enum entry
+	KstatDataUint32
+	// KstatDataInt64 enum entry
+	KstatDataInt64
+	// KstatDataUint64 enum entry
+	KstatDataUint64
+	// KstatDataLong enum entry
+	KstatDataLong
+	// KstatDataUlong enum entry
+	KstatDataUlong
+	// KstatDataString enum entry
+	KstatDataString
+)
+
+// Numeric returns true if values of this type are numeric
+func (t KstatDataType) Numeric() bool {
+	return t >= KstatDataInt32 && t <= KstatDataUlong
+}
+
+// KstatNamed is a single named value within a kstat
+type KstatNamed struct {
+	Name  string
+	Type  KstatDataType
+	Value string
+}
+
+// Kstat holds the named values for a kstat, in the order they were reported
+type Kstat struct {
+	Module string
+	Name   string
+	Data   []KstatNamed
+}
+
+// Values returns the kstat values keyed by name
+func (k Kstat) Values() map[string]string {
+	result := make(map[string]string, len(k.Data))
+	for _, d := range k.Data {
+		result[d.Name] = d.Value
+	}
+	return result
+}
+
+func readKstat(root, module, name string) (Kstat, error) {
+	f, err := os.Open(filepath.Join(root, module, name))
+	if err != nil {
+		return Kstat{}, err
+	}
+	defer f.Close()
+
+	data, err := parseKstat(f)
+	if err != nil {
+		return Kstat{}, err
+	}
+
+	return Kstat{Module: module, Name: name, Data: data}, nil
+}
+
+// readObjsetKstats reads the per-dataset objset kstats for a pool, eg:
+//
+// 28 1 0x01 7 2160 5217294424 38395749396
+// name                            type data
+// dataset_name                    7    rpool/ROOT/ubuntu
+// writes                          4    30153
+// nwritten                        4    561470464
+// reads                           4    58122
+// nread                           4    1217155072
+// nunlinks                        4    1032
+// nunlinked                       4    1032
+func readObjsetKstats(root, pool string) ([]Kstat, error) {
+	module := path.Join(`zfs`, pool)
+	paths, err := filepath.Glob(filepath.Join(root, module, `objset-*`))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	result := make([]Kstat, 0, len(paths))
+	for _, p := range paths {
+		stats, err := readKstat(root, module, filepath.Base(p))
+		if err != nil {
+			// Objsets may disappear between listing and reading when datasets are unmounted or destroyed.
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		result = append(result, stats)
+	}
+
+	return result, nil
+}
+
+// Example named kstat to parse:
+//
+// 13 1 0x01 123 33456 4895438594 9384756983745
+// name                            type data
+// hits                            4    2353461
+// misses                          4    113428
+func parseKstat(r io.Reader) ([]KstatNamed, error) {
+	scanner := bufio.NewScanner(r)
+	// Skip the kstat header and column headings
+	for i := 0; i < 2; i++ {
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return nil, err
+			}
+			return nil, ErrInvalidOutput
+		}
+	}
+	if fields := strings.Fields(scanner.Text()); len(fields) != 3 || fields[0] != `name` || fields[1] != `type` || fields[2] != `data` {
+		return nil, ErrInvalidOutput
+	}
+
+	result := make([]KstatNamed, 0)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 2 {
+			return nil, ErrInvalidOutput
+		}
+		kind, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, ErrInvalidOutput
+		}
+		result = append(result, KstatNamed{
+			Name:  fields[0],
+			Type:  KstatDataType(kind),
+			Value: strings.Join(fields[2:], ` `),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,123 @@
+package zfs
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestKstatRead(t *testing.T) {
+	client := New(Config{KstatRoot: `testdata/kstat`})
+	stats, err := client.Kstat(context.Background(), `zfs`, `arcstats`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Module != `zfs` || stats.Name != `arcstats` {
+		t.Fatalf("Unexpected kstat identity: %s/%s", stats.Module, stats.Name)
+	}
+	if len(stats.Data) != 69 {
+		t.Fatalf("Expected exactly 69 values, got %d", len(stats.Data))
+	}
+
+	expectedOutput := []KstatNamed{
+		{Name: `hits`, Type: KstatDataUint64, Value: `2353461`},
+		{Name: `misses`, Type: KstatDataUint64, Value: `113428`},
+	}
+	if diff := cmp.Diff(stats.Data[:2], expectedOutput); diff != `` {
+		t.Fatalf("Parsed kstat output is not equal to expected output: %s", diff)
+	}
+
+	values := stats.Values()
+	if values[`memory_available_bytes`] != `2768404480` {
+		t.Fatalf("Unexpected value for memory_available_bytes: %s", values[`memory_available_bytes`])
+	}
+}
+
+func TestKstatReadMissing(t *testing.T) {
+	client := New(Config{KstatRoot: `testdata/kstat`})
+	if _, err := client.Kstat(context.Background(), `zfs`, `missing`); err == nil {
+		t.Fatal(`Expected error reading missing kstat`)
+	}
+}
+
+func TestObjsetKstatsRead(t *testing.T) {
+	client := New(Config{KstatRoot: `testdata/kstat`})
+	objsets, err := client.ObjsetKstats(context.Background(), `testpool`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(objsets) != 2 {
+		t.Fatalf("Expected exactly 2 objsets, got %d", len(objsets))
+	}
+	for i, expected := range []string{`testpool`, `testpool/vol`} {
+		values := objsets[i].Values()
+		if objsets[i].Module != `zfs/testpool` {
+			t.Fatalf("Unexpected module for objset %s: %s", objsets[i].Name, objsets[i].Module)
+		}
+		if values[`dataset_name`] != expected {
+			t.Fatalf("Expected dataset %s, got %s", expected, values[`dataset_name`])
+		}
+	}
+
+	objsets, err = client.ObjsetKstats(context.Background(), `missing`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objsets) != 0 {
+		t.Fatalf("Expected no objsets for missing pool, got %d", len(objsets))
+	}
+}
+
+func TestKstatParse(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected []KstatNamed
+		err      error
+	}{
+		{
+			name: `mixed types`,
+			input: `3 1 0x01 3 144 2945398281 8293741920374
+name                            type data
+zil_commit_count                4    1234
+memory_available_bytes          3    -1024
+class                           7    misc stats
+`,
+			expected: []KstatNamed{
+				{Name: `zil_commit_count`, Type: KstatDataUint64, Value: `1234`},
+				{Name: `memory_available_bytes`, Type: KstatDataInt64, Value: `-1024`},
+				{Name: `class`, Type: KstatDataString, Value: `misc stats`},
+			},
+		},
+		{
+			name:  `missing headings`,
+			input: "3 1 0x01 3 144 2945398281 8293741920374\n",
+			err:   ErrInvalidOutput,
+		},
+		{
+			name: `invalid type`,
+			input: `3 1 0x01 3 144 2945398281 8293741920374
+name                            type data
+hits                            x    1234
+`,
+			err: ErrInvalidOutput,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := parseKstat(strings.NewReader(tc.input))
+			if err != tc.err {
+				t.Fatalf("Expected error %v, got %v", tc.err, err)
+			}
+			if diff := cmp.Diff(result, tc.expected); diff != `` {
+				t.Fatalf("Parsed kstat output is not equal to expected output: %s", diff)
+			}
+		})
+	}
+}
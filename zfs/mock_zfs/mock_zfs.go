@@ -5,6 +5,7 @@
 package mock_zfs
 
 import (
+	context "context"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -48,20 +49,86 @@ func (mr *MockClientMockRecorder) Datasets(pool, kind interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Datasets", reflect.TypeOf((*MockClient)(nil).Datasets), pool, kind)
 }
 
-// Pool mocks base method.
-func (m *MockClient) Pool(name string) zfs.Pool {
+// Events mocks base method.
+func (m *MockClient) Events(ctx context.Context, handler func(zfs.Event)) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Pool", name)
-	ret0, _ := ret[0].(zfs.Pool)
+	ret := m.ctrl.Call(m, "Events", ctx, handler)
+	ret0, _ := ret[0].(error)
 	return ret0
 }
 
+// Events indicates an expected call of Events.
+func (mr *MockClientMockRecorder) Events(ctx, handler interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Events", reflect.TypeOf((*MockClient)(nil).Events), ctx, handler)
+}
+
+// GroupSpace mocks base method.
+func (m *MockClient) GroupSpace(ctx context.Context, dataset string) ([]zfs.Space, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GroupSpace", ctx, dataset)
+	ret0, _ := ret[0].([]zfs.Space)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GroupSpace indicates an expected call of GroupSpace.
+func (mr *MockClientMockRecorder) GroupSpace(ctx, dataset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GroupSpace", reflect.TypeOf((*MockClient)(nil).GroupSpace), ctx, dataset)
+}
+
+// Kstat mocks base method.
+func (m *MockClient) Kstat(ctx context.Context, module, name string) (zfs.Kstat, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Kstat", ctx, module, name)
+	ret0, _ := ret[0].(zfs.Kstat)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Kstat indicates an expected call of Kstat.
+func (mr *MockClientMockRecorder) Kstat(ctx, module, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Kstat", reflect.TypeOf((*MockClient)(nil).Kstat), ctx, module, name)
+}
+
+// ModuleParameters mocks base method.
+func (m *MockClient) ModuleParameters(ctx context.Context) ([]zfs.ModuleParameter, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ModuleParameters", ctx)
+	ret0, _ := ret[0].([]zfs.ModuleParameter)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ModuleParameters indicates an expected call of ModuleParameters.
+func (mr *MockClientMockRecorder) ModuleParameters(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ModuleParameters", reflect.TypeOf((*MockClient)(nil).ModuleParameters), ctx)
+}
+
+// ObjsetKstats mocks base method.
+func (m *MockClient) ObjsetKstats(ctx context.Context, pool string) ([]zfs.Kstat, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ObjsetKstats", ctx, pool)
+	ret0, _ := ret[0].([]zfs.Kstat)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ObjsetKstats indicates an expected call of ObjsetKstats.
+func (mr *MockClientMockRecorder) ObjsetKstats(ctx, pool interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ObjsetKstats", reflect.TypeOf((*MockClient)(nil).ObjsetKstats), ctx, pool)
+}
+
 // Pool mocks base method.
-func (m *MockClient) PoolDisks() ([]zfs.PoolDisk, error) {
+func (m *MockClient) Pool(name string) zfs.Pool {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "PoolDisks")
-	ret0, _ := ret[0].([]zfs.PoolDisk)
-	return ret0, nil
+	ret := m.ctrl.Call(m, "Pool", name)
+	ret0, _ := ret[0].(zfs.Pool)
+	return ret0
 }
 
 // Pool indicates an expected call of Pool.
@@ -71,24 +138,113 @@ func (mr *MockClientMockRecorder) Pool(name interface{}) *gomock.Call {
 }
 
 // PoolNames mocks base method.
-func (m *MockClient) PoolNames() ([]string, error) {
+func (m *MockClient) PoolNames(ctx context.Context) ([]string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "PoolNames")
+	ret := m.ctrl.Call(m, "PoolNames", ctx)
 	ret0, _ := ret[0].([]string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // PoolNames indicates an expected call of PoolNames.
-func (mr *MockClientMockRecorder) PoolNames() *gomock.Call {
+func (mr *MockClientMockRecorder) PoolNames(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PoolNames", reflect.TypeOf((*MockClient)(nil).PoolNames), ctx)
+}
+
+// ProjectSpace mocks base method.
+func (m *MockClient) ProjectSpace(ctx context.Context, dataset string) ([]zfs.Space, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProjectSpace", ctx, dataset)
+	ret0, _ := ret[0].([]zfs.Space)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ProjectSpace indicates an expected call of ProjectSpace.
+func (mr *MockClientMockRecorder) ProjectSpace(ctx, dataset interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PoolNames", reflect.TypeOf((*MockClient)(nil).PoolNames))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProjectSpace", reflect.TypeOf((*MockClient)(nil).ProjectSpace), ctx, dataset)
 }
 
-// PoolDisks indicates an expected call of PoolDIsks
-func (mr *MockClientMockRecorder) PoolDisks() *gomock.Call {
+// SendSize mocks base method.
+func (m *MockClient) SendSize(ctx context.Context, from, to string) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendSize", ctx, from, to)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SendSize indicates an expected call of SendSize.
+func (mr *MockClientMockRecorder) SendSize(ctx, from, to interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PoolDisks", reflect.TypeOf((*MockClient)(nil).PoolDisks))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendSize", reflect.TypeOf((*MockClient)(nil).SendSize), ctx, from, to)
+}
+
+// Txgs mocks base method.
+func (m *MockClient) Txgs(ctx context.Context, pool string) ([]zfs.Txg, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Txgs", ctx, pool)
+	ret0, _ := ret[0].([]zfs.Txg)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Txgs indicates an expected call of Txgs.
+func (mr *MockClientMockRecorder) Txgs(ctx, pool interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Txgs", reflect.TypeOf((*MockClient)(nil).Txgs), ctx, pool)
+}
+
+// UserSpace mocks base method.
+func (m *MockClient) UserSpace(ctx context.Context, dataset string) ([]zfs.Space, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UserSpace", ctx, dataset)
+	ret0, _ := ret[0].([]zfs.Space)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UserSpace indicates an expected call of UserSpace.
+func (mr *MockClientMockRecorder) UserSpace(ctx, dataset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserSpace", reflect.TypeOf((*MockClient)(nil).UserSpace), ctx, dataset)
+}
+
+// VdevTrees mocks base method.
+func (m *MockClient) VdevTrees(ctx context.Context, pools ...string) ([]zfs.VdevTree, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx}
+	for _, a := range pools {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "VdevTrees", varargs...)
+	ret0, _ := ret[0].([]zfs.VdevTree)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VdevTrees indicates an expected call of VdevTrees.
+func (mr *MockClientMockRecorder) VdevTrees(ctx interface{}, pools ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx}, pools...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VdevTrees", reflect.TypeOf((*MockClient)(nil).VdevTrees), varargs...)
+}
+
+// Version mocks base method.
+func (m *MockClient) Version(ctx context.Context) (zfs.Version, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Version", ctx)
+	ret0, _ := ret[0].(zfs.Version)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Version indicates an expected call of Version.
+func (mr *MockClientMockRecorder) Version(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Version", reflect.TypeOf((*MockClient)(nil).Version), ctx)
 }
 
 // MockPool is a mock of Pool interface.
@@ -114,6 +270,36 @@ func (m *MockPool) EXPECT() *MockPoolMockRecorder {
 	return m.recorder
 }
 
+// Dedup mocks base method.
+func (m *MockPool) Dedup(ctx context.Context) (zfs.DedupStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Dedup", ctx)
+	ret0, _ := ret[0].(zfs.DedupStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Dedup indicates an expected call of Dedup.
+func (mr *MockPoolMockRecorder) Dedup(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Dedup", reflect.TypeOf((*MockPool)(nil).Dedup), ctx)
+}
+
+// LatencyHistograms mocks base method.
+func (m *MockPool) LatencyHistograms(ctx context.Context, vdevs bool) ([]zfs.LatencyHistogram, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LatencyHistograms", ctx, vdevs)
+	ret0, _ := ret[0].([]zfs.LatencyHistogram)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LatencyHistograms indicates an expected call of LatencyHistograms.
+func (mr *MockPoolMockRecorder) LatencyHistograms(ctx, vdevs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LatencyHistograms", reflect.TypeOf((*MockPool)(nil).LatencyHistograms), ctx, vdevs)
+}
+
 // Name mocks base method.
 func (m *MockPool) Name() string {
 	m.ctrl.T.Helper()
@@ -129,9 +315,9 @@ func (mr *MockPoolMockRecorder) Name() *gomock.Call {
 }
 
 // Properties mocks base method.
-func (m *MockPool) Properties(props ...string) (zfs.PoolProperties, error) {
+func (m *MockPool) Properties(ctx context.Context, props ...string) (zfs.PoolProperties, error) {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{}
+	varargs := []interface{}{ctx}
 	for _, a := range props {
 		varargs = append(varargs, a)
 	}
@@ -142,9 +328,10 @@ func (m *MockPool) Properties(props ...string) (zfs.PoolProperties, error) {
 }
 
 // Properties indicates an expected call of Properties.
-func (mr *MockPoolMockRecorder) Properties(props ...interface{}) *gomock.Call {
+func (mr *MockPoolMockRecorder) Properties(ctx interface{}, props ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Properties", reflect.TypeOf((*MockPool)(nil).Properties), props...)
+	varargs := append([]interface{}{ctx}, props...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Properties", reflect.TypeOf((*MockPool)(nil).Properties), varargs...)
 }
 
 // MockPoolProperties is a mock of PoolProperties interface.
@@ -221,6 +408,26 @@ func (mr *MockDatasetsMockRecorder) Kind() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Kind", reflect.TypeOf((*MockDatasets)(nil).Kind))
 }
 
+// List mocks base method.
+func (m *MockDatasets) List(ctx context.Context, props ...string) ([]zfs.DatasetProperties, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx}
+	for _, a := range props {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "List", varargs...)
+	ret0, _ := ret[0].([]zfs.DatasetProperties)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockDatasetsMockRecorder) List(ctx interface{}, props ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx}, props...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockDatasets)(nil).List), varargs...)
+}
+
 // Pool mocks base method.
 func (m *MockDatasets) Pool() string {
 	m.ctrl.T.Helper()
@@ -236,9 +443,9 @@ func (mr *MockDatasetsMockRecorder) Pool() *gomock.Call {
 }
 
 // Properties mocks base method.
-func (m *MockDatasets) Properties(props ...string) ([]zfs.DatasetProperties, error) {
+func (m *MockDatasets) Properties(ctx context.Context, props ...string) ([]zfs.DatasetProperties, error) {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{}
+	varargs := []interface{}{ctx}
 	for _, a := range props {
 		varargs = append(varargs, a)
 	}
@@ -249,9 +456,10 @@ func (m *MockDatasets) Properties(props ...string) ([]zfs.DatasetProperties, err
 }
 
 // Properties indicates an expected call of Properties.
-func (mr *MockDatasetsMockRecorder) Properties(props ...interface{}) *gomock.Call {
+func (mr *MockDatasetsMockRecorder) Properties(ctx interface{}, props ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Properties", reflect.TypeOf((*MockDatasets)(nil).Properties), props...)
+	varargs := append([]interface{}{ctx}, props...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Properties", reflect.TypeOf((*MockDatasets)(nil).Properties), varargs...)
 }
 
 // MockDatasetProperties is a mock of DatasetProperties interface.
@@ -0,0 +1,48 @@
+package zfs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// DefaultModuleRoot is the default location of the ZFS kernel module in sysfs on Linux
+	DefaultModuleRoot = `/sys/module/zfs`
+)
+
+// ModuleParameter holds the value of a ZFS kernel module parameter (tunable)
+type ModuleParameter struct {
+	Name  string
+	Value string
+}
+
+// readModuleParameters reads all parameters of the ZFS kernel module, in name order.
+func readModuleParameters(root string) ([]ModuleParameter, error) {
+	dir := filepath.Join(root, `parameters`)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]ModuleParameter, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			// Some parameters may be write-only, or removed between listing and reading.
+			if os.IsPermission(err) || os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		result = append(result, ModuleParameter{
+			Name:  entry.Name(),
+			Value: strings.TrimSpace(string(b)),
+		})
+	}
+
+	return result, nil
+}
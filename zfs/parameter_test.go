@@ -0,0 +1,37 @@
+package zfs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestModuleParametersRead(t *testing.T) {
+	client := New(Config{ModuleRoot: `testdata/module`})
+	params, err := client.ModuleParameters(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []ModuleParameter{
+		{Name: `zfs_arc_max`, Value: `4294967296`},
+		{Name: `zfs_arc_meta_limit_percent`, Value: `-1`},
+		{Name: `zfs_arc_min`, Value: `0`},
+		{Name: `zfs_dirty_data_max`, Value: `429496729`},
+		{Name: `zfs_fletcher_4_impl`, Value: `fletcher4`},
+		{Name: `zfs_vdev_async_write_max_active`, Value: `10`},
+		{Name: `zfs_vdev_raidz_impl`, Value: `[fastest] original scalar sse2 ssse3 avx2`},
+		{Name: `zfs_vdev_sync_read_max_active`, Value: `3`},
+	}
+	if diff := cmp.Diff(params, expected); diff != `` {
+		t.Fatalf("Read module parameters are not equal to expected parameters: %s", diff)
+	}
+}
+
+func TestModuleParametersReadMissing(t *testing.T) {
+	client := New(Config{ModuleRoot: `testdata/missing`})
+	if _, err := client.ModuleParameters(context.Background()); err == nil {
+		t.Fatal(`Expected error reading missing module parameters`)
+	}
+}
@@ -0,0 +1,257 @@
+package zfs
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ScanFunction enum contains the type of scan
+type ScanFunction string
+
+const (
+	// ScanNone enum entry
+	ScanNone ScanFunction = `none`
+	// ScanScrub enum entry
+	ScanScrub ScanFunction = `scrub`
+	// ScanResilver enum entry
+	ScanResilver ScanFunction = `resilver`
+)
+
+// ScanState enum contains the state of a scan
+type ScanState string
+
+const (
+	// ScanStateNone enum entry
+	ScanStateNone ScanState = `none`
+	// ScanStateFinished enum entry
+	ScanStateFinished ScanState = `finished`
+	// ScanStateInProgress enum entry
+	ScanStateInProgress ScanState = `in_progress`
+	// ScanStateCanceled enum entry
+	ScanStateCanceled ScanState = `canceled`
+	// ScanStatePaused enum entry
+	ScanStatePaused ScanState = `paused`
+)
+
+const (
+	scanTimeLayout = `Mon Jan 2 15:04:05 2006`
+)
+
+var (
+	scanFinishedRegexp   = regexp.MustCompile(`^(?:scrub repaired|resilvered) (\S+) in (.+?) with (\d+) errors on (.+)$`)
+	scanCanceledRegexp   = regexp.MustCompile(`^(scrub|resilver) canceled on (.+)$`)
+	scanInProgressRegexp = regexp.MustCompile(`^(scrub|resilver) in progress since (.+)$`)
+	scanPausedRegexp     = regexp.MustCompile(`^scrub paused since (.+)$`)
+	scanStartedRegexp    = regexp.MustCompile(`^scrub started on (.+)$`)
+	scanScannedRegexp    = regexp.MustCompile(`(\S+)(?: / (\S+))? scanned`)
+	scanOutOfRegexp      = regexp.MustCompile(`scanned out of (\S+)`)
+	scanIssuedRegexp     = regexp.MustCompile(`(\S+)(?: / (\S+))? issued`)
+	scanTotalRegexp      = regexp.MustCompile(`(\S+) total`)
+	scanRepairedRegexp   = regexp.MustCompile(`(\S+) (?:repaired|resilvered),`)
+	scanProgressRegexp   = regexp.MustCompile(`([\d.]+)% done`)
+	scanDurationRegexp   = regexp.MustCompile(`^(?:(\d+) days? )?(\d+):(\d+):(\d+)$`)
+)
+
+// PoolScan holds the status of the most recent scrub or resilver of a pool
+type PoolScan struct {
+	Function ScanFunction
+	State    ScanState
+	Start    time.Time
+	End      time.Time
+	Scanned  uint64
+	Issued   uint64
+	Total    uint64
+	// Repaired holds the bytes repaired by a scrub, or resilvered by a resilver
+	Repaired uint64
+	Errors   uint64
+	// Progress holds the completion ratio of the scan
+	Progress float64
+}
+
+// statusSection returns the lines for the named section of `zpool status` output, with the section name removed and
+// leading whitespace trimmed.
+func statusSection(lines []string, name string) []string {
+	result := make([]string, 0)
+	prefix := name + `:`
+	inSection := false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if !inSection {
+			if strings.HasPrefix(trimmed, prefix) {
+				inSection = true
+				if v := strings.TrimSpace(strings.TrimPrefix(trimmed, prefix)); v != `` {
+					result = append(result, v)
+				}
+			}
+			continue
+		}
+		// Sections end at the next unindented key, or blank line
+		if trimmed == `` || (len(line) > 0 && line[0] != '\t' && line[0] != ' ') || isStatusKey(trimmed) {
+			break
+		}
+		result = append(result, trimmed)
+	}
+	return result
+}
+
+func isStatusKey(line string) bool {
+	i := strings.Index(line, `:`)
+	if i <= 0 || (i+1 < len(line) && line[i+1] != ' ') {
+		return false
+	}
+	for _, r := range line[:i] {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// Example strings to parse:
+//
+//	scan: scrub repaired 0B in 02:44:52 with 0 errors on Sun Aug 14 03:08:54 2022
+//
+//	scan: resilver in progress since Mon Aug 15 10:00:51 2022
+//		1.23T scanned at 1.05G/s, 800G issued at 700M/s, 2.00T total
+//		100G resilvered, 40.00% done, 00:29:45 to go
+func parsePoolScan(lines []string, loc *time.Location) (PoolScan, error) {
+	section := statusSection(lines, `scan`)
+	result := PoolScan{Function: ScanNone, State: ScanStateNone}
+	if len(section) == 0 || section[0] == `none requested` {
+		return result, nil
+	}
+
+	summary := strings.Join(strings.Fields(section[0]), ` `)
+	details := strings.Join(strings.Fields(strings.Join(section[1:], ` `)), ` `)
+	var err error
+	if m := scanFinishedRegexp.FindStringSubmatch(summary); m != nil {
+		result.Function = ScanScrub
+		if strings.HasPrefix(summary, `resilvered`) {
+			result.Function = ScanResilver
+		}
+		result.State = ScanStateFinished
+		result.Progress = 1
+		if result.Repaired, err = parseNiceNumber(m[1]); err != nil {
+			return result, err
+		}
+		duration, err := parseScanDuration(m[2])
+		if err != nil {
+			return result, err
+		}
+		if result.Errors, err = strconv.ParseUint(m[3], 10, 64); err != nil {
+			return result, ErrInvalidOutput
+		}
+		if result.End, err = parseScanTime(m[4], loc); err != nil {
+			return result, err
+		}
+		result.Start = result.End.Add(-duration)
+		return result, nil
+	}
+
+	if m := scanCanceledRegexp.FindStringSubmatch(summary); m != nil {
+		result.Function = ScanFunction(m[1])
+		result.State = ScanStateCanceled
+		result.End, err = parseScanTime(m[2], loc)
+		return result, err
+	}
+
+	if m := scanInProgressRegexp.FindStringSubmatch(summary); m != nil {
+		result.Function = ScanFunction(m[1])
+		result.State = ScanStateInProgress
+		if result.Start, err = parseScanTime(m[2], loc); err != nil {
+			return result, err
+		}
+	} else if m := scanPausedRegexp.FindStringSubmatch(summary); m != nil {
+		result.Function = ScanScrub
+		result.State = ScanStatePaused
+		for _, line := range section[1:] {
+			if m := scanStartedRegexp.FindStringSubmatch(line); m != nil {
+				if result.Start, err = parseScanTime(m[1], loc); err != nil {
+					return result, err
+				}
+			}
+		}
+	} else {
+		return result, ErrInvalidOutput
+	}
+
+	return result, parseScanProgress(&result, details)
+}
+
+func parseScanProgress(scan *PoolScan, details string) error {
+	var err error
+	if m := scanScannedRegexp.FindStringSubmatch(details); m != nil {
+		if scan.Scanned, err = parseNiceNumber(m[1]); err != nil {
+			return err
+		}
+		if m[2] != `` {
+			if scan.Total, err = parseNiceNumber(m[2]); err != nil {
+				return err
+			}
+		}
+	}
+	if m := scanOutOfRegexp.FindStringSubmatch(details); m != nil {
+		if scan.Total, err = parseNiceNumber(m[1]); err != nil {
+			return err
+		}
+	}
+	if m := scanIssuedRegexp.FindStringSubmatch(details); m != nil {
+		if scan.Issued, err = parseNiceNumber(m[1]); err != nil {
+			return err
+		}
+	}
+	if m := scanTotalRegexp.FindStringSubmatch(details); m != nil {
+		if scan.Total, err = parseNiceNumber(m[1]); err != nil {
+			return err
+		}
+	}
+	if m := scanRepairedRegexp.FindStringSubmatch(details); m != nil {
+		if scan.Repaired, err = parseNiceNumber(m[1]); err != nil {
+			return err
+		}
+	}
+	if m := scanProgressRegexp.FindStringSubmatch(details); m != nil {
+		progress, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			return ErrInvalidOutput
+		}
+		scan.Progress = progress / 100
+	}
+
+	return nil
+}
+
+func parseScanTime(value string, loc *time.Location) (time.Time, error) {
+	t, err := time.ParseInLocation(scanTimeLayout, strings.Join(strings.Fields(value), ` `), loc)
+	if err != nil {
+		return t, ErrInvalidOutput
+	}
+	return t, nil
+}
+
+// parseScanDuration handles both the current `1 days 02:44:52` format, and the `2h44m` format of older releases.
+func parseScanDuration(value string) (time.Duration, error) {
+	m := scanDurationRegexp.FindStringSubmatch(value)
+	if m == nil {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, ErrInvalidOutput
+		}
+		return d, nil
+	}
+
+	var result time.Duration
+	for i, unit := range []time.Duration{24 * time.Hour, time.Hour, time.Minute, time.Second} {
+		if m[i+1] == `` {
+			continue
+		}
+		v, err := strconv.Atoi(m[i+1])
+		if err != nil {
+			return 0, ErrInvalidOutput
+		}
+		result += time.Duration(v) * unit
+	}
+	return result, nil
+}
@@ -0,0 +1,232 @@
+package zfs
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPoolScanParse(t *testing.T) {
+	date := func(value string) time.Time {
+		d, err := time.ParseInLocation(scanTimeLayout, value, time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+
+	testCases := []struct {
+		name     string
+		input    string
+		expected PoolScan
+		err      error
+	}{
+		{
+			name: `none requested`,
+			input: `  pool: testpool
+ state: ONLINE
+  scan: none requested
+config:
+`,
+			expected: PoolScan{Function: ScanNone, State: ScanStateNone},
+		},
+		{
+			name: `no scan section`,
+			input: `  pool: testpool
+ state: ONLINE
+config:
+`,
+			expected: PoolScan{Function: ScanNone, State: ScanStateNone},
+		},
+		{
+			name: `scrub finished`,
+			input: `  pool: testpool
+ state: ONLINE
+  scan: scrub repaired 1.50K in 02:44:52 with 2 errors on Sun Aug 14 03:08:54 2022
+config:
+`,
+			expected: PoolScan{
+				Function: ScanScrub,
+				State:    ScanStateFinished,
+				Start:    date(`Sun Aug 14 00:24:02 2022`),
+				End:      date(`Sun Aug 14 03:08:54 2022`),
+				Repaired: 1536,
+				Errors:   2,
+				Progress: 1,
+			},
+		},
+		{
+			name: `scrub finished with days`,
+			input: `  scan: scrub repaired 0B in 1 days 00:00:01 with 0 errors on Sun Aug  7 03:08:54 2022
+config:
+`,
+			expected: PoolScan{
+				Function: ScanScrub,
+				State:    ScanStateFinished,
+				Start:    date(`Sat Aug 6 03:08:53 2022`),
+				End:      date(`Sun Aug 7 03:08:54 2022`),
+				Progress: 1,
+			},
+		},
+		{
+			name: `scrub finished legacy`,
+			input: `  scan: scrub repaired 0 in 2h44m with 0 errors on Sun Aug 14 03:08:54 2022
+config:
+`,
+			expected: PoolScan{
+				Function: ScanScrub,
+				State:    ScanStateFinished,
+				Start:    date(`Sun Aug 14 00:24:54 2022`),
+				End:      date(`Sun Aug 14 03:08:54 2022`),
+				Progress: 1,
+			},
+		},
+		{
+			name: `resilver finished`,
+			input: `  scan: resilvered 1.00G in 00:10:00 with 0 errors on Mon Aug 15 10:11:12 2022
+config:
+`,
+			expected: PoolScan{
+				Function: ScanResilver,
+				State:    ScanStateFinished,
+				Start:    date(`Mon Aug 15 10:01:12 2022`),
+				End:      date(`Mon Aug 15 10:11:12 2022`),
+				Repaired: 1 << 30,
+				Progress: 1,
+			},
+		},
+		{
+			name: `scrub canceled`,
+			input: `  scan: scrub canceled on Sun Aug 14 03:08:54 2022
+config:
+`,
+			expected: PoolScan{
+				Function: ScanScrub,
+				State:    ScanStateCanceled,
+				End:      date(`Sun Aug 14 03:08:54 2022`),
+			},
+		},
+		{
+			name: `scrub in progress`,
+			input: `  scan: scrub in progress since Sun Aug 14 00:24:02 2022
+	1.50T scanned at 1.05G/s, 512G issued at 700M/s, 2.00T total
+	0B repaired, 25.00% done, 00:29:45 to go
+config:
+`,
+			expected: PoolScan{
+				Function: ScanScrub,
+				State:    ScanStateInProgress,
+				Start:    date(`Sun Aug 14 00:24:02 2022`),
+				Scanned:  3 << 39,
+				Issued:   1 << 39,
+				Total:    1 << 41,
+				Progress: 0.25,
+			},
+		},
+		{
+			name: `resilver in progress`,
+			input: `  scan: resilver in progress since Mon Aug 15 10:00:51 2022
+	1.50T / 2.00T scanned at 1.05G/s, 512G / 2.00T issued at 700M/s
+	100M resilvered, 25.00% done, no estimated completion time
+config:
+`,
+			expected: PoolScan{
+				Function: ScanResilver,
+				State:    ScanStateInProgress,
+				Start:    date(`Mon Aug 15 10:00:51 2022`),
+				Scanned:  3 << 39,
+				Issued:   1 << 39,
+				Total:    1 << 41,
+				Repaired: 100 << 20,
+				Progress: 0.25,
+			},
+		},
+		{
+			name: `scrub in progress legacy`,
+			input: `  scan: scrub in progress since Sun Aug 14 00:24:02 2022
+    1.50T scanned out of 2.00T at 100M/s, 2h10m to go
+    0 repaired, 75.00% done
+config:
+`,
+			expected: PoolScan{
+				Function: ScanScrub,
+				State:    ScanStateInProgress,
+				Start:    date(`Sun Aug 14 00:24:02 2022`),
+				Scanned:  3 << 39,
+				Total:    1 << 41,
+				Progress: 0.75,
+			},
+		},
+		{
+			name: `scrub paused`,
+			input: `  scan: scrub paused since Sun Aug 14 01:00:00 2022
+	scrub started on Sun Aug 14 00:24:02 2022
+	1.50T scanned, 512G issued, 2.00T total
+	0B repaired, 25.00% done
+config:
+`,
+			expected: PoolScan{
+				Function: ScanScrub,
+				State:    ScanStatePaused,
+				Start:    date(`Sun Aug 14 00:24:02 2022`),
+				Scanned:  3 << 39,
+				Issued:   1 << 39,
+				Total:    1 << 41,
+				Progress: 0.25,
+			},
+		},
+		{
+			name: `unknown`,
+			input: `  scan: something unexpected
+config:
+`,
+			err: ErrInvalidOutput,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			scan, err := parsePoolScan(strings.Split(tc.input, "\n"), time.UTC)
+			if err != tc.err {
+				t.Fatalf("Expected error %v, got %v", tc.err, err)
+			}
+			if tc.err != nil {
+				return
+			}
+			if diff := cmp.Diff(scan, tc.expected); diff != `` {
+				t.Fatalf("Parsed scan is not equal to expected scan: %s", diff)
+			}
+		})
+	}
+}
+
+func TestParseNiceNumber(t *testing.T) {
+	testCases := map[string]uint64{
+		`0`:     0,
+		`0B`:    0,
+		`512`:   512,
+		`512B`:  512,
+		`1.50K`: 1536,
+		`2M`:    2 << 20,
+		`1.00G`: 1 << 30,
+		`2.00T`: 1 << 41,
+	}
+	for input, expected := range testCases {
+		v, err := parseNiceNumber(input)
+		if err != nil {
+			t.Fatalf("Unexpected error parsing %s: %v", input, err)
+		}
+		if v != expected {
+			t.Fatalf("Expected %d parsing %s, got %d", expected, input, v)
+		}
+	}
+	for _, input := range []string{``, `-`, `abc`, `1.2X`} {
+		if _, err := parseNiceNumber(input); err != ErrInvalidOutput {
+			t.Fatalf("Expected error parsing %q, got %v", input, err)
+		}
+	}
+}
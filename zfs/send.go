@@ -0,0 +1,54 @@
+package zfs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// sendSize estimates the size in bytes of an incremental stream between the from and to snapshots, via a dry run of
+// `zfs send`.
+func sendSize(ctx context.Context, from, to string) (uint64, error) {
+	cmd := newCommand(ctx, `zfs`, `send`, `-nvP`, `-i`, from, to)
+	defer cmd.close()
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+	if err := cmd.Wait(); err != nil {
+		return 0, err
+	}
+
+	// Releases prior to OpenZFS 2.0 write the estimate to stderr.
+	if size, err := parseSendSize(&stdout); err == nil {
+		return size, nil
+	}
+	return parseSendSize(&stderr)
+}
+
+// parseSendSize returns the total size from the parsable output of `zfs send -nvP`.
+func parseSendSize(r io.Reader) (uint64, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || fields[0] != `size` {
+			continue
+		}
+		size, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return 0, ErrInvalidOutput
+		}
+		return size, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+
+	return 0, ErrInvalidOutput
+}
@@ -0,0 +1,45 @@
+package zfs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSendSize(t *testing.T) {
+	testCases := []struct {
+		name     string
+		output   string
+		expected uint64
+		err      error
+	}{
+		{
+			name:     `incremental`,
+			output:   "incremental\tdaily-2\ttank/data@daily-3\t1246040\nsize\t1246040\n",
+			expected: 1246040,
+		},
+		{
+			name:   `missing size`,
+			output: "incremental\tdaily-2\ttank/data@daily-3\t1246040\n",
+			err:    ErrInvalidOutput,
+		},
+		{
+			name:   `invalid size`,
+			output: "size\t1.19M\n",
+			err:    ErrInvalidOutput,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			size, err := parseSendSize(strings.NewReader(tc.output))
+			if err != tc.err {
+				t.Fatalf("Expected error %v, got %v", tc.err, err)
+			}
+			if size != tc.expected {
+				t.Fatalf("Expected size %d, got %d", tc.expected, size)
+			}
+		})
+	}
+}
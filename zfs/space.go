@@ -0,0 +1,170 @@
+package zfs
+
+import (
+	"context"
+	"os/user"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SpaceKind enum of space accounting types
+type SpaceKind string
+
+const (
+	// SpaceUser enum entry
+	SpaceUser SpaceKind = `user`
+	// SpaceGroup enum entry
+	SpaceGroup SpaceKind = `group`
+	// SpaceProject enum entry
+	SpaceProject SpaceKind = `project`
+)
+
+const (
+	spaceTypePOSIXUser  = `POSIX User`
+	spaceTypePOSIXGroup = `POSIX Group`
+
+	// spaceNameRetryInterval is the interval after which IDs that could not be resolved are looked up again
+	spaceNameRetryInterval = 5 * time.Minute
+)
+
+var (
+	// lookupUser and lookupGroup resolve numeric IDs that ZFS was unable to resolve.
+	lookupUser = func(id string) (string, error) {
+		u, err := user.LookupId(id)
+		if err != nil {
+			return ``, err
+		}
+		return u.Username, nil
+	}
+	lookupGroup = func(id string) (string, error) {
+		g, err := user.LookupGroupId(id)
+		if err != nil {
+			return ``, err
+		}
+		return g.Name, nil
+	}
+)
+
+// Space holds the space accounting of a single user, group or project of a dataset, as reported by
+// `zfs userspace`, `zfs groupspace` or `zfs projectspace`. Quotas are zero when not set.
+type Space struct {
+	// Type holds the type of principal (eg - `POSIX User`, `SMB Group`, `Project`)
+	Type         string
+	Name         string
+	Used         uint64
+	Quota        uint64
+	ObjectsUsed  uint64
+	ObjectsQuota uint64
+}
+
+func space(ctx context.Context, names *spaceNames, kind SpaceKind, dataset string) ([]Space, error) {
+	handler := &spaceHandler{names: names}
+	if err := execute(ctx, dataset, handler, `zfs`, string(kind)+`space`, `-Hp`, `-o`, `type,name,used,quota,objused,objquota`); err != nil {
+		return nil, err
+	}
+	return handler.spaces, nil
+}
+
+// spaceHandler handles parsing of the rows returned from `zfs userspace` and friends
+type spaceHandler struct {
+	names  *spaceNames
+	spaces []Space
+}
+
+// processLine implements the handler interface
+func (h *spaceHandler) processLine(dataset string, line []string) error {
+	if len(line) != 6 {
+		return ErrInvalidOutput
+	}
+	s := Space{Type: line[0], Name: h.names.resolve(line[0], line[1])}
+	for i, value := range []*uint64{&s.Used, &s.Quota, &s.ObjectsUsed, &s.ObjectsQuota} {
+		v, err := parseSpaceValue(line[i+2])
+		if err != nil {
+			return err
+		}
+		*value = v
+	}
+	h.spaces = append(h.spaces, s)
+
+	return nil
+}
+
+func parseSpaceValue(value string) (uint64, error) {
+	if value == `-` || value == `none` {
+		return 0, nil
+	}
+	v, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidOutput
+	}
+	return v, nil
+}
+
+type spaceNameKey struct {
+	kind string
+	id   string
+}
+
+type spaceName struct {
+	name string
+	// expires is set for IDs that could not be resolved, after which they are looked up again
+	expires time.Time
+}
+
+// spaceNames caches the names of POSIX users and groups resolved by ID for the lifetime of the client, as lookups may
+// be expensive (ie - via LDAP), and are otherwise repeated for every row of every collection. IDs that cannot be
+// resolved are only cached for spaceNameRetryInterval, so that users and groups created later are resolved.
+type spaceNames struct {
+	names map[spaceNameKey]spaceName
+	now   func() time.Time
+	sync.Mutex
+}
+
+// resolve returns the name of POSIX users and groups that ZFS reported by ID, where they can be resolved.
+func (n *spaceNames) resolve(kind, name string) string {
+	if _, err := strconv.ParseUint(name, 10, 32); err != nil {
+		return name
+	}
+	if kind != spaceTypePOSIXUser && kind != spaceTypePOSIXGroup {
+		return name
+	}
+	key := spaceNameKey{kind: kind, id: name}
+	n.Lock()
+	defer n.Unlock()
+	now := n.now()
+	if cached, ok := n.names[key]; ok && (cached.expires.IsZero() || now.Before(cached.expires)) {
+		return cached.name
+	}
+	resolved, ok := resolveSpaceName(kind, name)
+	entry := spaceName{name: resolved}
+	if !ok {
+		entry.expires = now.Add(spaceNameRetryInterval)
+	}
+	n.names[key] = entry
+	return resolved
+}
+
+func newSpaceNames() *spaceNames {
+	return &spaceNames{names: make(map[spaceNameKey]spaceName), now: time.Now}
+}
+
+// resolveSpaceName looks up the name of a POSIX user or group ID, returning the ID and false if it cannot be resolved.
+func resolveSpaceName(kind, name string) (string, bool) {
+	var (
+		resolved string
+		err      error
+	)
+	switch kind {
+	case spaceTypePOSIXUser:
+		resolved, err = lookupUser(name)
+	case spaceTypePOSIXGroup:
+		resolved, err = lookupGroup(name)
+	default:
+		return name, false
+	}
+	if err != nil || resolved == `` {
+		return name, false
+	}
+	return resolved, true
+}
@@ -0,0 +1,89 @@
+package zfs
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSpaceHandler(t *testing.T) {
+	origUser, origGroup := lookupUser, lookupGroup
+	defer func() {
+		lookupUser, lookupGroup = origUser, origGroup
+	}()
+	lookups := 0
+	lookupUser = func(id string) (string, error) {
+		lookups++
+		if id == `1001` {
+			return `alice`, nil
+		}
+		return ``, errors.New(`unknown user`)
+	}
+	lookupGroup = func(id string) (string, error) {
+		if id == `1001` {
+			return `staff`, nil
+		}
+		return ``, errors.New(`unknown group`)
+	}
+
+	now := time.Unix(1660000000, 0)
+	names := newSpaceNames()
+	names.now = func() time.Time { return now }
+	handler := &spaceHandler{names: names}
+	for _, line := range [][]string{
+		{`POSIX User`, `root`, `1024`, `none`, `4`, `none`},
+		{`POSIX User`, `1001`, `2048`, `1073741824`, `8`, `1000`},
+		{`POSIX User`, `1002`, `512`, `none`, `1`, `none`},
+		{`POSIX User`, `1002`, `512`, `none`, `1`, `none`},
+		{`POSIX Group`, `1001`, `2560`, `none`, `9`, `-`},
+		{`SMB User`, `S-1-5-21-1004336348-1177238915-682003330-512`, `4096`, `none`, `2`, `none`},
+		{`Project`, `1001`, `4096`, `8192`, `2`, `none`},
+	} {
+		if err := handler.processLine(`testpool/home`, line); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := []Space{
+		{Type: `POSIX User`, Name: `root`, Used: 1024, ObjectsUsed: 4},
+		{Type: `POSIX User`, Name: `alice`, Used: 2048, Quota: 1073741824, ObjectsUsed: 8, ObjectsQuota: 1000},
+		{Type: `POSIX User`, Name: `1002`, Used: 512, ObjectsUsed: 1},
+		{Type: `POSIX User`, Name: `1002`, Used: 512, ObjectsUsed: 1},
+		{Type: `POSIX Group`, Name: `staff`, Used: 2560, ObjectsUsed: 9},
+		{Type: `SMB User`, Name: `S-1-5-21-1004336348-1177238915-682003330-512`, Used: 4096, ObjectsUsed: 2},
+		{Type: `Project`, Name: `1001`, Used: 4096, Quota: 8192, ObjectsUsed: 2},
+	}
+	if diff := cmp.Diff(handler.spaces, expected); diff != `` {
+		t.Fatalf("Parsed space is not equal to expected space: %s", diff)
+	}
+	// Each user ID is only looked up once, including those that cannot be resolved.
+	if lookups != 2 {
+		t.Fatalf("Expected 2 user lookups, got %d", lookups)
+	}
+
+	// IDs that could not be resolved are looked up again after the retry interval, resolved names are kept.
+	now = now.Add(spaceNameRetryInterval)
+	lookupUser = func(id string) (string, error) {
+		lookups++
+		return `user` + id, nil
+	}
+	for id, expected := range map[string]string{`1001`: `alice`, `1002`: `user1002`} {
+		if name := names.resolve(`POSIX User`, id); name != expected {
+			t.Fatalf("Expected %s to resolve to %s, got %s", id, expected, name)
+		}
+	}
+	if lookups != 3 {
+		t.Fatalf("Expected 3 user lookups, got %d", lookups)
+	}
+
+	for _, line := range [][]string{
+		{`POSIX User`, `root`, `1024`, `none`, `4`},
+		{`POSIX User`, `root`, `1K`, `none`, `4`, `none`},
+	} {
+		if err := handler.processLine(`testpool/home`, line); err != ErrInvalidOutput {
+			t.Fatalf("Expected error %v for %v, got %v", ErrInvalidOutput, line, err)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package zfs
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TxgState enum contains transaction group states
+type TxgState string
+
+const (
+	// TxgOpen enum entry
+	TxgOpen TxgState = `O`
+	// TxgQuiescing enum entry
+	TxgQuiescing TxgState = `Q`
+	// TxgWaiting enum entry
+	TxgWaiting TxgState = `W`
+	// TxgSyncing enum entry
+	TxgSyncing TxgState = `S`
+	// TxgCommitted enum entry
+	TxgCommitted TxgState = `C`
+)
+
+// Txg holds the history for a single transaction group
+type Txg struct {
+	Txg         uint64
+	Birth       time.Duration
+	State       TxgState
+	Dirty       uint64
+	Read        uint64
+	Written     uint64
+	Reads       uint64
+	Writes      uint64
+	OpenTime    time.Duration
+	QuiesceTime time.Duration
+	WaitTime    time.Duration
+	SyncTime    time.Duration
+}
+
+func readTxgs(root, pool string) ([]Txg, error) {
+	f, err := os.Open(filepath.Join(root, `zfs`, pool, `txgs`))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return parseTxgs(f)
+}
+
+// Example string to parse:
+//
+// 18 0 0x01 24 2688 9394863286 41830937478617
+// txg      birth            state ndirty       nread        nwritten     reads    writes   otime        qtime        wtime        stime
+// 7186218  41823437254301   C     41820160     0            18726912     0        374      5000150938   10596        64582        188713012
+// 7186219  41828437303045   O     0            0            0            0        0        0            0            0            0
+func parseTxgs(r io.Reader) ([]Txg, error) {
+	scanner := bufio.NewScanner(r)
+	// Skip the kstat header
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidOutput
+	}
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		// History is disabled when zfs_txg_history is zero.
+		return []Txg{}, nil
+	}
+	columns := make(map[string]int)
+	for i, name := range strings.Fields(scanner.Text()) {
+		columns[name] = i
+	}
+	for _, name := range []string{`txg`, `birth`, `state`, `ndirty`, `nread`, `nwritten`, `reads`, `writes`, `otime`, `qtime`, `wtime`, `stime`} {
+		if _, ok := columns[name]; !ok {
+			return nil, ErrInvalidOutput
+		}
+	}
+
+	result := make([]Txg, 0)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != len(columns) {
+			return nil, ErrInvalidOutput
+		}
+		values := make(map[string]uint64, len(columns))
+		for name, i := range columns {
+			if name == `state` {
+				continue
+			}
+			v, err := strconv.ParseUint(fields[i], 10, 64)
+			if err != nil {
+				return nil, ErrInvalidOutput
+			}
+			values[name] = v
+		}
+		result = append(result, Txg{
+			Txg:         values[`txg`],
+			Birth:       time.Duration(values[`birth`]),
+			State:       TxgState(fields[columns[`state`]]),
+			Dirty:       values[`ndirty`],
+			Read:        values[`nread`],
+			Written:     values[`nwritten`],
+			Reads:       values[`reads`],
+			Writes:      values[`writes`],
+			OpenTime:    time.Duration(values[`otime`]),
+			QuiesceTime: time.Duration(values[`qtime`]),
+			WaitTime:    time.Duration(values[`wtime`]),
+			SyncTime:    time.Duration(values[`stime`]),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
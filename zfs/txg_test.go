@@ -0,0 +1,87 @@
+package zfs
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTxgsRead(t *testing.T) {
+	client := New(Config{KstatRoot: `testdata/kstat`})
+	txgs, err := client.Txgs(context.Background(), `testpool`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(txgs) != 4 {
+		t.Fatalf("Expected exactly 4 txgs, got %d", len(txgs))
+	}
+
+	expected := Txg{
+		Txg:         7186217,
+		Birth:       41818437207114,
+		State:       TxgCommitted,
+		Dirty:       41820160,
+		Read:        4096,
+		Written:     18726912,
+		Reads:       1,
+		Writes:      374,
+		OpenTime:    5000150938 * time.Nanosecond,
+		QuiesceTime: 10596 * time.Nanosecond,
+		WaitTime:    64582 * time.Nanosecond,
+		SyncTime:    188713012 * time.Nanosecond,
+	}
+	if diff := cmp.Diff(txgs[1], expected); diff != `` {
+		t.Fatalf("Parsed txg is not equal to expected output: %s", diff)
+	}
+	if txgs[3].State != TxgOpen {
+		t.Fatalf("Expected last txg to be open, got %s", txgs[3].State)
+	}
+}
+
+func TestTxgsParse(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+		count int
+		err   error
+	}{
+		{
+			name:  `history disabled`,
+			input: "18 0 0x01 0 0 9394863286 41830937478617\n",
+			count: 0,
+		},
+		{
+			name: `missing columns`,
+			input: `18 0 0x01 1 112 9394863286 41830937478617
+txg      birth            state
+7186219  41828437303045   O
+`,
+			err: ErrInvalidOutput,
+		},
+		{
+			name: `truncated row`,
+			input: `18 0 0x01 1 112 9394863286 41830937478617
+txg      birth            state ndirty       nread        nwritten     reads    writes   otime        qtime        wtime        stime
+7186219  41828437303045   O     0            0
+`,
+			err: ErrInvalidOutput,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			txgs, err := parseTxgs(strings.NewReader(tc.input))
+			if err != tc.err {
+				t.Fatalf("Expected error %v, got %v", tc.err, err)
+			}
+			if len(txgs) != tc.count {
+				t.Fatalf("Expected exactly %d txgs, got %d", tc.count, len(txgs))
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package zfs
+
+import (
+	"bufio"
+	"context"
+	"strings"
+	"time"
+)
+
+// VdevClass enum contains the allocation class of a vdev
+type VdevClass string
+
+const (
+	// VdevClassNormal enum entry
+	VdevClassNormal VdevClass = `normal`
+	// VdevClassLog enum entry
+	VdevClassLog VdevClass = `log`
+	// VdevClassCache enum entry
+	VdevClassCache VdevClass = `cache`
+	// VdevClassSpecial enum entry
+	VdevClassSpecial VdevClass = `special`
+	// VdevClassDedup enum entry
+	VdevClassDedup VdevClass = `dedup`
+	// VdevClassSpare enum entry
+	VdevClassSpare VdevClass = `spare`
+)
+
+var (
+	// vdevClassSections maps the section headings in the config of `zpool status` to their allocation class
+	vdevClassSections = map[string]VdevClass{
+		`logs`:    VdevClassLog,
+		`cache`:   VdevClassCache,
+		`special`: VdevClassSpecial,
+		`dedup`:   VdevClassDedup,
+		`spares`:  VdevClassSpare,
+	}
+)
+
+// VdevTree holds the vdev configuration and scan status of a pool, as reported by `zpool status`
+type VdevTree struct {
+	Pool string
+	// Root is the root vdev of the pool, top-level vdevs of every allocation class are its children
+	Root Vdev
+	// Scan holds the status of the most recent scrub or resilver of the pool
+	Scan PoolScan
+	// ScanErr holds any error encountered parsing the scan status, in which case Scan is incomplete
+	ScanErr error
+}
+
+// Vdev holds the status of a single vdev and its children
+type Vdev struct {
+	Name           string
+	State          string
+	Class          VdevClass
+	ReadErrors     uint64
+	WriteErrors    uint64
+	ChecksumErrors uint64
+	// Annotation holds any trailing notes for the vdev (eg - `too many errors`, `was /dev/sdx`)
+	Annotation string
+	Children   []*Vdev
+	// Err holds any error encountered parsing the status of the vdev, in which case the error counts are unknown
+	Err error
+}
+
+// Walk calls fn for each descendant of the vdev, depth-first, along with its parent and depth relative to the vdev.
+func (v *Vdev) Walk(fn func(vdev, parent *Vdev, depth int)) {
+	v.walk(fn, 1)
+}
+
+func (v *Vdev) walk(fn func(vdev, parent *Vdev, depth int), depth int) {
+	for _, child := range v.Children {
+		fn(child, v, depth)
+		child.walk(fn, depth+1)
+	}
+}
+
+func vdevTrees(ctx context.Context, caps *capabilityProbe, pools ...string) ([]VdevTree, error) {
+	lines, err := poolStatus(ctx, caps, append([]string{`-L`}, pools...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseVdevTrees(lines, time.Local)
+}
+
+// poolStatus returns the lines output by `zpool status` with the provided arguments, requesting parsable numbers
+// where supported, otherwise human-readable numbers are output.
+func poolStatus(ctx context.Context, caps *capabilityProbe, args ...string) ([]string, error) {
+	c, err := caps.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cmdArgs := []string{`status`}
+	if c.ParsablePool {
+		cmdArgs = append(cmdArgs, `-p`)
+	}
+	lines := make([]string, 0)
+	cmd := newCommand(ctx, `zpool`, append(cmdArgs, args...)...)
+	defer cmd.close()
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(out)
+
+	if err = cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err = cmd.Wait(); err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+// Example string to parse, the config section is indented with a tab, and nesting with two spaces per level:
+//
+//	  pool: tank
+//	 state: DEGRADED
+//	config:
+//
+//		NAME             STATE     READ WRITE CKSUM
+//		tank             DEGRADED     0     0     0
+//		  mirror-0       DEGRADED     0     0     0
+//		    sda          ONLINE       0     0     0
+//		    replacing-1  DEGRADED     0     0     0
+//		      sdb        UNAVAIL      0     0     0  was /dev/sdb1
+//		      sdc        ONLINE       0     0     0
+//		logs
+//		  sdd            ONLINE       0     0     0
+//		cache
+//		  sde            ONLINE       0     0     0
+//		spares
+//		  sdf            AVAIL
+//
+//	errors: No known data errors
+func parseVdevTrees(lines []string, loc *time.Location) ([]VdevTree, error) {
+	result := make([]VdevTree, 0)
+	var (
+		current   *VdevTree
+		inConfig  bool
+		minIndent int
+		class     VdevClass
+		stack     []*Vdev
+		// starts holds the index of the first line of each pool, for parsing the scan status of each
+		starts []int
+	)
+	for i, line := range lines {
+		line = strings.ReplaceAll(line, "\t", `        `)
+		fields := strings.Fields(line)
+		indent := len(line) - len(strings.TrimLeft(line, ` `))
+
+		if !inConfig {
+			if len(fields) > 1 && fields[0] == `pool:` {
+				result = append(result, VdevTree{Pool: fields[1]})
+				current = &result[len(result)-1]
+				starts = append(starts, i)
+				continue
+			}
+			if current != nil && len(fields) >= 5 && fields[0] == `NAME` && fields[1] == `STATE` {
+				inConfig = true
+				minIndent = indent
+				class = VdevClassNormal
+				stack = nil
+			}
+			continue
+		}
+
+		// The config section ends at the first line outdented from the heading, or a blank line.
+		if len(fields) == 0 || indent < minIndent {
+			inConfig = false
+			continue
+		}
+
+		depth := (indent - minIndent) / 2
+		if depth == 0 {
+			if c, ok := vdevClassSections[fields[0]]; ok && len(fields) == 1 {
+				class = c
+				continue
+			}
+			current.Root = *parseVdev(fields, VdevClassNormal)
+			stack = []*Vdev{&current.Root}
+			continue
+		}
+
+		if len(stack) == 0 {
+			return nil, ErrInvalidOutput
+		}
+		// Unexpected indentation is attached to the deepest vdev, rather than discarding the whole tree.
+		if depth > len(stack) {
+			depth = len(stack)
+		}
+		vdev := parseVdev(fields, class)
+		parent := stack[depth-1]
+		parent.Children = append(parent.Children, vdev)
+		stack = append(stack[:depth], vdev)
+	}
+
+	for i := range result {
+		end := len(lines)
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		result[i].Scan, result[i].ScanErr = parsePoolScan(lines[starts[i]:end], loc)
+	}
+
+	return result, nil
+}
+
+// parseVdev parses a single row of the config section, spares only report a state, and any row may be followed by
+// an annotation. Error counts are exact when parsable output is available, and human-readable otherwise (eg - 1.2K).
+func parseVdev(fields []string, class VdevClass) *Vdev {
+	vdev := &Vdev{Name: fields[0], Class: class}
+	if len(fields) < 2 {
+		vdev.Err = ErrInvalidOutput
+		return vdev
+	}
+	vdev.State = fields[1]
+	if class == VdevClassSpare {
+		vdev.Annotation = strings.Join(fields[2:], ` `)
+		return vdev
+	}
+	if len(fields) < 5 {
+		vdev.Err = ErrInvalidOutput
+		return vdev
+	}
+
+	counts := make([]uint64, 3)
+	for i := range counts {
+		v, err := parseNiceNumber(fields[i+2])
+		if err != nil {
+			vdev.Err = err
+			return vdev
+		}
+		counts[i] = v
+	}
+	vdev.ReadErrors, vdev.WriteErrors, vdev.ChecksumErrors = counts[0], counts[1], counts[2]
+	vdev.Annotation = strings.Join(fields[5:], ` `)
+
+	return vdev
+}
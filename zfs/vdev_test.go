@@ -0,0 +1,292 @@
+package zfs
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestVdevTreesParse(t *testing.T) {
+	inputStr := `  pool: ssd_tank
+ state: ONLINE
+  scan: scrub repaired 0B in 02:44:52 with 0 errors on Sun Aug 14 03:08:54 2022
+config:
+
+	NAME        STATE     READ WRITE CKSUM
+	ssd_tank    ONLINE       0    13    26
+	  mirror-0  ONLINE       1    14    27
+	    sdc     ONLINE       2    15    28
+	    sda     ONLINE       3    16    29
+	  mirror-1  ONLINE       4    17    30
+	    sdh     ONLINE       5    18    31
+	    sdd     ONLINE       6    19    32
+	spares
+	  sdj       AVAIL
+
+errors: No known data errors
+`
+	trees, err := parseVdevTrees(strings.Split(inputStr, "\n"), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedOutput := []VdevTree{
+		{
+			Pool: `ssd_tank`,
+			Root: Vdev{
+				Name: `ssd_tank`, State: `ONLINE`, Class: VdevClassNormal, WriteErrors: 13, ChecksumErrors: 26,
+				Children: []*Vdev{
+					{
+						Name: `mirror-0`, State: `ONLINE`, Class: VdevClassNormal, ReadErrors: 1, WriteErrors: 14, ChecksumErrors: 27,
+						Children: []*Vdev{
+							{Name: `sdc`, State: `ONLINE`, Class: VdevClassNormal, ReadErrors: 2, WriteErrors: 15, ChecksumErrors: 28},
+							{Name: `sda`, State: `ONLINE`, Class: VdevClassNormal, ReadErrors: 3, WriteErrors: 16, ChecksumErrors: 29},
+						},
+					},
+					{
+						Name: `mirror-1`, State: `ONLINE`, Class: VdevClassNormal, ReadErrors: 4, WriteErrors: 17, ChecksumErrors: 30,
+						Children: []*Vdev{
+							{Name: `sdh`, State: `ONLINE`, Class: VdevClassNormal, ReadErrors: 5, WriteErrors: 18, ChecksumErrors: 31},
+							{Name: `sdd`, State: `ONLINE`, Class: VdevClassNormal, ReadErrors: 6, WriteErrors: 19, ChecksumErrors: 32},
+						},
+					},
+					{Name: `sdj`, State: `AVAIL`, Class: VdevClassSpare},
+				},
+			},
+			Scan: PoolScan{
+				Function: ScanScrub,
+				State:    ScanStateFinished,
+				Start:    time.Date(2022, time.August, 14, 0, 24, 2, 0, time.UTC),
+				End:      time.Date(2022, time.August, 14, 3, 8, 54, 0, time.UTC),
+				Progress: 1,
+			},
+		},
+	}
+
+	if diff := cmp.Diff(trees, expectedOutput); diff != `` {
+		t.Fatalf("Parsed vdev trees are not equal to expected output: %s", diff)
+	}
+}
+
+func TestVdevTreesParseClasses(t *testing.T) {
+	inputStr := `  pool: tank
+ state: DEGRADED
+status: One or more devices could not be used because the label is missing or
+	invalid.  Sufficient replicas exist for the pool to continue
+	functioning in a degraded state.
+config:
+
+	NAME                 STATE     READ WRITE CKSUM
+	tank                 DEGRADED     0     0     0
+	  raidz1-0           DEGRADED     0     0     0
+	    sda              ONLINE       0     0     0
+	    replacing-1      DEGRADED     0     0     0
+	      sdb            UNAVAIL      0     0     0  was /dev/sdb1
+	      sdc            ONLINE       0     0     0  (resilvering)
+	    spare-2          ONLINE       0     0     0
+	      sdd            FAULTED      3     0     0  too many errors
+	      sdk            ONLINE       0     0     0
+	special
+	  mirror-1           ONLINE       0     0     0
+	    nvme0n1          ONLINE       0     0     0
+	    nvme1n1          ONLINE       0     0     0
+	dedup
+	  nvme2n1            ONLINE       0     0     0
+	logs
+	  sde                ONLINE       0     0     0
+	cache
+	  sdf                ONLINE       0     0     0
+	spares
+	  sdk                INUSE     currently in use
+
+errors: No known data errors
+`
+	trees, err := parseVdevTrees(strings.Split(inputStr, "\n"), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedOutput := []VdevTree{
+		{
+			Pool: `tank`,
+			Root: Vdev{
+				Name: `tank`, State: `DEGRADED`, Class: VdevClassNormal,
+				Children: []*Vdev{
+					{
+						Name: `raidz1-0`, State: `DEGRADED`, Class: VdevClassNormal,
+						Children: []*Vdev{
+							{Name: `sda`, State: `ONLINE`, Class: VdevClassNormal},
+							{
+								Name: `replacing-1`, State: `DEGRADED`, Class: VdevClassNormal,
+								Children: []*Vdev{
+									{Name: `sdb`, State: `UNAVAIL`, Class: VdevClassNormal, Annotation: `was /dev/sdb1`},
+									{Name: `sdc`, State: `ONLINE`, Class: VdevClassNormal, Annotation: `(resilvering)`},
+								},
+							},
+							{
+								Name: `spare-2`, State: `ONLINE`, Class: VdevClassNormal,
+								Children: []*Vdev{
+									{Name: `sdd`, State: `FAULTED`, Class: VdevClassNormal, ReadErrors: 3, Annotation: `too many errors`},
+									{Name: `sdk`, State: `ONLINE`, Class: VdevClassNormal},
+								},
+							},
+						},
+					},
+					{
+						Name: `mirror-1`, State: `ONLINE`, Class: VdevClassSpecial,
+						Children: []*Vdev{
+							{Name: `nvme0n1`, State: `ONLINE`, Class: VdevClassSpecial},
+							{Name: `nvme1n1`, State: `ONLINE`, Class: VdevClassSpecial},
+						},
+					},
+					{Name: `nvme2n1`, State: `ONLINE`, Class: VdevClassDedup},
+					{Name: `sde`, State: `ONLINE`, Class: VdevClassLog},
+					{Name: `sdf`, State: `ONLINE`, Class: VdevClassCache},
+					{Name: `sdk`, State: `INUSE`, Class: VdevClassSpare, Annotation: `currently in use`},
+				},
+			},
+			Scan: PoolScan{Function: ScanNone, State: ScanStateNone},
+		},
+	}
+
+	if diff := cmp.Diff(trees, expectedOutput); diff != `` {
+		t.Fatalf("Parsed vdev trees are not equal to expected output: %s", diff)
+	}
+}
+
+func TestVdevTreesParseMultiplePools(t *testing.T) {
+	inputStr := `  pool: bpool
+ state: ONLINE
+  scan: none requested
+config:
+
+	NAME        STATE     READ WRITE CKSUM
+	bpool       ONLINE       0     0     0
+	  sda       ONLINE       0     0     1
+
+errors: No known data errors
+
+  pool: rpool
+ state: ONLINE
+  scan: scrub repaired 0B in 00:10:01 with 0 errors on Sun Aug 14 03:08:54 2022
+config:
+
+	NAME        STATE     READ WRITE CKSUM
+	rpool       ONLINE       0     0     0
+	  mirror-0  ONLINE       0     2     0
+	    sdc     ONLINE       0     2     0
+	    sdd     ONLINE       0     0     0
+
+errors: No known data errors
+`
+	trees, err := parseVdevTrees(strings.Split(inputStr, "\n"), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedOutput := []VdevTree{
+		{
+			Pool: `bpool`,
+			Root: Vdev{
+				Name: `bpool`, State: `ONLINE`, Class: VdevClassNormal,
+				Children: []*Vdev{
+					{Name: `sda`, State: `ONLINE`, Class: VdevClassNormal, ChecksumErrors: 1},
+				},
+			},
+			Scan: PoolScan{Function: ScanNone, State: ScanStateNone},
+		},
+		{
+			Pool: `rpool`,
+			Root: Vdev{
+				Name: `rpool`, State: `ONLINE`, Class: VdevClassNormal,
+				Children: []*Vdev{
+					{
+						Name: `mirror-0`, State: `ONLINE`, Class: VdevClassNormal, WriteErrors: 2,
+						Children: []*Vdev{
+							{Name: `sdc`, State: `ONLINE`, Class: VdevClassNormal, WriteErrors: 2},
+							{Name: `sdd`, State: `ONLINE`, Class: VdevClassNormal},
+						},
+					},
+				},
+			},
+			Scan: PoolScan{
+				Function: ScanScrub,
+				State:    ScanStateFinished,
+				Start:    time.Date(2022, time.August, 14, 2, 58, 53, 0, time.UTC),
+				End:      time.Date(2022, time.August, 14, 3, 8, 54, 0, time.UTC),
+				Progress: 1,
+			},
+		},
+	}
+
+	if diff := cmp.Diff(trees, expectedOutput); diff != `` {
+		t.Fatalf("Parsed vdev trees are not equal to expected output: %s", diff)
+	}
+}
+
+func TestVdevWalk(t *testing.T) {
+	root := Vdev{
+		Name: `tank`,
+		Children: []*Vdev{
+			{Name: `mirror-0`, Children: []*Vdev{{Name: `sda`}, {Name: `sdb`}}},
+			{Name: `sdc`},
+		},
+	}
+
+	visited := make([]string, 0)
+	root.Walk(func(vdev, parent *Vdev, depth int) {
+		visited = append(visited, strings.Join([]string{parent.Name, vdev.Name, strings.Repeat(`+`, depth)}, `/`))
+	})
+
+	expected := []string{`tank/mirror-0/+`, `mirror-0/sda/++`, `mirror-0/sdb/++`, `tank/sdc/+`}
+	if diff := cmp.Diff(visited, expected); diff != `` {
+		t.Fatalf("Walked vdevs are not equal to expected vdevs: %s", diff)
+	}
+}
+
+func TestVdevTreesParseCounts(t *testing.T) {
+	inputStr := `  pool: tank
+ state: DEGRADED
+config:
+
+	NAME        STATE     READ WRITE CKSUM
+	tank        DEGRADED     0     0     0
+	  mirror-0  DEGRADED     0     0     0
+	    sda     FAULTED  1.50K     0    12  too many errors
+	    sdb     ONLINE       0   bad     0
+	    sdc     ONLINE    1234     0     0
+
+errors: No known data errors
+`
+	trees, err := parseVdevTrees(strings.Split(inputStr, "\n"), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedOutput := []VdevTree{
+		{
+			Pool: `tank`,
+			Root: Vdev{
+				Name: `tank`, State: `DEGRADED`, Class: VdevClassNormal,
+				Children: []*Vdev{
+					{
+						Name: `mirror-0`, State: `DEGRADED`, Class: VdevClassNormal,
+						Children: []*Vdev{
+							{Name: `sda`, State: `FAULTED`, Class: VdevClassNormal, ReadErrors: 1536, ChecksumErrors: 12, Annotation: `too many errors`},
+							{Name: `sdb`, State: `ONLINE`, Class: VdevClassNormal, Err: ErrInvalidOutput},
+							{Name: `sdc`, State: `ONLINE`, Class: VdevClassNormal, ReadErrors: 1234},
+						},
+					},
+				},
+			},
+			Scan: PoolScan{Function: ScanNone, State: ScanStateNone},
+		},
+	}
+
+	if diff := cmp.Diff(trees, expectedOutput, cmpopts.EquateErrors()); diff != `` {
+		t.Fatalf("Parsed vdev trees are not equal to expected output: %s", diff)
+	}
+}
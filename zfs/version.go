@@ -0,0 +1,204 @@
+package zfs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	versionUserlandPrefix = `zfs-`
+	versionKernelPrefix   = `zfs-kmod-`
+)
+
+var (
+	versionReleaseRegexp = regexp.MustCompile(`^(\d+)\.(\d+)(?:\.(\d+))?`)
+
+	// Releases that introduced each capability
+	versionBookmarkType = release{0, 6, 4}
+	versionParsablePool = release{0, 7, 0}
+	versionJSON         = release{2, 3, 0}
+
+	// defaultCapabilities are assumed if neither version is known, which are those the commands relied on prior to
+	// capability detection.
+	defaultCapabilities = Capabilities{BookmarkType: true, ParsablePool: true}
+)
+
+// Version holds the versions of the OpenZFS userland tools and kernel module (eg - 2.2.2-0ubuntu9), either may be
+// empty if unknown
+type Version struct {
+	Userland string
+	Kernel   string
+}
+
+// Mismatch returns true if the userland tools and kernel module are known to be different releases. Packaging
+// suffixes are ignored, as they commonly differ between the userland and kernel module packages of a release.
+func (v Version) Mismatch() bool {
+	userland, ok := parseRelease(v.Userland)
+	if !ok {
+		return false
+	}
+	kernel, ok := parseRelease(v.Kernel)
+	if !ok {
+		return false
+	}
+	return userland != kernel
+}
+
+// Capabilities returns the features supported by the userland tools, falling back to the kernel module version if
+// the userland version is unknown. If neither version is known, all capabilities except JSON output are assumed.
+func (v Version) Capabilities() Capabilities {
+	r, ok := parseRelease(v.Userland)
+	if !ok {
+		if r, ok = parseRelease(v.Kernel); !ok {
+			return defaultCapabilities
+		}
+	}
+	return Capabilities{
+		BookmarkType: !r.less(versionBookmarkType),
+		ParsablePool: !r.less(versionParsablePool),
+		JSON:         !r.less(versionJSON),
+	}
+}
+
+// Capabilities holds the version-dependent features of the zfs/zpool CLI, used to select command variants
+type Capabilities struct {
+	// BookmarkType indicates support for the bookmark type in `zfs get -t`, from ZFS on Linux 0.6.4
+	BookmarkType bool
+	// ParsablePool indicates support for exact numbers via `zpool get -p` and `zpool status -p`, from ZFS on Linux 0.7
+	ParsablePool bool
+	// JSON indicates support for JSON output via `-j`, from OpenZFS 2.3
+	JSON bool
+}
+
+// capabilityProbe detects the capabilities of the installed version on first use, and caches them for the lifetime of
+// the client, since the version cannot change without restarting the exporter.
+type capabilityProbe struct {
+	moduleRoot string
+	detected   *Capabilities
+	sync.Mutex
+}
+
+// get returns the detected capabilities, or the context error if detection was interrupted, in which case it will be
+// retried on the next call.
+func (p *capabilityProbe) get(ctx context.Context) (Capabilities, error) {
+	p.Lock()
+	defer p.Unlock()
+	if p.detected != nil {
+		return *p.detected, nil
+	}
+
+	version, err := readVersion(ctx, p.moduleRoot)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return Capabilities{}, ctxErr
+	}
+	capabilities := defaultCapabilities
+	if err == nil {
+		capabilities = version.Capabilities()
+	}
+	p.detected = &capabilities
+
+	return capabilities, nil
+}
+
+func newCapabilityProbe(moduleRoot string) *capabilityProbe {
+	return &capabilityProbe{moduleRoot: moduleRoot}
+}
+
+// release holds the numeric major, minor and patch version of a release
+type release [3]int
+
+func (r release) less(other release) bool {
+	for i := range r {
+		if r[i] != other[i] {
+			return r[i] < other[i]
+		}
+	}
+	return false
+}
+
+func parseRelease(version string) (release, bool) {
+	matches := versionReleaseRegexp.FindStringSubmatch(version)
+	if matches == nil {
+		return release{}, false
+	}
+	var r release
+	for i, m := range matches[1:] {
+		if m == `` {
+			continue
+		}
+		v, err := strconv.Atoi(m)
+		if err != nil {
+			return release{}, false
+		}
+		r[i] = v
+	}
+	return r, true
+}
+
+// readVersion reports the userland version from `zfs version`, and the kernel module version from sysfs where
+// available (ie - Linux), otherwise from `zfs version`. Releases prior to OpenZFS 0.8 do not support `zfs version`, in
+// which case only the kernel module version may be known.
+func readVersion(ctx context.Context, moduleRoot string) (Version, error) {
+	cmd := newCommand(ctx, `zfs`, `version`)
+	defer cmd.close()
+	stdout := &bytes.Buffer{}
+	cmd.Stdout = stdout
+
+	var (
+		version Version
+		cmdErr  error
+	)
+	if cmdErr = cmd.Start(); cmdErr == nil {
+		cmdErr = cmd.Wait()
+	}
+	if err := ctx.Err(); err != nil {
+		return Version{}, err
+	}
+	if cmdErr == nil {
+		version = parseVersion(stdout)
+	}
+
+	if b, err := os.ReadFile(filepath.Join(moduleRoot, `version`)); err == nil {
+		if kernel := strings.TrimSpace(string(b)); kernel != `` {
+			version.Kernel = kernel
+		}
+	}
+
+	if version.Userland == `` && version.Kernel == `` {
+		if cmdErr == nil {
+			cmdErr = ErrInvalidOutput
+		}
+		return Version{}, fmt.Errorf("unable to determine ZFS version: %w", cmdErr)
+	}
+
+	return version, nil
+}
+
+// Example string to parse:
+//
+//	zfs-2.2.2-0ubuntu9
+//	zfs-kmod-2.2.2-0ubuntu9
+func parseVersion(r io.Reader) Version {
+	version := Version{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, versionKernelPrefix):
+			version.Kernel = strings.TrimPrefix(line, versionKernelPrefix)
+		case strings.HasPrefix(line, versionUserlandPrefix):
+			version.Userland = strings.TrimPrefix(line, versionUserlandPrefix)
+		}
+	}
+
+	return version
+}
@@ -0,0 +1,97 @@
+package zfs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseVersion(t *testing.T) {
+	testCases := []struct {
+		name     string
+		output   string
+		expected Version
+	}{
+		{
+			name:     `linux`,
+			output:   "zfs-2.2.2-0ubuntu9\nzfs-kmod-2.2.2-0ubuntu9.1\n",
+			expected: Version{Userland: `2.2.2-0ubuntu9`, Kernel: `2.2.2-0ubuntu9.1`},
+		},
+		{
+			name:     `freebsd`,
+			output:   "zfs-2.1.4-FreeBSD_g52bad4f23\nzfs-kmod-2.1.4-FreeBSD_g52bad4f23\n",
+			expected: Version{Userland: `2.1.4-FreeBSD_g52bad4f23`, Kernel: `2.1.4-FreeBSD_g52bad4f23`},
+		},
+		{
+			name:     `module not loaded`,
+			output:   "zfs-2.3.0-1\n",
+			expected: Version{Userland: `2.3.0-1`},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			version := parseVersion(strings.NewReader(tc.output))
+			if diff := cmp.Diff(version, tc.expected); diff != `` {
+				t.Fatalf("Parsed version is not equal to expected version: %s", diff)
+			}
+		})
+	}
+}
+
+func TestVersionCapabilities(t *testing.T) {
+	testCases := []struct {
+		name         string
+		version      Version
+		mismatch     bool
+		capabilities Capabilities
+	}{
+		{
+			name:         `unknown`,
+			version:      Version{},
+			capabilities: Capabilities{BookmarkType: true, ParsablePool: true},
+		},
+		{
+			name:         `zfs on linux 0.6`,
+			version:      Version{Kernel: `0.6.5.11-1`},
+			capabilities: Capabilities{BookmarkType: true},
+		},
+		{
+			name:         `zfs on linux 0.7`,
+			version:      Version{Kernel: `0.7.13-1`},
+			capabilities: Capabilities{BookmarkType: true, ParsablePool: true},
+		},
+		{
+			name:         `packaging mismatch`,
+			version:      Version{Userland: `2.2.2-0ubuntu9`, Kernel: `2.2.2-0ubuntu9.1`},
+			capabilities: Capabilities{BookmarkType: true, ParsablePool: true},
+		},
+		{
+			name:         `release mismatch`,
+			version:      Version{Userland: `2.3.1-1`, Kernel: `2.2.7-1`},
+			mismatch:     true,
+			capabilities: Capabilities{BookmarkType: true, ParsablePool: true, JSON: true},
+		},
+		{
+			name:         `release candidate`,
+			version:      Version{Userland: `2.3.0-rc5`, Kernel: `2.3.0-rc5`},
+			capabilities: Capabilities{BookmarkType: true, ParsablePool: true, JSON: true},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if mismatch := tc.version.Mismatch(); mismatch != tc.mismatch {
+				t.Fatalf("Expected mismatch %t, got %t", tc.mismatch, mismatch)
+			}
+			if diff := cmp.Diff(tc.version.Capabilities(), tc.capabilities); diff != `` {
+				t.Fatalf("Capabilities are not equal to expected capabilities: %s", diff)
+			}
+		})
+	}
+}
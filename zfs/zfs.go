@@ -1,10 +1,13 @@
 package zfs
 
 import (
+	"context"
 	"encoding/csv"
 	"errors"
 	"io"
-	"os/exec"
+	"math"
+	"strconv"
+	"strings"
 )
 
 var (
@@ -14,27 +17,38 @@ var (
 
 // Client is the primary entrypoint
 type Client interface {
-	PoolNames() ([]string, error)
+	PoolNames(ctx context.Context) ([]string, error)
 	Pool(name string) Pool
-	PoolDisks() ([]PoolDisk, error)
+	VdevTrees(ctx context.Context, pools ...string) ([]VdevTree, error)
 	Datasets(pool string, kind DatasetKind) Datasets
+	Kstat(ctx context.Context, module, name string) (Kstat, error)
+	ObjsetKstats(ctx context.Context, pool string) ([]Kstat, error)
+	Txgs(ctx context.Context, pool string) ([]Txg, error)
+	SendSize(ctx context.Context, from, to string) (uint64, error)
+	UserSpace(ctx context.Context, dataset string) ([]Space, error)
+	GroupSpace(ctx context.Context, dataset string) ([]Space, error)
+	ProjectSpace(ctx context.Context, dataset string) ([]Space, error)
+	Events(ctx context.Context, handler func(Event)) error
+	ModuleParameters(ctx context.Context) ([]ModuleParameter, error)
+	Version(ctx context.Context) (Version, error)
 }
 
-type PoolDisk struct {
-	Zpool          string
-	Vdev           string
-	Name           string
-	Kind           string
-	State          string
-	ReadErrors     int
-	WriteErrors    int
-	ChecksumErrors int
+// Config configures a ZFS Client
+type Config struct {
+	// KstatRoot is the root of the kstat tree, defaults to DefaultKstatRoot
+	KstatRoot string
+	// ModuleRoot is the sysfs directory of the ZFS kernel module, defaults to DefaultModuleRoot
+	ModuleRoot string
+	// Backend selects the output format of the zfs/zpool CLI, defaults to BackendAuto
+	Backend Backend
 }
 
 // Pool allows querying pool properties
 type Pool interface {
 	Name() string
-	Properties(props ...string) (PoolProperties, error)
+	Properties(ctx context.Context, props ...string) (PoolProperties, error)
+	LatencyHistograms(ctx context.Context, vdevs bool) ([]LatencyHistogram, error)
+	Dedup(ctx context.Context) (DedupStats, error)
 }
 
 // PoolProperties provides access to the properties for a pool
@@ -46,7 +60,8 @@ type PoolProperties interface {
 type Datasets interface {
 	Pool() string
 	Kind() DatasetKind
-	Properties(props ...string) ([]DatasetProperties, error)
+	Properties(ctx context.Context, props ...string) ([]DatasetProperties, error)
+	List(ctx context.Context, props ...string) ([]DatasetProperties, error)
 }
 
 // DatasetProperties provides access to the properties for a dataset
@@ -60,26 +75,83 @@ type handler interface {
 }
 
 type clientImpl struct {
+	kstatRoot  string
+	moduleRoot string
+	caps       *capabilityProbe
+	names      *spaceNames
 }
 
-func (z clientImpl) PoolNames() ([]string, error) {
-	return poolNames()
+func (z clientImpl) PoolNames(ctx context.Context) ([]string, error) {
+	return poolNames(ctx)
 }
 
 func (z clientImpl) Pool(name string) Pool {
-	return newPoolImpl(name)
+	return newPoolImpl(name, z.caps)
 }
 
 func (z clientImpl) Datasets(pool string, kind DatasetKind) Datasets {
-	return newDatasetsImpl(pool, kind)
+	return newDatasetsImpl(pool, kind, z.caps)
 }
 
-func (z clientImpl) PoolDisks() ([]PoolDisk, error) {
-	return poolDisks()
+func (z clientImpl) VdevTrees(ctx context.Context, pools ...string) ([]VdevTree, error) {
+	return vdevTrees(ctx, z.caps, pools...)
 }
 
-func execute(pool string, h handler, cmd string, args ...string) error {
-	c := exec.Command(cmd, append(args, pool)...)
+func (z clientImpl) Kstat(ctx context.Context, module, name string) (Kstat, error) {
+	if err := ctx.Err(); err != nil {
+		return Kstat{}, err
+	}
+	return readKstat(z.kstatRoot, module, name)
+}
+
+func (z clientImpl) ObjsetKstats(ctx context.Context, pool string) ([]Kstat, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return readObjsetKstats(z.kstatRoot, pool)
+}
+
+func (z clientImpl) Txgs(ctx context.Context, pool string) ([]Txg, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return readTxgs(z.kstatRoot, pool)
+}
+
+func (z clientImpl) SendSize(ctx context.Context, from, to string) (uint64, error) {
+	return sendSize(ctx, from, to)
+}
+
+func (z clientImpl) UserSpace(ctx context.Context, dataset string) ([]Space, error) {
+	return space(ctx, z.names, SpaceUser, dataset)
+}
+
+func (z clientImpl) GroupSpace(ctx context.Context, dataset string) ([]Space, error) {
+	return space(ctx, z.names, SpaceGroup, dataset)
+}
+
+func (z clientImpl) ProjectSpace(ctx context.Context, dataset string) ([]Space, error) {
+	return space(ctx, z.names, SpaceProject, dataset)
+}
+
+func (z clientImpl) Events(ctx context.Context, handler func(Event)) error {
+	return followEvents(ctx, handler)
+}
+
+func (z clientImpl) ModuleParameters(ctx context.Context) ([]ModuleParameter, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return readModuleParameters(z.moduleRoot)
+}
+
+func (z clientImpl) Version(ctx context.Context) (Version, error) {
+	return readVersion(ctx, z.moduleRoot)
+}
+
+func execute(ctx context.Context, pool string, h handler, cmd string, args ...string) error {
+	c := newCommand(ctx, cmd, append(args, pool)...)
+	defer c.close()
 	out, err := c.StdoutPipe()
 	if err != nil {
 		return err
@@ -89,7 +161,7 @@ func execute(pool string, h handler, cmd string, args ...string) error {
 	r.Comma = '\t'
 	r.LazyQuotes = true
 	r.ReuseRecord = true
-	r.FieldsPerRecord = 3
+	r.FieldsPerRecord = -1
 
 	if err = c.Start(); err != nil {
 		return err
@@ -111,7 +183,53 @@ func execute(pool string, h handler, cmd string, args ...string) error {
 	return c.Wait()
 }
 
+// parseNiceNumber parses the human-readable numbers output by ZFS tools (eg - 0B, 512, 1.23G), using base-2
+// multipliers.
+func parseNiceNumber(value string) (uint64, error) {
+	value = strings.TrimSuffix(value, `B`)
+	if value == `` || value == `-` {
+		return 0, ErrInvalidOutput
+	}
+	exponent := strings.IndexByte(`KMGTPE`, value[len(value)-1]) + 1
+	if exponent > 0 {
+		value = value[:len(value)-1]
+	}
+	if exponent == 0 {
+		if v, err := strconv.ParseUint(value, 10, 64); err == nil {
+			return v, nil
+		}
+	}
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil || v < 0 {
+		return 0, ErrInvalidOutput
+	}
+	v *= math.Pow(1024, float64(exponent))
+	if v >= math.MaxUint64 {
+		return 0, ErrInvalidOutput
+	}
+	return uint64(math.Round(v)), nil
+}
+
 // New instantiates a ZFS Client
-func New() Client {
-	return clientImpl{}
+func New(config Config) Client {
+	if config.KstatRoot == `` {
+		config.KstatRoot = DefaultKstatRoot
+	}
+	if config.ModuleRoot == `` {
+		config.ModuleRoot = DefaultModuleRoot
+	}
+	text := clientImpl{
+		kstatRoot:  config.KstatRoot,
+		moduleRoot: config.ModuleRoot,
+		caps:       newCapabilityProbe(config.ModuleRoot),
+		names:      newSpaceNames(),
+	}
+	switch config.Backend {
+	case BackendText:
+		return text
+	case BackendJSON:
+		return jsonClient{clientImpl: text}
+	default:
+		return &autoClient{text: text, json: jsonClient{clientImpl: text}, caps: text.caps}
+	}
 }
@@ -1,9 +1,13 @@
 package main
 
 import (
+	"context"
 	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/pdf/zfs_exporter/v2/collector"
 	"github.com/pdf/zfs_exporter/v2/zfs"
@@ -23,8 +27,12 @@ func main() {
 		metricsPath             = kingpin.Flag("web.telemetry-path", "Path under which to expose metrics.").Default("/metrics").String()
 		metricsExporterDisabled = kingpin.Flag(`web.disable-exporter-metrics`, `Exclude metrics about the exporter itself (promhttp_*, process_*, go_*).`).Default(`false`).Bool()
 		deadline                = kingpin.Flag("deadline", "Maximum duration that a collection should run before returning cached data. Should be set to a value shorter than your scrape timeout duration. The current collection run will continue and update the cache when complete (default: 8s)").Default("8s").Duration()
+		maxBackgroundRun        = kingpin.Flag("max-background-run", "Maximum duration that a collection may continue running in the background after exceeding the deadline, after which any running zfs/zpool commands are killed. Set to 0 to kill commands as soon as the deadline is exceeded (default: 1m)").Default("1m").Duration()
 		pools                   = kingpin.Flag("pool", "Name of the pool(s) to collect, repeat for multiple pools (default: all pools).").Strings()
 		excludes                = kingpin.Flag("exclude", "Exclude datasets/snapshots/volumes that match the provided regex (e.g. '^rpool/docker/'), may be specified multiple times.").Strings()
+		kstatRoot               = kingpin.Flag("kstat-root", "Root path of the SPL kstat tree.").Default(zfs.DefaultKstatRoot).String()
+		moduleRoot              = kingpin.Flag("module-root", "Sysfs path of the ZFS kernel module.").Default(zfs.DefaultModuleRoot).String()
+		backend                 = kingpin.Flag("backend", "Output format to request from the zfs/zpool CLI, one of [auto, text, json]. The json backend requires OpenZFS 2.3 or later, auto uses it when available.").Default(string(zfs.BackendAuto)).Enum(string(zfs.BackendAuto), string(zfs.BackendText), string(zfs.BackendJSON))
 	)
 
 	promlogConfig := &promlog.Config{}
@@ -37,13 +45,18 @@ func main() {
 	_ = level.Info(logger).Log("msg", "Starting zfs_exporter", "version", version.Info())
 	_ = level.Info(logger).Log("msg", "Build context", "context", version.BuildContext())
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	c, err := collector.NewZFS(collector.ZFSConfig{
-		DisableMetrics: *metricsExporterDisabled,
-		Deadline:       *deadline,
-		Pools:          *pools,
-		Excludes:       *excludes,
-		Logger:         logger,
-		ZFSClient:      zfs.New(),
+		Context:          ctx,
+		DisableMetrics:   *metricsExporterDisabled,
+		Deadline:         *deadline,
+		MaxBackgroundRun: *maxBackgroundRun,
+		Pools:            *pools,
+		Excludes:         *excludes,
+		Logger:           logger,
+		ZFSClient:        zfs.New(zfs.Config{KstatRoot: *kstatRoot, ModuleRoot: *moduleRoot, Backend: zfs.Backend(*backend)}),
 	})
 	if err != nil {
 		_ = level.Error(logger).Log("msg", "Error creating an exporter", "err", err)
@@ -86,9 +99,20 @@ func main() {
 		}
 	})
 
+	server := &http.Server{Addr: *listenAddress}
+	go func() {
+		<-ctx.Done()
+		_ = level.Info(logger).Log("msg", "Shutting down")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			_ = level.Error(logger).Log("msg", "Error shutting down HTTP server", "err", err)
+		}
+	}()
+
 	_ = level.Info(logger).Log("msg", "Listening on address", "address", *listenAddress)
-	err = http.ListenAndServe(*listenAddress, nil)
-	if err != nil {
+	err = server.ListenAndServe()
+	if err != nil && err != http.ErrServerClosed {
 		_ = level.Error(logger).Log("msg", "Error starting HTTP server", "err", err)
 		os.Exit(1)
 	}